@@ -4,45 +4,59 @@ import (
 	"context"
 	"fmt"
 	"github.com/airchains-network/tracks/config"
-	"github.com/ethereum/go-ethereum/ethclient"
+	logs "github.com/airchains-network/tracks/log"
+	"github.com/airchains-network/tracks/store"
 	"github.com/spf13/viper"
-	"github.com/syndtr/goleveldb/leveldb"
 	"os"
-	"path/filepath"
 	"sync"
+	"sync/atomic"
 )
 
-func StartIndexer(wg *sync.WaitGroup, client *ethclient.Client, ctx context.Context, blockDatabaseConnection *leveldb.DB, txnDatabaseConnection *leveldb.DB, latestBlock int) {
-	wg.Done()
+var syncerInstance atomic.Pointer[Syncer]
+
+// StartIndexer indexes the configured station with the StationIndexer registered for its
+// station type, resuming after the recorded indexer progress, or from latestBlock for stations
+// indexed before progress was recorded. It returns once ctx is cancelled and the block being
+// written has been persisted. committedTxns bounds how far a station reorganisation may be
+// unwound. Station calls fail over between the configured station endpoints, which are health
+// checked while the indexer runs.
+func StartIndexer(wg *sync.WaitGroup, ctx context.Context, blockDatabaseConnection store.Store, txnDatabaseConnection store.Store, latestBlock int, committedTxns func() (int, error)) {
+	defer wg.Done()
 	bsgConfig, err := LoadConfig()
 	if err != nil {
-		fmt.Println(err)
+		logs.Log.Error(fmt.Sprintf("Error in loading config : %s", err.Error()))
+		return
 	}
 
-	if bsgConfig.Station.StationType == "EVM" || bsgConfig.Station.StationType == "evm" {
-		StoreEVMBlock(client, ctx, latestBlock, blockDatabaseConnection, txnDatabaseConnection)
-	} else if bsgConfig.Station.StationType == "WASM" || bsgConfig.Station.StationType == "wasm" {
-		JsonRPC := bsgConfig.Station.StationRPC
-		JsonAPI := bsgConfig.Station.StationAPI
-		StoreWasmBlock(blockDatabaseConnection, txnDatabaseConnection, JsonRPC, JsonAPI)
-	} else if bsgConfig.Station.StationType == "SVM" || bsgConfig.Station.StationType == "svm" {
-		JsonRPC := bsgConfig.Station.StationRPC
-		JsonAPI := bsgConfig.Station.StationAPI
-		StoreSVMBlock(blockDatabaseConnection, txnDatabaseConnection, JsonRPC, JsonAPI)
+	indexer, err := NewStationIndexer(bsgConfig.Station)
+	if err != nil {
+		logs.Log.Error(fmt.Sprintf("Error in creating station indexer : %s", err.Error()))
+		return
 	}
 
+	go WatchStationEndpoints(ctx, bsgConfig.Station)
+
+	syncer := NewSyncer(indexer, blockDatabaseConnection, txnDatabaseConnection, committedTxns)
+	syncer.SetCatchUp(bsgConfig.Station.SyncWorkers, bsgConfig.Station.SyncBatchSize)
+	syncerInstance.Store(syncer)
+	err = syncer.Run(ctx, latestBlock)
+	logs.Log.Info(fmt.Sprintf("Indexer stopped at block %d : %v", syncer.Height(), err))
+}
+
+// GetSyncer returns the running station Syncer, or nil if indexing has not started.
+func GetSyncer() *Syncer {
+	return syncerInstance.Load()
 }
 
-func LoadConfig() (config config.Config, err error) {
-	homeDir, err := os.UserHomeDir()
+func LoadConfig() (conf config.Config, err error) {
+	configDir, err := config.ConfigDirPath()
 	if err != nil {
-		return config, err // Return error, perhaps log it as well
+		return conf, err // Return error, perhaps log it as well
 	}
-	configDir := filepath.Join(homeDir, ".tracks/config")
 
 	_, err = os.Stat(configDir)
 	if os.IsNotExist(err) {
-		return config, fmt.Errorf("config directory not found: %s", configDir)
+		return conf, fmt.Errorf("config directory not found: %s", configDir)
 	}
 
 	viper.AddConfigPath(configDir)
@@ -50,9 +64,9 @@ func LoadConfig() (config config.Config, err error) {
 	viper.SetConfigType("toml")
 
 	if err = viper.ReadInConfig(); err != nil {
-		return config, err
+		return conf, err
 	}
 
-	err = viper.Unmarshal(&config)
-	return config, err
+	err = viper.Unmarshal(&conf)
+	return conf, err
 }
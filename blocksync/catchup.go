@@ -0,0 +1,123 @@
+package blocksync
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+const (
+	// defaultSyncWorkers is the number of concurrent station requests used to catch up.
+	defaultSyncWorkers = 4
+	// defaultSyncBatchSize is the number of blocks requested per station round trip.
+	defaultSyncBatchSize = 10
+)
+
+// BatchFetcher is implemented by indexers that can fetch a range of blocks in a single station
+// round trip, typically with a JSON-RPC batch request.
+type BatchFetcher interface {
+	// FetchBlocks fetches the blocks from..to, in height order. On error it may return the
+	// blocks fetched before the failing one.
+	FetchBlocks(ctx context.Context, from int, to int) ([]*StationBlock, error)
+}
+
+// fetchedBlock is a block fetched ahead of persistence together with its transactions.
+type fetchedBlock struct {
+	block *StationBlock
+	txns  [][]byte
+}
+
+// SetCatchUp sets how many concurrent station requests the Syncer makes while it is behind the
+// station head and how many blocks each request covers. Non-positive values select the defaults.
+func (s *Syncer) SetCatchUp(workers int, batchSize int) {
+	if workers <= 0 {
+		workers = defaultSyncWorkers
+	}
+	if batchSize <= 0 {
+		batchSize = defaultSyncBatchSize
+	}
+	s.workers = workers
+	s.batchSize = batchSize
+}
+
+// fetchWindow returns the last height of the next range to fetch from height on.
+func (s *Syncer) fetchWindow(height int, latestHeight int) int {
+	return min(height+s.workers*s.batchSize-1, latestHeight)
+}
+
+// fetchRange fetches the blocks from..to and their transactions with up to s.workers concurrent
+// chunks of s.batchSize blocks. The result is in height order and stops before the first block
+// that could not be fetched, whose error is returned alongside.
+func (s *Syncer) fetchRange(ctx context.Context, from int, to int) ([]*fetchedBlock, error) {
+	results := make([]*fetchedBlock, to-from+1)
+	errs := make([]error, len(results))
+
+	chunks := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < s.workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for start := range chunks {
+				end := min(start+s.batchSize-1, to)
+				blocks, err := s.fetchChunk(ctx, start, end)
+				for i, block := range blocks {
+					results[start-from+i] = block
+				}
+				if failed := start - from + len(blocks); err != nil && failed < len(errs) {
+					errs[failed] = err
+				}
+			}
+		}()
+	}
+	for start := from; start <= to && ctx.Err() == nil; start += s.batchSize {
+		chunks <- start
+	}
+	close(chunks)
+	wg.Wait()
+
+	for i, result := range results {
+		if result == nil {
+			if errs[i] == nil {
+				errs[i] = ctx.Err()
+			}
+			return results[:i], errs[i]
+		}
+	}
+	return results, nil
+}
+
+// fetchChunk fetches the blocks from..to and extracts their transactions, returning the blocks
+// fetched before the first failure.
+func (s *Syncer) fetchChunk(ctx context.Context, from int, to int) ([]*fetchedBlock, error) {
+	var blocks []*StationBlock
+	var err error
+	if batcher, ok := s.indexer.(BatchFetcher); ok && to > from {
+		blocks, err = batcher.FetchBlocks(ctx, from, to)
+	} else {
+		for height := from; height <= to; height++ {
+			var block *StationBlock
+			if block, err = s.indexer.FetchBlock(ctx, height); err != nil {
+				break
+			}
+			blocks = append(blocks, block)
+		}
+	}
+	if err != nil {
+		err = fmt.Errorf("failed to get block %d: %w", from+len(blocks), err)
+	}
+
+	fetched := make([]*fetchedBlock, 0, len(blocks))
+	for _, block := range blocks {
+		if block.Skipped {
+			fetched = append(fetched, &fetchedBlock{block: block})
+			continue
+		}
+		txns, txnErr := s.indexer.ExtractTxns(ctx, block)
+		if txnErr != nil {
+			return fetched, fmt.Errorf("failed to get transactions of block %d: %w", block.Height, txnErr)
+		}
+		fetched = append(fetched, &fetchedBlock{block: block, txns: txns})
+	}
+	return fetched, err
+}
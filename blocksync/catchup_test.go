@@ -0,0 +1,176 @@
+package blocksync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/airchains-network/tracks/utils"
+)
+
+// batchingIndexer is a fakeIndexer that also fetches ranges of blocks, recording how many
+// batch requests are in flight at once. FetchBlocks fails at failAt when it is set.
+type batchingIndexer struct {
+	*fakeIndexer
+	failAt int
+
+	mu        sync.Mutex
+	active    int
+	maxActive int
+}
+
+func (b *batchingIndexer) FetchBlocks(ctx context.Context, from int, to int) ([]*StationBlock, error) {
+	b.mu.Lock()
+	b.active++
+	b.maxActive = max(b.maxActive, b.active)
+	b.mu.Unlock()
+	defer func() {
+		b.mu.Lock()
+		b.active--
+		b.mu.Unlock()
+	}()
+	time.Sleep(5 * time.Millisecond)
+
+	var blocks []*StationBlock
+	for height := from; height <= to; height++ {
+		if height == b.failAt {
+			return blocks, fmt.Errorf("station failed block %d", height)
+		}
+		block, err := b.FetchBlock(ctx, height)
+		if err != nil {
+			return blocks, err
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks, nil
+}
+
+func TestFetchWindow(t *testing.T) {
+	tests := []struct{ workers, batchSize, height, latest, want int }{
+		{4, 10, 1, 100, 40},
+		{4, 10, 95, 100, 100},
+		{1, 1, 7, 7, 7},
+		{0, 0, 1, 1000, defaultSyncWorkers * defaultSyncBatchSize},
+	}
+	for _, tt := range tests {
+		s := newTestSyncer(t, newFakeIndexer("a", 1, 0), nil)
+		s.SetCatchUp(tt.workers, tt.batchSize)
+		if got := s.fetchWindow(tt.height, tt.latest); got != tt.want {
+			t.Errorf("fetchWindow(%d, %d) with %d workers of %d blocks = %d, want %d", tt.height, tt.latest, tt.workers, tt.batchSize, got, tt.want)
+		}
+	}
+}
+
+func TestFetchRange(t *testing.T) {
+	tests := []struct {
+		name      string
+		workers   int
+		batchSize int
+		from, to  int
+		failAt    int
+		want      int
+		wantErr   bool
+	}{
+		{"single worker", 1, 3, 1, 10, 0, 10, false},
+		{"more chunks than workers", 3, 2, 1, 20, 0, 20, false},
+		{"range within one batch", 4, 10, 5, 7, 0, 3, false},
+		{"failure mid range", 3, 2, 1, 10, 6, 5, true},
+		{"failure at the first block", 2, 5, 1, 10, 1, 0, true},
+	}
+	for _, tt := range tests {
+		station := &batchingIndexer{fakeIndexer: newFakeIndexer("a", 20, 2), failAt: tt.failAt}
+		s := newTestSyncer(t, station, nil)
+		s.SetCatchUp(tt.workers, tt.batchSize)
+
+		fetched, err := s.fetchRange(context.Background(), tt.from, tt.to)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: fetchRange returned error %v", tt.name, err)
+		}
+		if len(fetched) != tt.want {
+			t.Fatalf("%s: fetched %d blocks, want %d", tt.name, len(fetched), tt.want)
+		}
+		for i, f := range fetched {
+			if height := tt.from + i; f.block.Height != height || f.block.Hash != fmt.Sprintf("a%d", height) {
+				t.Errorf("%s: block %d is %d %s", tt.name, i, f.block.Height, f.block.Hash)
+			}
+			if len(f.txns) != 2 || string(f.txns[0]) != fmt.Sprintf("a%d-0", tt.from+i) {
+				t.Errorf("%s: block %d has txns %q", tt.name, f.block.Height, f.txns)
+			}
+		}
+		if station.maxActive > tt.workers {
+			t.Errorf("%s: %d batch requests in flight, want at most %d", tt.name, station.maxActive, tt.workers)
+		}
+	}
+}
+
+func TestFetchRangeWithoutBatchRequests(t *testing.T) {
+	// the station only has blocks 1..7, so the range stops before block 8
+	s := newTestSyncer(t, newFakeIndexer("a", 7, 1), nil)
+	s.SetCatchUp(2, 3)
+
+	fetched, err := s.fetchRange(context.Background(), 1, 10)
+	if err == nil {
+		t.Error("fetchRange past the station head returned no error")
+	}
+	if len(fetched) != 7 {
+		t.Fatalf("fetched %d blocks, want 7", len(fetched))
+	}
+	for i, f := range fetched {
+		if f.block.Height != i+1 {
+			t.Errorf("block %d has height %d", i, f.block.Height)
+		}
+	}
+}
+
+func TestWasmFetchBlocksOrder(t *testing.T) {
+	tests := []struct {
+		name    string
+		omit    int
+		want    int
+		wantErr bool
+	}{
+		{"responses out of order", 0, 5, false},
+		{"response missing", 4, 3, true},
+	}
+	for _, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var requests []struct {
+				ID int `json:"id"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&requests); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			// answer in reverse order, as a batch response carries no ordering guarantee
+			var responses []string
+			for i := len(requests) - 1; i >= 0; i-- {
+				id := requests[i].ID
+				if id == tt.omit {
+					continue
+				}
+				responses = append(responses, fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"result":{"block_id":{"hash":"h%d"},"block":{"header":{"height":"%d","time":"2024-01-01T00:00:%02dZ","last_block_id":{"hash":"h%d"}},"data":{"txs":[]}}}}`, id, id, id, id, id-1))
+			}
+			fmt.Fprintf(w, "[%s]", strings.Join(responses, ","))
+		}))
+
+		blocks, err := (&WasmIndexer{rpc: utils.NewEndpointPool("station rpc", []string{server.URL}, nil)}).FetchBlocks(context.Background(), 1, 5)
+		server.Close()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: FetchBlocks returned error %v", tt.name, err)
+		}
+		if len(blocks) != tt.want {
+			t.Fatalf("%s: got %d blocks, want %d", tt.name, len(blocks), tt.want)
+		}
+		for i, block := range blocks {
+			if block.Height != i+1 || block.Hash != fmt.Sprintf("h%d", i+1) || block.ParentHash != fmt.Sprintf("h%d", i) {
+				t.Errorf("%s: block %d is %d %s on %s", tt.name, i, block.Height, block.Hash, block.ParentHash)
+			}
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package blocksync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/airchains-network/tracks/config"
+	"github.com/airchains-network/tracks/utils"
+)
+
+// endpointCheckInterval is how often the station endpoints are health checked.
+const endpointCheckInterval = 30 * time.Second
+
+var (
+	endpointsMu sync.Mutex
+	rpcPool     *utils.EndpointPool
+	apiPool     *utils.EndpointPool
+)
+
+// StationRPCEndpoints returns the endpoint pool of the station RPC, shared by indexing and pod
+// building. The pool is created from the station configuration on first use.
+func StationRPCEndpoints(station *config.StationConfig) *utils.EndpointPool {
+	endpointsMu.Lock()
+	defer endpointsMu.Unlock()
+	if rpcPool == nil {
+		rpcPool = utils.NewEndpointPool("station rpc", station.RPCEndpoints(), rpcProbe(station.StationType))
+	}
+	return rpcPool
+}
+
+// StationAPIEndpoints returns the endpoint pool of the station API, shared by indexing and pod
+// building. The pool is created from the station configuration on first use.
+func StationAPIEndpoints(station *config.StationConfig) *utils.EndpointPool {
+	endpointsMu.Lock()
+	defer endpointsMu.Unlock()
+	if apiPool == nil {
+		apiPool = utils.NewEndpointPool("station api", station.APIEndpoints(), apiProbe(station.StationType))
+	}
+	return apiPool
+}
+
+// WatchStationEndpoints health checks the station endpoint pools until ctx is cancelled.
+func WatchStationEndpoints(ctx context.Context, station *config.StationConfig) {
+	go StationAPIEndpoints(station).Run(ctx, endpointCheckInterval)
+	StationRPCEndpoints(station).Run(ctx, endpointCheckInterval)
+}
+
+func rpcProbe(stationType string) utils.EndpointProbe {
+	switch strings.ToLower(stationType) {
+	case "evm":
+		return evmProbe{}
+	case "wasm":
+		return tendermintProbe{}
+	case "svm":
+		return svmProbe{}
+	}
+	return nil
+}
+
+func apiProbe(stationType string) utils.EndpointProbe {
+	switch strings.ToLower(stationType) {
+	case "evm":
+		return evmProbe{}
+	case "wasm":
+		return cosmosAPIProbe{}
+	}
+	return nil
+}
+
+// jsonRPCCall posts a single JSON-RPC request and decodes its result.
+func jsonRPCCall(ctx context.Context, url string, method string, params any, result any) error {
+	payload, err := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": 1, "method": method, "params": params})
+	if err != nil {
+		return err
+	}
+	body, err := httpPost(ctx, url, payload)
+	if err != nil {
+		return err
+	}
+	var response struct {
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err = json.Unmarshal(body, &response); err != nil {
+		return fmt.Errorf("error decoding %s response: %w", method, err)
+	}
+	if response.Error != nil {
+		return fmt.Errorf("%s failed: %s", method, response.Error.Message)
+	}
+	return json.Unmarshal(response.Result, result)
+}
+
+type evmProbe struct{}
+
+func (evmProbe) LatestHeight(ctx context.Context, url string) (int, error) {
+	var height string
+	if err := jsonRPCCall(ctx, url, "eth_blockNumber", []any{}, &height); err != nil {
+		return 0, err
+	}
+	value, err := strconv.ParseInt(strings.TrimPrefix(height, "0x"), 16, 64)
+	return int(value), err
+}
+
+func (evmProbe) BlockHash(ctx context.Context, url string, height int) (string, error) {
+	var block *struct {
+		Hash string `json:"hash"`
+	}
+	if err := jsonRPCCall(ctx, url, "eth_getBlockByNumber", []any{fmt.Sprintf("0x%x", height), false}, &block); err != nil || block == nil {
+		return "", err
+	}
+	return block.Hash, nil
+}
+
+type tendermintProbe struct{}
+
+func (tendermintProbe) LatestHeight(ctx context.Context, url string) (int, error) {
+	body, err := httpGet(ctx, url+"/status")
+	if err != nil {
+		return 0, err
+	}
+	var status struct {
+		Result struct {
+			SyncInfo struct {
+				LatestBlockHeight string `json:"latest_block_height"`
+			} `json:"sync_info"`
+		} `json:"result"`
+	}
+	if err = json.Unmarshal(body, &status); err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(status.Result.SyncInfo.LatestBlockHeight)
+}
+
+func (tendermintProbe) BlockHash(ctx context.Context, url string, height int) (string, error) {
+	body, err := httpGet(ctx, fmt.Sprintf("%s/block?height=%d", url, height))
+	if err != nil {
+		return "", err
+	}
+	var blockData Response
+	if err = json.Unmarshal(body, &blockData); err != nil {
+		return "", err
+	}
+	return blockData.Result.BlockID.Hash, nil
+}
+
+type cosmosAPIProbe struct{}
+
+func (cosmosAPIProbe) LatestHeight(ctx context.Context, url string) (int, error) {
+	body, err := httpGet(ctx, url+"/cosmos/base/tendermint/v1beta1/blocks/latest")
+	if err != nil {
+		return 0, err
+	}
+	var latest BlockObject
+	if err = json.Unmarshal(body, &latest); err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(latest.Block.Header.Height)
+}
+
+func (cosmosAPIProbe) BlockHash(ctx context.Context, url string, height int) (string, error) {
+	body, err := httpGet(ctx, fmt.Sprintf("%s/cosmos/base/tendermint/v1beta1/blocks/%d", url, height))
+	if err != nil {
+		return "", err
+	}
+	var block struct {
+		BlockID struct {
+			Hash string `json:"hash"`
+		} `json:"block_id"`
+	}
+	if err = json.Unmarshal(body, &block); err != nil {
+		return "", err
+	}
+	return block.BlockID.Hash, nil
+}
+
+type svmProbe struct{}
+
+func (svmProbe) LatestHeight(ctx context.Context, url string) (int, error) {
+	var slot int
+	err := jsonRPCCall(ctx, url, "getSlot", []any{map[string]string{"commitment": svmCommitmentFinalized}}, &slot)
+	return slot, err
+}
+
+func (svmProbe) BlockHash(ctx context.Context, url string, height int) (string, error) {
+	var block *struct {
+		Blockhash string `json:"blockhash"`
+	}
+	params := []any{height, map[string]any{"transactionDetails": "none", "rewards": false, "maxSupportedTransactionVersion": 0}}
+	if err := jsonRPCCall(ctx, url, "getBlock", params, &block); err != nil || block == nil {
+		// skipped slots have no block to compare
+		return "", nil
+	}
+	return block.Blockhash, nil
+}
@@ -0,0 +1,622 @@
+package blocksync
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/airchains-network/tracks/config"
+	stationTypes "github.com/airchains-network/tracks/types"
+	"github.com/airchains-network/tracks/utils"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/rs/zerolog/log"
+)
+
+func init() {
+	RegisterIndexer("evm", NewEVMIndexer)
+}
+
+// EVMIndexer indexes EVM stations over the station JSON-RPC.
+type EVMIndexer struct {
+	endpoints     *utils.EndpointPool
+	chainID       *big.Int
+	finality      string
+	confirmations int
+
+	// noBlockReceipts is set once the station rejected eth_getBlockReceipts.
+	noBlockReceipts atomic.Bool
+
+	clientsMu sync.Mutex
+	clients   map[string]*ethclient.Client
+}
+
+// NewEVMIndexer connects to the station JSON-RPC and returns an EVM StationIndexer.
+func NewEVMIndexer(station *config.StationConfig) (StationIndexer, error) {
+	finality, err := FinalityPolicy(station)
+	if err != nil {
+		return nil, err
+	}
+	e := &EVMIndexer{
+		endpoints:     StationRPCEndpoints(station),
+		finality:      finality,
+		confirmations: station.Confirmations,
+		clients:       make(map[string]*ethclient.Client),
+	}
+	err = e.call(context.Background(), func(client *ethclient.Client) (err error) {
+		e.chainID, err = client.NetworkID(context.Background())
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the network ID: %w", err)
+	}
+	return e, nil
+}
+
+// call runs fn against the station endpoints in order of preference until it succeeds.
+func (e *EVMIndexer) call(ctx context.Context, fn func(client *ethclient.Client) error) error {
+	return e.endpoints.Do(ctx, func(url string) error {
+		client, err := e.client(url)
+		if err != nil {
+			return err
+		}
+		return fn(client)
+	})
+}
+
+// client returns the client of a station endpoint, connecting on first use.
+func (e *EVMIndexer) client(url string) (*ethclient.Client, error) {
+	e.clientsMu.Lock()
+	defer e.clientsMu.Unlock()
+	if client, ok := e.clients[url]; ok {
+		return client, nil
+	}
+	client, err := ethclient.Dial(url)
+	if err != nil {
+		return nil, fmt.Errorf("error in connecting to the station: %w", err)
+	}
+	e.clients[url] = client
+	return client, nil
+}
+
+func (e *EVMIndexer) FirstHeight() int {
+	return 0
+}
+
+func (e *EVMIndexer) LatestHeight(ctx context.Context) (int, error) {
+	var latest uint64
+	err := e.call(ctx, func(client *ethclient.Client) (err error) {
+		latest, err = client.BlockNumber(ctx)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int(latest), nil
+}
+
+func (e *EVMIndexer) FinalizedHeight(ctx context.Context) (int, error) {
+	switch e.finality {
+	case config.FinalitySafe, config.FinalityFinalized:
+		tag := rpc.FinalizedBlockNumber
+		if e.finality == config.FinalitySafe {
+			tag = rpc.SafeBlockNumber
+		}
+		var header *types.Header
+		err := e.call(ctx, func(client *ethclient.Client) (err error) {
+			header, err = client.HeaderByNumber(ctx, big.NewInt(int64(tag)))
+			return err
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to get the %s block: %w", e.finality, err)
+		}
+		return int(header.Number.Int64()), nil
+	case config.FinalityConfirmations:
+		latest, err := e.LatestHeight(ctx)
+		if err != nil {
+			return 0, err
+		}
+		return confirmedHeight(latest, e.confirmations, e.FirstHeight()), nil
+	default:
+		return e.LatestHeight(ctx)
+	}
+}
+
+// evmBlock is the payload of an EVM StationBlock.
+type evmBlock struct {
+	header *types.Header
+	txns   []*evmTxn
+}
+
+// evmTxn is a block transaction. tx is nil when the transaction could not be decoded or its
+// sender could not be derived, in which case reason tells why.
+type evmTxn struct {
+	hash   common.Hash
+	txType uint64
+	tx     *types.Transaction
+	from   common.Address
+	reason string
+}
+
+func (e *EVMIndexer) FetchBlock(ctx context.Context, height int) (*StationBlock, error) {
+	var raw json.RawMessage
+	err := e.call(ctx, func(client *ethclient.Client) error {
+		return client.Client().CallContext(ctx, &raw, "eth_getBlockByNumber", hexutil.EncodeUint64(uint64(height)), true)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return e.decodeBlock(height, raw)
+}
+
+// FetchBlocks fetches the blocks from..to with a single eth_getBlockByNumber batch request.
+func (e *EVMIndexer) FetchBlocks(ctx context.Context, from int, to int) ([]*StationBlock, error) {
+	raws := make([]json.RawMessage, to-from+1)
+	batch := make([]rpc.BatchElem, len(raws))
+	for i := range batch {
+		batch[i] = rpc.BatchElem{
+			Method: "eth_getBlockByNumber",
+			Args:   []any{hexutil.EncodeUint64(uint64(from + i)), true},
+			Result: &raws[i],
+		}
+	}
+	err := e.call(ctx, func(client *ethclient.Client) error {
+		return client.Client().BatchCallContext(ctx, batch)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	blocks := make([]*StationBlock, 0, len(batch))
+	for i, elem := range batch {
+		if elem.Error != nil {
+			return blocks, elem.Error
+		}
+		block, err := e.decodeBlock(from+i, raws[i])
+		if err != nil {
+			return blocks, err
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks, nil
+}
+
+// decodeBlock decodes an eth_getBlockByNumber result with full transactions. Transactions are
+// decoded one by one so that a transaction type this build does not know only skips that
+// transaction.
+func (e *EVMIndexer) decodeBlock(height int, raw json.RawMessage) (*StationBlock, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, ethereum.NotFound
+	}
+	var header types.Header
+	if err := json.Unmarshal(raw, &header); err != nil {
+		return nil, err
+	}
+	if header.Number == nil || header.Number.Int64() != int64(height) {
+		return nil, fmt.Errorf("station returned block %v for height %d", header.Number, height)
+	}
+	// the hash the station reports, which is what the next block names as its parent; stations
+	// such as Ethermint do not hash their header the way go-ethereum does
+	var body struct {
+		Hash         common.Hash         `json:"hash"`
+		Size         hexutil.Uint64      `json:"size"`
+		Transactions []json.RawMessage   `json:"transactions"`
+		Uncles       []common.Hash       `json:"uncles"`
+		Withdrawals  []*types.Withdrawal `json:"withdrawals"`
+	}
+	if err := json.Unmarshal(raw, &body); err != nil {
+		return nil, err
+	}
+	if body.Hash == (common.Hash{}) {
+		body.Hash = header.Hash()
+	}
+
+	payload := &evmBlock{header: &header, txns: make([]*evmTxn, 0, len(body.Transactions))}
+	var skipped []stationTypes.SkippedTransactionStruct
+	for i, rawTx := range body.Transactions {
+		txn := e.decodeTxn(rawTx)
+		payload.txns = append(payload.txns, txn)
+		if txn.tx == nil {
+			skipped = append(skipped, skippedTransaction(txn, uint(i)))
+		}
+	}
+
+	var recipients []string
+	for _, withdrawal := range body.Withdrawals {
+		recipients = append(recipients, withdrawal.Address.Hex())
+	}
+
+	var block = stationTypes.BlockStruct{
+		BaseFeePerGas:        utils.ToString(header.BaseFee),
+		Difficulty:           utils.ToString(header.Difficulty.String()),
+		ExtraData:            utils.ToString(header.Extra),
+		GasLimit:             utils.ToString(header.GasLimit),
+		GasUsed:              utils.ToString(header.GasUsed),
+		Hash:                 utils.ToString(body.Hash.String()),
+		LogsBloom:            utils.ToString(header.Bloom),
+		Miner:                utils.ToString(header.Coinbase.String()),
+		MixHash:              utils.ToString(header.MixDigest.String()),
+		Nonce:                utils.ToString(header.Nonce.Uint64()),
+		Number:               utils.ToString(header.Number.String()),
+		ParentHash:           utils.ToString(header.ParentHash.String()),
+		ReceiptsRoot:         utils.ToString(header.ReceiptHash.String()),
+		Sha3Uncles:           utils.ToString(header.UncleHash),
+		Size:                 utils.ToString(uint64(body.Size)),
+		StateRoot:            utils.ToString(header.Root.String()),
+		Timestamp:            utils.ToString(header.Time),
+		TotalDifficulty:      utils.ToString(header.Difficulty.String()),
+		TransactionCount:     len(body.Transactions),
+		TransactionsRoot:     utils.ToString(header.TxHash.String()),
+		Uncles:               utils.ToString(body.Uncles),
+		SkippedTransactions:  skipped,
+		WithdrawalRecipients: recipients,
+	}
+	data, err := json.Marshal(block)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling block data: %w", err)
+	}
+
+	return &StationBlock{
+		Height:     height,
+		Hash:       block.Hash,
+		ParentHash: block.ParentHash,
+		Time:       time.Unix(int64(header.Time), 0),
+		Data:       data,
+		Payload:    payload,
+	}, nil
+}
+
+// decodeTxn decodes a transaction of an eth_getBlockByNumber result and derives its sender with
+// the latest signer of the station chain.
+func (e *EVMIndexer) decodeTxn(raw json.RawMessage) *evmTxn {
+	var envelope struct {
+		Hash common.Hash    `json:"hash"`
+		Type hexutil.Uint64 `json:"type"`
+	}
+	if err := json.Unmarshal(raw, &envelope); err != nil {
+		return &evmTxn{reason: fmt.Sprintf("undecodable transaction: %v", err)}
+	}
+
+	txn := &evmTxn{hash: envelope.Hash, txType: uint64(envelope.Type)}
+	var tx types.Transaction
+	if err := json.Unmarshal(raw, &tx); err != nil {
+		if errors.Is(err, types.ErrTxTypeNotSupported) {
+			txn.reason = fmt.Sprintf("unsupported transaction type %d", txn.txType)
+		} else {
+			txn.reason = fmt.Sprintf("undecodable transaction: %v", err)
+		}
+		return txn
+	}
+	from, err := types.Sender(types.LatestSignerForChainID(e.chainID), &tx)
+	if err != nil {
+		txn.reason = fmt.Sprintf("failed to derive the sender address: %v", err)
+		return txn
+	}
+	txn.tx, txn.from = &tx, from
+	return txn
+}
+
+func skippedTransaction(txn *evmTxn, index uint) stationTypes.SkippedTransactionStruct {
+	log.Warn().Str("module", "blocksync").Msg(fmt.Sprintf("Skipping transaction %s: %s", txn.hash.Hex(), txn.reason))
+	return stationTypes.SkippedTransactionStruct{
+		Hash:             txn.hash.Hex(),
+		TransactionIndex: utils.ToString(index),
+		Type:             fmt.Sprintf("%d", txn.txType),
+		Reason:           txn.reason,
+	}
+}
+
+func (e *EVMIndexer) ExtractTxns(ctx context.Context, block *StationBlock) ([][]byte, error) {
+	payload, ok := block.Payload.(*evmBlock)
+	if !ok {
+		return nil, fmt.Errorf("unexpected payload %T for evm block %d", block.Payload, block.Height)
+	}
+
+	receipts, err := e.blockReceipts(ctx, payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the receipts of block %d: %w", block.Height, err)
+	}
+
+	txns := make([][]byte, 0, len(payload.txns))
+	for i, txn := range payload.txns {
+		if txn.tx == nil {
+			continue
+		}
+		txData := e.transactionRecord(txn, uint(i), block, receipts[i])
+		data, err := json.Marshal(txData)
+		if err != nil {
+			return nil, fmt.Errorf("error marshalling transaction %s: %w", txn.hash.Hex(), err)
+		}
+		txns = append(txns, data)
+	}
+	return txns, nil
+}
+
+// TxnIndexEntries indexes the logs of an EVM transaction by emitting address and by topic.
+func (e *EVMIndexer) TxnIndexEntries(_ int, txn []byte) (map[string][]byte, error) {
+	var tx stationTypes.TransactionStruct
+	if err := json.Unmarshal(txn, &tx); err != nil {
+		return nil, err
+	}
+	return logIndexEntries(&tx)
+}
+
+// DescribeTxn returns the hash of an EVM transaction with its sender, recipient and created
+// contract.
+func (e *EVMIndexer) DescribeTxn(txn []byte) (string, []string, error) {
+	var tx stationTypes.TransactionStruct
+	if err := json.Unmarshal(txn, &tx); err != nil {
+		return "", nil, err
+	}
+	addresses := []string{tx.From}
+	if tx.To != (common.Address{}).Hex() {
+		addresses = append(addresses, tx.To)
+	}
+	if tx.ContractAddress != "" {
+		addresses = append(addresses, tx.ContractAddress)
+	}
+	return tx.Hash, addresses, nil
+}
+
+// LedgerTransfer returns the value moved by an EVM transaction record, with the fee paid for the
+// gas and blob gas it used. The value of a contract creation goes to the created contract.
+func (e *EVMIndexer) LedgerTransfer(txn []byte) (*LedgerTransfer, error) {
+	var tx stationTypes.TransactionStruct
+	if err := json.Unmarshal(txn, &tx); err != nil {
+		return nil, err
+	}
+	if tx.Status == "" {
+		return nil, fmt.Errorf("transaction %s was indexed without its receipt", tx.Hash)
+	}
+
+	nonce, err := strconv.ParseUint(tx.Nonce, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid nonce of transaction %s: %w", tx.Hash, err)
+	}
+	value, ok := new(big.Int).SetString(tx.Value, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid value of transaction %s", tx.Hash)
+	}
+	gasPrice := tx.EffectiveGasPrice
+	if gasPrice == "" {
+		gasPrice = tx.GasPrice
+	}
+	fee, err := decimalProduct(tx.GasUsed, gasPrice)
+	if err != nil {
+		return nil, fmt.Errorf("invalid gas of transaction %s: %w", tx.Hash, err)
+	}
+	if tx.BlobGasPrice != "" {
+		blobFee, err := decimalProduct(tx.BlobGasUsed, tx.BlobGasPrice)
+		if err != nil {
+			return nil, fmt.Errorf("invalid blob gas of transaction %s: %w", tx.Hash, err)
+		}
+		fee.Add(fee, blobFee)
+	}
+
+	to := tx.To
+	if tx.ContractAddress != "" {
+		to = tx.ContractAddress
+	}
+	transfer := &LedgerTransfer{
+		From:    tx.From,
+		To:      to,
+		Value:   value,
+		Fee:     fee,
+		Nonce:   nonce,
+		Success: tx.Status == stationTypes.TxStatusSuccess,
+	}
+	// anything but a plain transfer ran code at the recipient, which may have moved its value on
+	if transfer.Success && tx.GasUsed != strconv.FormatUint(params.TxGas, 10) {
+		transfer.Untracked = []string{to}
+	}
+	return transfer, nil
+}
+
+// LedgerBlockAccounts returns the fee recipient of a stored EVM block, which collects the priority
+// fees and any block reward, and the recipients of its withdrawals.
+func (e *EVMIndexer) LedgerBlockAccounts(data []byte) ([]string, error) {
+	var block stationTypes.BlockStruct
+	if err := json.Unmarshal(data, &block); err != nil {
+		return nil, err
+	}
+	return append([]string{block.Miner}, block.WithdrawalRecipients...), nil
+}
+
+// decimalProduct multiplies two decimal strings.
+func decimalProduct(a string, b string) (*big.Int, error) {
+	x, ok := new(big.Int).SetString(a, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid number %q", a)
+	}
+	y, ok := new(big.Int).SetString(b, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid number %q", b)
+	}
+	return x.Mul(x, y), nil
+}
+
+func (e *EVMIndexer) BlockKey(height int) string {
+	return fmt.Sprintf("block_%d", height)
+}
+
+// blockReceipts returns the receipts of the block transactions in block order. It uses
+// eth_getBlockReceipts and falls back to a batch of eth_getTransactionReceipt on stations that
+// do not serve it.
+func (e *EVMIndexer) blockReceipts(ctx context.Context, block *evmBlock) ([]*types.Receipt, error) {
+	if len(block.txns) == 0 {
+		return nil, nil
+	}
+
+	var receipts []*types.Receipt
+	if !e.noBlockReceipts.Load() {
+		err := e.call(ctx, func(client *ethclient.Client) error {
+			return client.Client().CallContext(ctx, &receipts, "eth_getBlockReceipts", hexutil.EncodeBig(block.header.Number))
+		})
+		if unsupportedMethod(err) {
+			log.Info().Str("module", "blocksync").Msg(fmt.Sprintf("Station does not serve eth_getBlockReceipts (%s), fetching receipts per transaction", err.Error()))
+			e.noBlockReceipts.Store(true)
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	if e.noBlockReceipts.Load() {
+		receipts = make([]*types.Receipt, len(block.txns))
+		batch := make([]rpc.BatchElem, 0, len(block.txns))
+		for i, txn := range block.txns {
+			if txn.tx == nil {
+				continue
+			}
+			batch = append(batch, rpc.BatchElem{
+				Method: "eth_getTransactionReceipt",
+				Args:   []any{txn.hash},
+				Result: &receipts[i],
+			})
+		}
+		err := e.call(ctx, func(client *ethclient.Client) error {
+			return client.Client().BatchCallContext(ctx, batch)
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, elem := range batch {
+			if elem.Error != nil {
+				return nil, elem.Error
+			}
+		}
+	}
+
+	if len(receipts) != len(block.txns) {
+		return nil, fmt.Errorf("station returned %d receipts for %d transactions", len(receipts), len(block.txns))
+	}
+	for i, txn := range block.txns {
+		if txn.tx != nil && (receipts[i] == nil || receipts[i].TxHash != txn.hash) {
+			return nil, fmt.Errorf("receipt of transaction %s not found", txn.hash.Hex())
+		}
+	}
+	return receipts, nil
+}
+
+// unsupportedMethod reports whether err is the station rejecting a JSON-RPC method it does not
+// serve, either with the method not found code or with an error message saying so. Any other
+// error, such as a timeout or a block the station has not caught up with, is transient.
+func unsupportedMethod(err error) bool {
+	var rpcErr rpc.Error
+	if !errors.As(err, &rpcErr) {
+		return false
+	}
+	if rpcErr.ErrorCode() == -32601 {
+		return true
+	}
+	message := strings.ToLower(rpcErr.Error())
+	for _, unsupported := range []string{"method not found", "not supported", "unsupported", "does not exist", "not available"} {
+		if strings.Contains(message, unsupported) && strings.Contains(message, "method") {
+			return true
+		}
+	}
+	return false
+}
+
+// transactionRecord converts a block transaction and its receipt into the record stored in the
+// txns-N sequence.
+func (e *EVMIndexer) transactionRecord(txn *evmTxn, index uint, block *StationBlock, receipt *types.Receipt) *stationTypes.TransactionStruct {
+	tx := txn.tx
+	v, r, s := tx.RawSignatureValues()
+
+	var contractAddress string
+	if receipt.ContractAddress != (common.Address{}) {
+		contractAddress = receipt.ContractAddress.Hex()
+	}
+	logs := make([]stationTypes.LogStruct, 0, len(receipt.Logs))
+	for _, l := range receipt.Logs {
+		topics := make([]string, 0, len(l.Topics))
+		for _, topic := range l.Topics {
+			topics = append(topics, topic.Hex())
+		}
+		logs = append(logs, stationTypes.LogStruct{
+			Address:          l.Address.Hex(),
+			Topics:           topics,
+			Data:             hexutil.Encode(l.Data),
+			BlockNumber:      uint64(block.Height),
+			BlockHash:        block.Hash,
+			TransactionHash:  tx.Hash().Hex(),
+			TransactionIndex: utils.ToString(index),
+			LogIndex:         utils.ToString(l.Index),
+		})
+	}
+
+	var toAddress string
+	if tx.To() == nil {
+		toAddress = "0x0000000000000000000000000000000000000000"
+	} else {
+		toAddress = tx.To().Hex()
+	}
+
+	record := &stationTypes.TransactionStruct{
+		BlockHash:        block.Hash,
+		BlockNumber:      uint64(block.Height),
+		From:             txn.from.Hex(),
+		Gas:              utils.ToString(tx.Gas()),
+		GasPrice:         tx.GasPrice().String(),
+		Hash:             tx.Hash().Hex(),
+		Input:            string(tx.Data()),
+		Nonce:            utils.ToString(tx.Nonce()),
+		R:                r.String(),
+		S:                s.String(),
+		To:               toAddress,
+		TransactionIndex: utils.ToString(index),
+		Type:             fmt.Sprintf("%d", tx.Type()),
+		V:                v.String(),
+		Value:            tx.Value().String(),
+		Status:           utils.ToString(receipt.Status),
+		GasUsed:          utils.ToString(receipt.GasUsed),
+		ContractAddress:  contractAddress,
+		Logs:             logs,
+	}
+
+	if receipt.EffectiveGasPrice != nil {
+		record.EffectiveGasPrice = receipt.EffectiveGasPrice.String()
+	}
+	if receipt.BlobGasPrice != nil {
+		record.BlobGasUsed = utils.ToString(receipt.BlobGasUsed)
+		record.BlobGasPrice = receipt.BlobGasPrice.String()
+	}
+
+	if tx.Type() != types.LegacyTxType {
+		record.ChainID = tx.ChainId().String()
+		record.AccessList = make([]stationTypes.AccessTupleStruct, 0, len(tx.AccessList()))
+		for _, tuple := range tx.AccessList() {
+			storageKeys := make([]string, 0, len(tuple.StorageKeys))
+			for _, key := range tuple.StorageKeys {
+				storageKeys = append(storageKeys, key.Hex())
+			}
+			record.AccessList = append(record.AccessList, stationTypes.AccessTupleStruct{
+				Address:     tuple.Address.Hex(),
+				StorageKeys: storageKeys,
+			})
+		}
+	}
+	if tx.Type() >= types.DynamicFeeTxType {
+		record.MaxFeePerGas = tx.GasFeeCap().String()
+		record.MaxPriorityFeePerGas = tx.GasTipCap().String()
+	}
+	if tx.Type() == types.BlobTxType {
+		record.MaxFeePerBlobGas = tx.BlobGasFeeCap().String()
+		for _, hash := range tx.BlobHashes() {
+			record.BlobVersionedHashes = append(record.BlobVersionedHashes, hash.Hex())
+		}
+	}
+	return record
+}
@@ -0,0 +1,412 @@
+package blocksync
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	stationTypes "github.com/airchains-network/tracks/types"
+	"github.com/airchains-network/tracks/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// rpcRequest and rpcResponse are the JSON-RPC messages of the fake EVM station.
+type rpcRequest struct {
+	ID     json.RawMessage   `json:"id"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+}
+
+type rpcResponse struct {
+	JsonRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// rpcHandler answers a JSON-RPC method of the fake EVM station.
+type rpcHandler func(params []json.RawMessage) (any, *rpcError)
+
+// newTestEVMIndexer returns an EVMIndexer of chain 1 talking to a fake station that answers
+// single and batch requests with handlers, and counts the calls of every method.
+func newTestEVMIndexer(t *testing.T, handlers map[string]rpcHandler) (*EVMIndexer, map[string]int) {
+	t.Helper()
+	calls := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body json.RawMessage
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		answer := func(req rpcRequest) rpcResponse {
+			calls[req.Method]++
+			handler, ok := handlers[req.Method]
+			if !ok {
+				return rpcResponse{JsonRPC: "2.0", ID: req.ID, Error: &rpcError{Code: -32601, Message: "method not found"}}
+			}
+			result, rpcErr := handler(req.Params)
+			return rpcResponse{JsonRPC: "2.0", ID: req.ID, Result: result, Error: rpcErr}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if bytes.HasPrefix(bytes.TrimSpace(body), []byte("[")) {
+			var requests []rpcRequest
+			if err := json.Unmarshal(body, &requests); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			responses := make([]rpcResponse, 0, len(requests))
+			for _, req := range requests {
+				responses = append(responses, answer(req))
+			}
+			json.NewEncoder(w).Encode(responses)
+			return
+		}
+		var req rpcRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(answer(req))
+	}))
+	t.Cleanup(server.Close)
+
+	e := &EVMIndexer{
+		endpoints: utils.NewEndpointPool("station rpc", []string{server.URL}, nil),
+		chainID:   big.NewInt(1),
+		clients:   make(map[string]*ethclient.Client),
+	}
+	t.Cleanup(func() {
+		for _, client := range e.clients {
+			client.Close()
+		}
+	})
+	return e, calls
+}
+
+// testEVMTxns returns two transfers of chain 1 and their receipts in block 7. The first transfer
+// emits a log, the second one reverted.
+func testEVMTxns(t *testing.T) ([]*types.Transaction, []*types.Receipt) {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := types.LatestSignerForChainID(big.NewInt(1))
+	to := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+
+	var txs []*types.Transaction
+	var receipts []*types.Receipt
+	for i, status := range []uint64{types.ReceiptStatusSuccessful, types.ReceiptStatusFailed} {
+		tx := types.MustSignNewTx(key, signer, &types.DynamicFeeTx{
+			ChainID:   big.NewInt(1),
+			Nonce:     uint64(i),
+			GasTipCap: big.NewInt(1),
+			GasFeeCap: big.NewInt(10),
+			Gas:       21000,
+			To:        &to,
+			Value:     big.NewInt(int64(i + 1)),
+		})
+		receipt := &types.Receipt{
+			Type:              types.DynamicFeeTxType,
+			Status:            status,
+			CumulativeGasUsed: uint64(21000 * (i + 1)),
+			GasUsed:           21000,
+			TxHash:            tx.Hash(),
+			Logs:              []*types.Log{},
+			BlockNumber:       big.NewInt(7),
+			TransactionIndex:  uint(i),
+		}
+		if status == types.ReceiptStatusSuccessful {
+			receipt.Logs = append(receipt.Logs, &types.Log{
+				Address:     to,
+				Topics:      []common.Hash{common.HexToHash("0x01"), common.HexToHash("0x02")},
+				Data:        []byte{0xca, 0xfe},
+				BlockNumber: 7,
+				TxHash:      tx.Hash(),
+				TxIndex:     uint(i),
+				Index:       3,
+			})
+		}
+		txs = append(txs, tx)
+		receipts = append(receipts, receipt)
+	}
+	return txs, receipts
+}
+
+// testEVMBlockHash is the hash the fake station reports for its blocks. It is not the
+// go-ethereum hash of their header, as on Ethermint stations.
+const testEVMBlockHash = "0x1111111111111111111111111111111111111111111111111111111111111111"
+
+// rawEVMBlock returns the eth_getBlockByNumber result of the block at height with the given
+// transactions.
+func rawEVMBlock(t *testing.T, height int, txs ...any) json.RawMessage {
+	t.Helper()
+	transactions, err := json.Marshal(append([]any{}, txs...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return json.RawMessage(fmt.Sprintf(`{
+		"hash": "%s",
+		"parentHash": "0x2222222222222222222222222222222222222222222222222222222222222222",
+		"sha3Uncles": "0x1dcc4de8dec75d7aab85b567b6ccd41ad312451b948a7413f0a142fd40d49347",
+		"miner": "0x0000000000000000000000000000000000000000",
+		"stateRoot": "0x0000000000000000000000000000000000000000000000000000000000000000",
+		"transactionsRoot": "0x0000000000000000000000000000000000000000000000000000000000000000",
+		"receiptsRoot": "0x0000000000000000000000000000000000000000000000000000000000000000",
+		"logsBloom": "0x%s",
+		"difficulty": "0x0",
+		"number": "%s",
+		"gasLimit": "0x0",
+		"gasUsed": "0x0",
+		"timestamp": "0x0",
+		"extraData": "0x",
+		"size": "0x0",
+		"transactions": %s,
+		"uncles": []
+	}`, testEVMBlockHash, strings.Repeat("0", 512), hexutil.EncodeUint64(uint64(height)), transactions))
+}
+
+func TestEVMExtractTxnsReceipts(t *testing.T) {
+	txs, receipts := testEVMTxns(t)
+	blockReceipts := func(params []json.RawMessage) (any, *rpcError) { return receipts, nil }
+	txReceipt := func(params []json.RawMessage) (any, *rpcError) {
+		var hash common.Hash
+		if err := json.Unmarshal(params[0], &hash); err != nil {
+			return nil, &rpcError{Code: -32602, Message: err.Error()}
+		}
+		for _, receipt := range receipts {
+			if receipt.TxHash == hash {
+				return receipt, nil
+			}
+		}
+		return nil, nil
+	}
+	rejectWith := func(code int, message string) rpcHandler {
+		return func(params []json.RawMessage) (any, *rpcError) { return nil, &rpcError{Code: code, Message: message} }
+	}
+
+	tests := []struct {
+		name          string
+		blockReceipts rpcHandler
+		wantFallback  bool
+		wantErr       bool
+	}{
+		{"block receipts", blockReceipts, false, false},
+		{"method not found", nil, true, false},
+		{"method not available", rejectWith(-32000, "the method eth_getBlockReceipts does not exist/is not available"), true, false},
+		{"transient error", rejectWith(-32000, "header not found"), false, true},
+	}
+	for _, tt := range tests {
+		handlers := map[string]rpcHandler{"eth_getTransactionReceipt": txReceipt}
+		if tt.blockReceipts != nil {
+			handlers["eth_getBlockReceipts"] = tt.blockReceipts
+		}
+		e, calls := newTestEVMIndexer(t, handlers)
+
+		block, err := e.decodeBlock(7, rawEVMBlock(t, 7, txs[0], txs[1]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		txns, err := e.ExtractTxns(context.Background(), block)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("%s: ExtractTxns returned error %v", tt.name, err)
+		}
+		if e.noBlockReceipts.Load() != tt.wantFallback {
+			t.Errorf("%s: noBlockReceipts = %v, want %v", tt.name, e.noBlockReceipts.Load(), tt.wantFallback)
+		}
+		if tt.wantErr {
+			continue
+		}
+		if tt.wantFallback && calls["eth_getTransactionReceipt"] != 2 {
+			t.Errorf("%s: %d eth_getTransactionReceipt calls, want 2", tt.name, calls["eth_getTransactionReceipt"])
+		}
+
+		if len(txns) != 2 {
+			t.Fatalf("%s: got %d transactions, want 2", tt.name, len(txns))
+		}
+		var records []stationTypes.TransactionStruct
+		for _, txn := range txns {
+			var record stationTypes.TransactionStruct
+			if err = json.Unmarshal(txn, &record); err != nil {
+				t.Fatal(err)
+			}
+			records = append(records, record)
+		}
+		if records[0].Status != stationTypes.TxStatusSuccess || records[1].Status != stationTypes.TxStatusReverted {
+			t.Errorf("%s: statuses are %q and %q", tt.name, records[0].Status, records[1].Status)
+		}
+		if records[0].GasUsed != "21000" {
+			t.Errorf("%s: gas used is %q", tt.name, records[0].GasUsed)
+		}
+		if len(records[0].Logs) != 1 || len(records[1].Logs) != 0 {
+			t.Fatalf("%s: transactions have %d and %d logs", tt.name, len(records[0].Logs), len(records[1].Logs))
+		}
+		l := records[0].Logs[0]
+		if l.Address != common.HexToAddress("0xaa").Hex() || len(l.Topics) != 2 || l.Data != "0xcafe" ||
+			l.BlockNumber != 7 || l.BlockHash != testEVMBlockHash || l.TransactionHash != records[0].Hash || l.LogIndex != "3" {
+			t.Errorf("%s: stored log is %+v", tt.name, l)
+		}
+	}
+}
+
+func TestUnsupportedMethod(t *testing.T) {
+	rpcErr := func(code int, message string) error {
+		e, _ := newTestEVMIndexer(t, map[string]rpcHandler{
+			"eth_getBlockReceipts": func(params []json.RawMessage) (any, *rpcError) {
+				return nil, &rpcError{Code: code, Message: message}
+			},
+		})
+		return e.call(context.Background(), func(client *ethclient.Client) error {
+			return client.Client().CallContext(context.Background(), nil, "eth_getBlockReceipts", "0x1")
+		})
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"no error", nil, false},
+		{"not an rpc error", errors.New("connection refused"), false},
+		{"method not found code", rpcErr(-32601, "the method eth_getBlockReceipts does not exist/is not available"), true},
+		{"unsupported message", rpcErr(-32000, "unsupported method eth_getBlockReceipts"), true},
+		{"method not available", rpcErr(-32000, "the method eth_getBlockReceipts is not available"), true},
+		{"unknown block", rpcErr(-32000, "header not found"), false},
+		{"rate limited", rpcErr(-32005, "limit exceeded"), false},
+		{"invalid params", rpcErr(-32602, "invalid argument 0: hex string without 0x prefix"), false},
+	}
+	for _, tt := range tests {
+		if got := unsupportedMethod(tt.err); got != tt.want {
+			t.Errorf("%s: unsupportedMethod(%v) = %v, want %v", tt.name, tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestEVMDecodeTxn(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	to := common.HexToAddress("0xaa")
+	chainID := big.NewInt(1)
+	signed := func(signer types.Signer, data types.TxData) *types.Transaction {
+		return types.MustSignNewTx(key, signer, data)
+	}
+	latest := types.LatestSignerForChainID(chainID)
+
+	tests := []struct {
+		name       string
+		txn        any
+		wantType   uint64
+		wantReason string
+	}{
+		{"legacy", signed(types.NewEIP155Signer(chainID), &types.LegacyTx{Gas: 21000, GasPrice: big.NewInt(1), To: &to}), types.LegacyTxType, ""},
+		{"unprotected legacy", signed(types.HomesteadSigner{}, &types.LegacyTx{Gas: 21000, GasPrice: big.NewInt(1), To: &to}), types.LegacyTxType, ""},
+		{"access list", signed(latest, &types.AccessListTx{ChainID: chainID, Gas: 21000, GasPrice: big.NewInt(1), To: &to}), types.AccessListTxType, ""},
+		{"dynamic fee", signed(latest, &types.DynamicFeeTx{ChainID: chainID, Gas: 21000, GasFeeCap: big.NewInt(1), GasTipCap: big.NewInt(1), To: &to}), types.DynamicFeeTxType, ""},
+		{"other chain", signed(types.LatestSignerForChainID(big.NewInt(5)), &types.DynamicFeeTx{ChainID: big.NewInt(5), Gas: 21000, GasFeeCap: big.NewInt(1), GasTipCap: big.NewInt(1), To: &to}), types.DynamicFeeTxType, "failed to derive the sender address"},
+		{"unsupported type", json.RawMessage(`{"hash":"0x3333333333333333333333333333333333333333333333333333333333333333","type":"0x7e","nonce":"0x0"}`), 0x7e, "unsupported transaction type 126"},
+		{"missing fields", json.RawMessage(`{"hash":"0x3333333333333333333333333333333333333333333333333333333333333333","type":"0x0"}`), types.LegacyTxType, "undecodable transaction"},
+		{"not an object", json.RawMessage(`"0x3333333333333333333333333333333333333333333333333333333333333333"`), 0, "undecodable transaction"},
+	}
+	e := &EVMIndexer{chainID: chainID}
+	for _, tt := range tests {
+		raw, err := json.Marshal(tt.txn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		txn := e.decodeTxn(raw)
+		if txn.txType != tt.wantType {
+			t.Errorf("%s: type %d, want %d", tt.name, txn.txType, tt.wantType)
+		}
+		if tt.wantReason == "" {
+			if txn.tx == nil || txn.from != from || txn.hash != txn.tx.Hash() {
+				t.Errorf("%s: decoded %+v, want a transaction from %s", tt.name, txn, from.Hex())
+			}
+			continue
+		}
+		if txn.tx != nil || !strings.HasPrefix(txn.reason, tt.wantReason) {
+			t.Errorf("%s: decoded %+v, want a skipped transaction because of %q", tt.name, txn, tt.wantReason)
+		}
+	}
+}
+
+func TestEVMDecodeBlockSkipsTransactions(t *testing.T) {
+	txs, receipts := testEVMTxns(t)
+	unsupported := json.RawMessage(`{"hash":"0x3333333333333333333333333333333333333333333333333333333333333333","type":"0x7e","nonce":"0x0"}`)
+	e, _ := newTestEVMIndexer(t, map[string]rpcHandler{
+		"eth_getBlockReceipts": func(params []json.RawMessage) (any, *rpcError) {
+			return []*types.Receipt{receipts[0], nil, receipts[1]}, nil
+		},
+	})
+
+	block, err := e.decodeBlock(7, rawEVMBlock(t, 7, txs[0], unsupported, txs[1]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var data stationTypes.BlockStruct
+	if err = json.Unmarshal(block.Data, &data); err != nil {
+		t.Fatal(err)
+	}
+	if data.TransactionCount != 3 {
+		t.Errorf("block records %d transactions, want 3", data.TransactionCount)
+	}
+	want := []stationTypes.SkippedTransactionStruct{{
+		Hash:             "0x3333333333333333333333333333333333333333333333333333333333333333",
+		TransactionIndex: "1",
+		Type:             "126",
+		Reason:           "unsupported transaction type 126",
+	}}
+	if fmt.Sprint(data.SkippedTransactions) != fmt.Sprint(want) {
+		t.Errorf("skipped transactions are %+v, want %+v", data.SkippedTransactions, want)
+	}
+
+	txns, err := e.ExtractTxns(context.Background(), block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(txns) != 2 {
+		t.Fatalf("extracted %d transactions, want 2", len(txns))
+	}
+	var second stationTypes.TransactionStruct
+	if err = json.Unmarshal(txns[1], &second); err != nil {
+		t.Fatal(err)
+	}
+	if second.Hash != txs[1].Hash().Hex() || second.TransactionIndex != "2" {
+		t.Errorf("second transaction is %s at index %s, want %s at index 2", second.Hash, second.TransactionIndex, txs[1].Hash().Hex())
+	}
+}
+
+func TestEVMBlockHashIsStationHash(t *testing.T) {
+	block, err := (&EVMIndexer{}).decodeBlock(7, rawEVMBlock(t, 7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if block.Hash != testEVMBlockHash {
+		t.Errorf("block hash is %s, want the hash reported by the station", block.Hash)
+	}
+	if block.ParentHash != "0x2222222222222222222222222222222222222222222222222222222222222222" {
+		t.Errorf("parent hash is %s", block.ParentHash)
+	}
+	if _, err = (&EVMIndexer{}).decodeBlock(8, rawEVMBlock(t, 7)); err == nil {
+		t.Error("decoding block 7 as block 8 returned no error")
+	}
+}
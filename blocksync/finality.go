@@ -0,0 +1,152 @@
+package blocksync
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/airchains-network/tracks/config"
+	"github.com/airchains-network/tracks/store"
+)
+
+// finalizedTxnCountKey holds how many transactions of the txns-N sequence belong to finalized
+// station blocks. The pod generator never reads past it.
+const finalizedTxnCountKey = "finalizedTxnCount"
+
+// GetFinalizedTxnCount returns how many indexed transactions belong to finalized station blocks.
+func GetFinalizedTxnCount(ldt store.Store) (int, error) {
+	return getCounter(ldt, finalizedTxnCountKey)
+}
+
+func putFinalizedTxnCount(ldt store.Store, count int) error {
+	return ldt.Put([]byte(finalizedTxnCountKey), []byte(strconv.Itoa(count)))
+}
+
+// finalizedTimeKey holds the station time of the newest finalized block in unix seconds. Unlike
+// the transaction watermark it also moves on with finalized blocks without transactions.
+const finalizedTimeKey = "finalizedTime"
+
+// txnTimeKey holds the station time of the block of the transaction stored as txns-index.
+func txnTimeKey(index int) []byte {
+	return []byte(fmt.Sprintf("txnTime-%d", index))
+}
+
+// GetFinalizedTime returns the station time of the newest finalized block, or the zero time if
+// the station does not report block times.
+func GetFinalizedTime(ldt store.Store) (time.Time, error) {
+	return getUnixTime(ldt, finalizedTimeKey)
+}
+
+// GetTxnTime returns the station time of the block of the transaction stored as txns-index, or
+// the zero time if the station does not report block times or the transaction was indexed
+// before they were recorded.
+func GetTxnTime(ldt store.Store, index int) (time.Time, error) {
+	return getUnixTime(ldt, string(txnTimeKey(index)))
+}
+
+func getUnixTime(ldt store.Store, key string) (time.Time, error) {
+	seconds, err := getCounter(ldt, key)
+	if err != nil || seconds == 0 {
+		return time.Time{}, err
+	}
+	return time.Unix(int64(seconds), 0), nil
+}
+
+// stationFinality lists the finality policies of each station type, its default first. SVM
+// stations are never indexed past the finalized slot, because only then is it certain which
+// slots were skipped, so they support no other policy.
+var stationFinality = map[string][]string{
+	"evm":  {config.FinalityLatest, config.FinalityConfirmations, config.FinalitySafe, config.FinalityFinalized},
+	"wasm": {config.FinalityCommitted, config.FinalityLatest, config.FinalityConfirmations},
+	"svm":  {config.FinalityFinalized},
+}
+
+// FinalityPolicy resolves the finality policy of the station, falling back to the default of its
+// station type when none is configured, and checks that the station type supports it.
+func FinalityPolicy(station *config.StationConfig) (string, error) {
+	supported, ok := stationFinality[strings.ToLower(station.StationType)]
+	if !ok {
+		return "", fmt.Errorf("no finality policies for station type %q", station.StationType)
+	}
+	mode := strings.ToLower(strings.TrimSpace(station.Finality))
+	if mode == "" {
+		mode = supported[0]
+	}
+	for _, s := range supported {
+		if mode != s {
+			continue
+		}
+		if mode == config.FinalityConfirmations && station.Confirmations <= 0 {
+			return "", fmt.Errorf("finality %q needs a positive confirmations count", mode)
+		}
+		return mode, nil
+	}
+	return "", fmt.Errorf("finality %q is not supported for station type %q (one of %s)", mode, station.StationType, strings.Join(supported, " | "))
+}
+
+// confirmedHeight returns the highest block with at least confirmations blocks built on top of
+// it, or first-1 if there is none yet.
+func confirmedHeight(latest int, confirmations int, first int) int {
+	height := latest - confirmations
+	if height < first-1 {
+		return first - 1
+	}
+	return height
+}
+
+// initFinalized treats every transaction indexed before finality was tracked as finalized.
+func (s *Syncer) initFinalized() error {
+	_, err := s.ldt.Get([]byte(finalizedTxnCountKey))
+	if err != store.ErrNotFound {
+		return err
+	}
+	txnCount, err := getCounter(s.ldt, "txnCount")
+	if err != nil {
+		return err
+	}
+	return putFinalizedTxnCount(s.ldt, txnCount)
+}
+
+// advanceFinalized moves the finalized transaction watermark up to the last transaction of the
+// highest indexed block at or below finalizedHeight, and the finalized time up to its block time.
+// Neither moves backwards here.
+func (s *Syncer) advanceFinalized(finalizedHeight int, last *BlockMeta) error {
+	if last == nil {
+		return nil
+	}
+
+	meta := last
+	for height := finalizedHeight; meta.Height > finalizedHeight; height-- {
+		if height < s.indexer.FirstHeight() {
+			return nil
+		}
+		found, err := GetBlockMeta(s.ldt, height)
+		if err != nil {
+			return err
+		}
+		if found != nil {
+			meta = found
+		}
+	}
+
+	finalized, err := GetFinalizedTxnCount(s.ldt)
+	if err != nil {
+		return err
+	}
+	finalizedTime, err := getCounter(s.ldt, finalizedTimeKey)
+	if err != nil {
+		return err
+	}
+	if meta.TxnEnd <= finalized && meta.Time <= int64(finalizedTime) {
+		return nil
+	}
+	batch := s.ldt.NewBatch()
+	if meta.TxnEnd > finalized {
+		batch.Put([]byte(finalizedTxnCountKey), []byte(strconv.Itoa(meta.TxnEnd)))
+	}
+	if meta.Time > int64(finalizedTime) {
+		batch.Put([]byte(finalizedTimeKey), []byte(strconv.FormatInt(meta.Time, 10)))
+	}
+	return s.ldt.Write(batch)
+}
@@ -0,0 +1,104 @@
+package blocksync
+
+import (
+	"testing"
+
+	"github.com/airchains-network/tracks/config"
+)
+
+func TestFinalityPolicy(t *testing.T) {
+	tests := []struct {
+		stationType   string
+		finality      string
+		confirmations int
+		want          string
+	}{
+		{"evm", "", 0, config.FinalityLatest},
+		{"EVM", "Safe", 0, config.FinalitySafe},
+		{"evm", config.FinalityConfirmations, 12, config.FinalityConfirmations},
+		{"evm", config.FinalityConfirmations, 0, ""},
+		{"evm", config.FinalityCommitted, 0, ""},
+		{"wasm", "", 0, config.FinalityCommitted},
+		{"wasm", config.FinalitySafe, 0, ""},
+		{"svm", "", 0, config.FinalityFinalized},
+		{"svm", config.FinalityLatest, 0, ""},
+		{"svm", config.FinalityConfirmations, 32, ""},
+		{"move", "", 0, ""},
+	}
+	for _, tt := range tests {
+		got, err := FinalityPolicy(&config.StationConfig{StationType: tt.stationType, Finality: tt.finality, Confirmations: tt.confirmations})
+		if got != tt.want || (err == nil) != (tt.want != "") {
+			t.Errorf("FinalityPolicy(%s, %q, %d) = %q, %v, want %q", tt.stationType, tt.finality, tt.confirmations, got, err, tt.want)
+		}
+	}
+}
+
+func TestConfirmedHeight(t *testing.T) {
+	tests := []struct{ latest, confirmations, first, want int }{
+		{100, 10, 1, 90},
+		{5, 10, 1, 0},
+		{5, 10, 3, 2},
+		{7, 0, 1, 7},
+	}
+	for _, tt := range tests {
+		if got := confirmedHeight(tt.latest, tt.confirmations, tt.first); got != tt.want {
+			t.Errorf("confirmedHeight(%d, %d, %d) = %d, want %d", tt.latest, tt.confirmations, tt.first, got, tt.want)
+		}
+	}
+}
+
+func TestFinalizedWatermark(t *testing.T) {
+	s := newTestSyncer(t, newFakeIndexer("a", 6, 2), nil)
+	indexBlocks(t, s, 1, 6)
+
+	// transactions indexed before finality was tracked count as finalized
+	if err := s.initFinalized(); err != nil {
+		t.Fatal(err)
+	}
+	if got := counter(t, s.ldt, finalizedTxnCountKey); got != 12 {
+		t.Fatalf("finalizedTxnCount = %d after initFinalized, want 12", got)
+	}
+
+	s = newTestSyncer(t, newFakeIndexer("a", 6, 2), nil)
+	indexBlocks(t, s, 1, 6)
+	if err := putFinalizedTxnCount(s.ldt, 0); err != nil {
+		t.Fatal(err)
+	}
+	last, err := GetBlockMeta(s.ldt, 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, step := range []struct{ finalizedHeight, want, wantTime int }{
+		{0, 0, 0},
+		{3, 6, 30},
+		{2, 6, 30}, // the watermark never moves back
+		{5, 10, 50},
+		{9, 12, 60},
+	} {
+		if err = s.advanceFinalized(step.finalizedHeight, last); err != nil {
+			t.Fatal(err)
+		}
+		if got := counter(t, s.ldt, finalizedTxnCountKey); got != step.want {
+			t.Errorf("finalizedTxnCount = %d at finalized height %d, want %d", got, step.finalizedHeight, step.want)
+		}
+		if got := counter(t, s.ldt, finalizedTimeKey); got != step.wantTime {
+			t.Errorf("finalizedTime = %d at finalized height %d, want %d", got, step.finalizedHeight, step.wantTime)
+		}
+	}
+	if txnTime, err := GetTxnTime(s.ldt, 6); err != nil || txnTime.Unix() != 30 {
+		t.Errorf("time of txns-6 is %v, %v, want the time of block 3", txnTime, err)
+	}
+
+	// finalized blocks without transactions move the finalized time on
+	s = newTestSyncer(t, newFakeIndexer("a", 4, 0), nil)
+	indexBlocks(t, s, 1, 4)
+	if last, err = s.lastBlockMeta(4); err != nil {
+		t.Fatal(err)
+	}
+	if err = s.advanceFinalized(4, last); err != nil {
+		t.Fatal(err)
+	}
+	if finalizedTime, err := GetFinalizedTime(s.ldt); err != nil || finalizedTime.Unix() != 40 {
+		t.Errorf("finalized time is %v, %v after empty blocks, want the time of block 4", finalizedTime, err)
+	}
+}
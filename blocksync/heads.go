@@ -0,0 +1,61 @@
+package blocksync
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// indexerSubscribedPollInterval is how long the indexer waits for a new block before polling the
+// station anyway while a head subscription is open.
+const indexerSubscribedPollInterval = 15 * time.Second
+
+// HeadSubscriber is implemented by indexers that can push new station heads instead of being
+// polled for them.
+type HeadSubscriber interface {
+	// SubscribeHeads opens a subscription that receives the height of every new station block.
+	// The channel is closed when the subscription ends or ctx is cancelled. Heights are wake-up
+	// signals only: they may be dropped, so the Syncer always indexes every height in between.
+	SubscribeHeads(ctx context.Context) (<-chan int, error)
+}
+
+// watchHeads keeps a head subscription open until ctx is cancelled and wakes the Syncer on every
+// new station head.
+func (s *Syncer) watchHeads(ctx context.Context, subscriber HeadSubscriber) {
+	for ctx.Err() == nil {
+		heads, err := subscriber.SubscribeHeads(ctx)
+		if err != nil {
+			log.Warn().Str("module", "blocksync").Err(err).Msg("Failed to subscribe to new station blocks")
+			sleepContext(ctx, indexerRetryInterval)
+			continue
+		}
+		log.Info().Str("module", "blocksync").Msg("Subscribed to new station blocks")
+		for range heads {
+			select {
+			case s.headCh <- struct{}{}:
+			default:
+			}
+		}
+		if ctx.Err() == nil {
+			log.Warn().Str("module", "blocksync").Msg("Station block subscription closed, resubscribing")
+			sleepContext(ctx, indexerRetryInterval)
+		}
+	}
+}
+
+// waitForHead waits until the station announces a new block, the poll interval elapses or ctx is
+// cancelled.
+func (s *Syncer) waitForHead(ctx context.Context) {
+	interval := indexerPollInterval
+	if _, ok := s.indexer.(HeadSubscriber); ok {
+		interval = indexerSubscribedPollInterval
+	}
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	case <-s.headCh:
+	}
+}
@@ -0,0 +1,364 @@
+package blocksync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/airchains-network/tracks/config"
+	"github.com/airchains-network/tracks/store"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// indexerPollInterval is how long the indexer waits for the station to produce a new block.
+	indexerPollInterval = 2 * time.Second
+	// indexerRetryInterval is how long the indexer waits after a failed station call.
+	indexerRetryInterval = 3 * time.Second
+)
+
+// StationBlock is the station agnostic view of a block that a StationIndexer hands to the
+// shared persistence path.
+type StationBlock struct {
+	Height     int
+	Hash       string
+	ParentHash string
+	// Time is when the station produced the block, or zero if the station does not report it.
+	// Partial pods are sealed by it, so every track seals them at the same transaction.
+	Time time.Time
+	// Data is the encoded block record stored under the indexer's block key.
+	Data []byte
+	// Payload is the station specific block, used by the indexer to extract transactions.
+	Payload any
+	// Skipped marks a height the station produced no block at, such as a skipped Solana slot.
+	// Its Data is stored as a marker so the height is never fetched again; it has no
+	// transactions and does not take part in following the chain.
+	Skipped bool
+}
+
+// StationIndexer is implemented once per station type. It only knows how to talk to the
+// station; progress tracking and persistence are shared by every indexer.
+type StationIndexer interface {
+	// FirstHeight returns the lowest block height the station produces.
+	FirstHeight() int
+	// LatestHeight returns the current head of the station.
+	LatestHeight(ctx context.Context) (int, error)
+	// FinalizedHeight returns the highest block that is final under the station's finality
+	// policy. Transactions above it are indexed but not handed to the pod generator.
+	FinalizedHeight(ctx context.Context) (int, error)
+	// FetchBlock fetches the block at the given height.
+	FetchBlock(ctx context.Context, height int) (*StationBlock, error)
+	// ExtractTxns returns the encoded transaction records of a block, in block order.
+	ExtractTxns(ctx context.Context, block *StationBlock) ([][]byte, error)
+	// BlockKey returns the block database key a block of the given height is stored under.
+	BlockKey(height int) string
+}
+
+// IndexerFactory builds a StationIndexer for the configured station.
+type IndexerFactory func(station *config.StationConfig) (StationIndexer, error)
+
+var (
+	indexersMu sync.RWMutex
+	indexers   = make(map[string]IndexerFactory)
+)
+
+// RegisterIndexer makes a StationIndexer available for the given station type. Station types
+// are case-insensitive. It panics if the station type is already registered.
+func RegisterIndexer(stationType string, factory IndexerFactory) {
+	indexersMu.Lock()
+	defer indexersMu.Unlock()
+
+	name := strings.ToLower(stationType)
+	if _, exists := indexers[name]; exists {
+		panic(fmt.Sprintf("blocksync: indexer already registered for station type %q", stationType))
+	}
+	indexers[name] = factory
+}
+
+// NewStationIndexer returns the StationIndexer registered for the station type of the given
+// station configuration.
+func NewStationIndexer(station *config.StationConfig) (StationIndexer, error) {
+	if station == nil {
+		return nil, fmt.Errorf("station config is missing")
+	}
+
+	indexersMu.RLock()
+	factory, ok := indexers[strings.ToLower(station.StationType)]
+	indexersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("no indexer registered for station type %q", station.StationType)
+	}
+	return factory(station)
+}
+
+// Syncer drives a StationIndexer, fetching ranges of blocks concurrently while it is behind the
+// station head and persisting every block with its transactions strictly in height order. It stops between blocks when its
+// context is cancelled, so a shutdown never interrupts a write.
+type Syncer struct {
+	indexer StationIndexer
+	ldb     store.Store
+	ldt     store.Store
+	height  atomic.Int64
+
+	// headCh wakes the Syncer when a HeadSubscriber announces a new station block.
+	headCh chan struct{}
+
+	// workers and batchSize bound the concurrent station requests while catching up.
+	workers   int
+	batchSize int
+
+	// committedTxns returns how many transactions of the txns-N sequence are part of a
+	// generated pod. A reorganisation is never unwound below that count.
+	committedTxns func() (int, error)
+}
+
+// NewSyncer returns a Syncer that indexes with the given indexer into the block and txn databases.
+// committedTxns may be nil, in which case reorganisations are unwound without a lower bound.
+func NewSyncer(indexer StationIndexer, ldb store.Store, ldt store.Store, committedTxns func() (int, error)) *Syncer {
+	s := &Syncer{
+		indexer:       indexer,
+		ldb:           ldb,
+		ldt:           ldt,
+		committedTxns: committedTxns,
+		headCh:        make(chan struct{}, 1),
+	}
+	s.SetCatchUp(0, 0)
+	return s
+}
+
+// Height returns the height of the next block the Syncer will index.
+func (s *Syncer) Height() int {
+	return int(s.height.Load())
+}
+
+// Run indexes the station until ctx is cancelled. It resumes after the recorded progress, or at
+// startHeight if no progress has been recorded yet.
+func (s *Syncer) Run(ctx context.Context, startHeight int) error {
+	height, err := s.resumeHeight(startHeight)
+	if err != nil {
+		return err
+	}
+	if height < s.indexer.FirstHeight() {
+		height = s.indexer.FirstHeight()
+	}
+	s.height.Store(int64(height))
+
+	last, err := s.lastBlockMeta(height - 1)
+	if err != nil {
+		return err
+	}
+	if err = s.initFinalized(); err != nil {
+		return err
+	}
+	if err = s.catchUpLedger(); err != nil {
+		log.Error().Str("module", "blocksync").Err(err).Msg("Failed to replay transactions into the ledger, pods fall back to station state")
+	}
+
+	if subscriber, ok := s.indexer.(HeadSubscriber); ok {
+		go s.watchHeads(ctx, subscriber)
+	}
+
+	latestHeight, finalizedHeight := height-1, height-1
+	var pending []*fetchedBlock
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if height > latestHeight {
+			if latestHeight, err = s.indexer.LatestHeight(ctx); err != nil {
+				log.Warn().Str("module", "blocksync").Err(err).Msg("Failed to get latest station height")
+				sleepContext(ctx, indexerRetryInterval)
+				continue
+			}
+			if finalizedHeight, err = s.indexer.FinalizedHeight(ctx); err != nil {
+				log.Warn().Str("module", "blocksync").Err(err).Msg("Failed to get finalized station height")
+				sleepContext(ctx, indexerRetryInterval)
+				continue
+			}
+			if err = s.advanceFinalized(finalizedHeight, last); err != nil {
+				log.Error().Str("module", "blocksync").Err(err).Msg("Failed to advance the finalized transaction count")
+			}
+			if height > latestHeight {
+				s.waitForHead(ctx)
+				continue
+			}
+		}
+
+		if len(pending) == 0 {
+			pending, err = s.fetchRange(ctx, height, s.fetchWindow(height, latestHeight))
+			if err != nil {
+				log.Warn().Str("module", "blocksync").Err(err).Msg(fmt.Sprintf("Failed to fetch blocks past block %d", height+len(pending)-1))
+			}
+			if len(pending) == 0 {
+				sleepContext(ctx, indexerRetryInterval)
+				continue
+			}
+		}
+		block, txns := pending[0].block, pending[0].txns
+		pending = pending[1:]
+
+		if !extendsChain(last, block) {
+			log.Warn().Str("module", "blocksync").Msg(fmt.Sprintf("Station reorganisation detected at block %d", height))
+			pending = nil
+			ancestor, err := s.findCommonAncestor(ctx, last)
+			if err != nil {
+				log.Warn().Str("module", "blocksync").Err(err).Msg("Failed to find the common ancestor")
+				sleepContext(ctx, indexerRetryInterval)
+				continue
+			}
+			if err = s.rewind(ancestor, height-1); err != nil {
+				if errors.Is(err, ErrReorgBeyondCommitted) {
+					return err
+				}
+				log.Error().Str("module", "blocksync").Err(err).Msg("Failed to unwind reorganised blocks")
+				sleepContext(ctx, indexerRetryInterval)
+				continue
+			}
+			log.Info().Str("module", "blocksync").Msg(fmt.Sprintf("Unwound blocks %d-%d, resuming from block %d", ancestor.Height+1, height-1, ancestor.Height+1))
+			height = ancestor.Height + 1
+			s.height.Store(int64(height))
+			if last, err = s.lastBlockMeta(ancestor.Height); err != nil {
+				return err
+			}
+			continue
+		}
+
+		meta, err := persistBlock(s.indexer, block, txns, s.ldb, s.ldt)
+		if err != nil {
+			log.Error().Str("module", "blocksync").Err(err).Msg(fmt.Sprintf("Failed to store block %d", height))
+			pending = nil
+			sleepContext(ctx, indexerRetryInterval)
+			continue
+		}
+
+		if !meta.Skipped {
+			last = meta
+		}
+		height++
+		s.height.Store(int64(height))
+
+		if meta.Height <= finalizedHeight {
+			if err = s.advanceFinalized(finalizedHeight, meta); err != nil {
+				log.Error().Str("module", "blocksync").Err(err).Msg("Failed to advance the finalized transaction count")
+			}
+		}
+	}
+}
+
+// sleepContext waits for the given duration or until ctx is cancelled.
+func sleepContext(ctx context.Context, d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
+
+// persistBlock writes the block together with blockCount in one batch of the block database, then
+// appends its transactions to the txns-N sequence together with their ledger changes, the
+// txnCount counter, the block meta and the indexer progress in one batch of the txn database.
+// If the second batch is lost the progress still points at the previous block, so the block is
+// simply stored again and resumeHeight puts blockCount back in line with the progress.
+func persistBlock(indexer StationIndexer, block *StationBlock, txns [][]byte, ldb store.Store, ldt store.Store) (*BlockMeta, error) {
+	blockBatch := ldb.NewBatch()
+	blockBatch.Put([]byte(indexer.BlockKey(block.Height)), block.Data)
+	blockBatch.Put([]byte("blockCount"), []byte(strconv.Itoa(block.Height+1)))
+	if err := ldb.Write(blockBatch); err != nil {
+		return nil, fmt.Errorf("error inserting block data into database: %w", err)
+	}
+
+	transactionNumber, err := getCounter(ldt, "txnCount")
+	if err != nil {
+		return nil, err
+	}
+	meta := &BlockMeta{
+		Height:     block.Height,
+		Hash:       block.Hash,
+		ParentHash: block.ParentHash,
+		Skipped:    block.Skipped,
+		TxnStart:   transactionNumber + 1,
+	}
+	if !block.Time.IsZero() {
+		meta.Time = block.Time.Unix()
+	}
+
+	batch := ldt.NewBatch()
+	for _, txn := range txns {
+		transactionNumber++
+		batch.Put([]byte(fmt.Sprintf("txns-%d", transactionNumber)), txn)
+		if err = putTxnIndexEntries(indexer, batch, transactionNumber, txn); err != nil {
+			return nil, fmt.Errorf("error indexing transaction %d: %w", transactionNumber, err)
+		}
+		if meta.Time != 0 {
+			batch.Put(txnTimeKey(transactionNumber), []byte(strconv.FormatInt(meta.Time, 10)))
+		}
+	}
+	if err = appendLedger(indexer, ldt, batch, block, meta.TxnStart, txns); err != nil {
+		return nil, err
+	}
+	batch.Put([]byte("txnCount"), []byte(strconv.Itoa(transactionNumber)))
+	meta.TxnEnd = transactionNumber
+
+	if err = putBlockMeta(batch, meta); err != nil {
+		return nil, err
+	}
+	if err = putProgress(batch, &Progress{Height: block.Height, Hash: block.Hash, TxnIndex: transactionNumber}); err != nil {
+		return nil, err
+	}
+	if err = ldt.Write(batch); err != nil {
+		return nil, fmt.Errorf("error inserting transactions of block %d into database: %w", block.Height, err)
+	}
+	return meta, nil
+}
+
+// putTxnIndexEntries adds the secondary index entries of a transaction to batch.
+func putTxnIndexEntries(indexer StationIndexer, batch store.Batch, index int, txn []byte) error {
+	entries, err := txnIndexEntries(indexer, index, txn)
+	if err != nil {
+		return err
+	}
+	for key, value := range entries {
+		batch.Put([]byte(key), value)
+	}
+	return nil
+}
+
+// deleteTxnIndexEntries adds the removal of the secondary index entries of a stored transaction
+// to batch.
+func deleteTxnIndexEntries(indexer StationIndexer, ldt store.Store, batch store.Batch, index int) error {
+	txn, err := GetTxn(ldt, index)
+	if err != nil || txn == nil {
+		return err
+	}
+	entries, err := txnIndexEntries(indexer, index, txn)
+	if err != nil {
+		return err
+	}
+	for key := range entries {
+		batch.Delete([]byte(key))
+	}
+	return nil
+}
+
+// getCounter reads a decimal counter, treating a missing key as zero.
+func getCounter(db store.Store, key string) (int, error) {
+	value, err := db.Get([]byte(key))
+	if err == store.ErrNotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get %s: %w", key, err)
+	}
+	counter, err := strconv.Atoi(strings.TrimSpace(string(value)))
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return counter, nil
+}
@@ -0,0 +1,151 @@
+package blocksync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/airchains-network/tracks/store"
+)
+
+// fakeBlock is a block of the fake station: its hash, the hash of its parent and its
+// transactions.
+type fakeBlock struct {
+	Hash   string   `json:"hash"`
+	Parent string   `json:"parent"`
+	Txns   []string `json:"txns"`
+}
+
+// fakeIndexer is a StationIndexer over an in-memory chain that tests can extend and reorganise.
+type fakeIndexer struct {
+	mu        sync.Mutex
+	blocks    map[int]fakeBlock
+	latest    int
+	finalized int
+}
+
+// newFakeIndexer returns a station with blocks 1..latest on fork, each holding txns
+// transactions.
+func newFakeIndexer(fork string, latest int, txns int) *fakeIndexer {
+	f := &fakeIndexer{blocks: make(map[int]fakeBlock)}
+	f.extend(fork, 1, latest, txns)
+	return f
+}
+
+// extend replaces the blocks from..to with blocks of fork, building on the block before from.
+func (f *fakeIndexer) extend(fork string, from int, to int, txns int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for height := from; height <= to; height++ {
+		block := fakeBlock{Hash: fmt.Sprintf("%s%d", fork, height), Parent: f.blocks[height-1].Hash}
+		for i := 0; i < txns; i++ {
+			block.Txns = append(block.Txns, fmt.Sprintf("%s-%d", block.Hash, i))
+		}
+		f.blocks[height] = block
+	}
+	if to > f.latest {
+		f.latest = to
+	}
+}
+
+func (f *fakeIndexer) FirstHeight() int { return 1 }
+
+func (f *fakeIndexer) LatestHeight(ctx context.Context) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.latest, nil
+}
+
+func (f *fakeIndexer) FinalizedHeight(ctx context.Context) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.finalized, nil
+}
+
+func (f *fakeIndexer) FetchBlock(ctx context.Context, height int) (*StationBlock, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	block, ok := f.blocks[height]
+	if !ok || height > f.latest {
+		return nil, fmt.Errorf("no block %d", height)
+	}
+	data, err := json.Marshal(block)
+	if err != nil {
+		return nil, err
+	}
+	// the station produces a block every ten seconds
+	return &StationBlock{Height: height, Hash: block.Hash, ParentHash: block.Parent, Time: time.Unix(int64(10*height), 0), Data: data, Payload: block}, nil
+}
+
+func (f *fakeIndexer) ExtractTxns(ctx context.Context, block *StationBlock) ([][]byte, error) {
+	var txns [][]byte
+	for _, txn := range block.Payload.(fakeBlock).Txns {
+		txns = append(txns, []byte(txn))
+	}
+	return txns, nil
+}
+
+func (f *fakeIndexer) BlockKey(height int) string {
+	return fmt.Sprintf("block-%d", height)
+}
+
+// DescribeTxn makes the fake station maintain the hash index.
+func (f *fakeIndexer) DescribeTxn(txn []byte) (string, []string, error) {
+	return string(txn), nil, nil
+}
+
+// newTestSyncer returns a Syncer of indexer over empty memory databases.
+func newTestSyncer(t *testing.T, indexer StationIndexer, committedTxns func() (int, error)) *Syncer {
+	t.Helper()
+	return NewSyncer(indexer, store.NewMemory(), store.NewMemory(), committedTxns)
+}
+
+// indexBlocks persists the blocks from..to of the station the way the Syncer does.
+func indexBlocks(t *testing.T, s *Syncer, from int, to int) {
+	t.Helper()
+	for height := from; height <= to; height++ {
+		block, err := s.indexer.FetchBlock(context.Background(), height)
+		if err != nil {
+			t.Fatal(err)
+		}
+		txns, err := s.indexer.ExtractTxns(context.Background(), block)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = persistBlock(s.indexer, block, txns, s.ldb, s.ldt); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// counter returns a counter of db, failing the test when it cannot be read.
+func counter(t *testing.T, db store.Store, key string) int {
+	t.Helper()
+	value, err := getCounter(db, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return value
+}
+
+func TestRegisteredIndexers(t *testing.T) {
+	for _, stationType := range []string{"evm", "wasm", "svm"} {
+		indexersMu.RLock()
+		_, ok := indexers[stationType]
+		indexersMu.RUnlock()
+		if !ok {
+			t.Errorf("no indexer registered for %s", stationType)
+		}
+	}
+
+	defer func() {
+		if r := recover(); r == nil || !strings.Contains(fmt.Sprint(r), "already registered") {
+			t.Errorf("registering EVM twice recovered %v", r)
+		}
+	}()
+	RegisterIndexer("EVM", nil)
+}
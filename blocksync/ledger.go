@@ -0,0 +1,490 @@
+package blocksync
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+
+	logs "github.com/airchains-network/tracks/log"
+	"github.com/airchains-network/tracks/store"
+)
+
+// Account ledger keys in the txn database. ledgerTxnIndex and ledgerHeight are the last
+// transaction of the txns-N sequence and the last block applied to the ledger; both are missing
+// until the ledger is seeded.
+const (
+	ledgerSeedKey        = "ledgerSeed"
+	ledgerTxnIndexKey    = "ledgerTxnIndex"
+	ledgerHeightKey      = "ledgerHeight"
+	ledgerAccountPrefix  = "ledgeracct-"
+	ledgerPreStatePrefix = "ledgerpre-"
+)
+
+// ledgerCatchUpBatch is about how many stored transactions are replayed into the ledger per
+// write; a write always ends at a block boundary.
+const ledgerCatchUpBatch = 1000
+
+// LedgerIndexer is implemented by indexers of stations whose account balances and nonces tracks
+// keeps in its own ledger. Once the ledger is seeded from a snapshot, the Syncer applies every
+// indexed transaction to it and records the accounts it touches as they were before the
+// transaction, so pods get their pre-state without querying historical station state.
+//
+// Value the station moves outside the transfers, such as contract internal transfers, fee
+// recipient tips, block rewards and withdrawals, is not in the ledger. Called contracts, fee
+// recipients and withdrawal recipients are marked drifted, as are accounts whose ledger balance
+// goes negative or whose nonce does not match a transaction, which is how the recipients of
+// internal transfers show up. Pods read the pre-state of drifted accounts from the station.
+type LedgerIndexer interface {
+	// LedgerTransfer returns the ledger changes of a stored transaction record.
+	LedgerTransfer(txn []byte) (*LedgerTransfer, error)
+	// LedgerBlockAccounts returns the accounts a stored block credits outside its transactions.
+	LedgerBlockAccounts(block []byte) ([]string, error)
+}
+
+// LedgerTransfer is what a transaction changes in the ledger: Fee is always charged to From and
+// the nonce of From becomes Nonce+1; Value only moves from From to To when Success is set. To is
+// empty when the value has no recipient. Untracked lists the accounts the transaction may change
+// beyond the transfer, such as a called contract.
+type LedgerTransfer struct {
+	From      string
+	To        string
+	Value     *big.Int
+	Fee       *big.Int
+	Nonce     uint64
+	Success   bool
+	Untracked []string
+}
+
+// LedgerAccount is the balance and nonce of a ledger account. Balance is a decimal string. A
+// drifted account no longer matches the station and stays drifted until the ledger is seeded
+// again.
+type LedgerAccount struct {
+	Balance string `json:"balance"`
+	Nonce   uint64 `json:"nonce"`
+	Drifted bool   `json:"drifted,omitempty"`
+}
+
+// LedgerPreState holds the ledger accounts of a transaction's sender and receiver as they were
+// right before the transaction.
+type LedgerPreState struct {
+	Sender          string        `json:"sender"`
+	SenderAccount   LedgerAccount `json:"senderAccount"`
+	Receiver        string        `json:"receiver,omitempty"`
+	ReceiverAccount LedgerAccount `json:"receiverAccount"`
+}
+
+// LedgerSnapshot seeds the ledger with the station accounts as of the end of block Height.
+type LedgerSnapshot struct {
+	Height   int                      `json:"height"`
+	Accounts map[string]LedgerAccount `json:"accounts"`
+}
+
+// LedgerSeed records the snapshot the ledger was seeded from and the last transaction of its block.
+type LedgerSeed struct {
+	Height   int `json:"height"`
+	TxnIndex int `json:"txnIndex"`
+}
+
+func ledgerAccountKey(address string) []byte {
+	return []byte(ledgerAccountPrefix + normalizeLookupKey(address))
+}
+
+func ledgerPreStateKey(index int) []byte {
+	return []byte(fmt.Sprintf("%s%d", ledgerPreStatePrefix, index))
+}
+
+// GetLedgerSeed returns the seed of the ledger, or nil if the ledger is not seeded.
+func GetLedgerSeed(ldt store.Store) (*LedgerSeed, error) {
+	data, err := ldt.Get([]byte(ledgerSeedKey))
+	if err == store.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var seed LedgerSeed
+	if err = json.Unmarshal(data, &seed); err != nil {
+		return nil, fmt.Errorf("invalid ledger seed: %w", err)
+	}
+	return &seed, nil
+}
+
+// getLedgerHeight returns the last block applied to the ledger and whether the ledger is seeded.
+func getLedgerHeight(ldt store.Store) (int, bool, error) {
+	value, err := ldt.Get([]byte(ledgerHeightKey))
+	if err == store.ErrNotFound {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	height, err := strconv.Atoi(string(value))
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid %s: %w", ledgerHeightKey, err)
+	}
+	return height, true, nil
+}
+
+// putLedgerPosition adds the last block and transaction applied to the ledger to batch.
+func putLedgerPosition(batch store.Batch, height int, txnIndex int) {
+	batch.Put([]byte(ledgerHeightKey), []byte(strconv.Itoa(height)))
+	batch.Put([]byte(ledgerTxnIndexKey), []byte(strconv.Itoa(txnIndex)))
+}
+
+// getLedgerTxnIndex returns the last transaction applied to the ledger and whether the ledger
+// is seeded at all.
+func getLedgerTxnIndex(ldt store.Store) (int, bool, error) {
+	value, err := ldt.Get([]byte(ledgerTxnIndexKey))
+	if err == store.ErrNotFound {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	applied, err := strconv.Atoi(string(value))
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid %s: %w", ledgerTxnIndexKey, err)
+	}
+	return applied, true, nil
+}
+
+// GetLedgerAccount returns the ledger account of address, which is empty if the ledger has not
+// seen the address.
+func GetLedgerAccount(ldt store.Store, address string) (LedgerAccount, error) {
+	account := LedgerAccount{Balance: "0"}
+	data, err := ldt.Get(ledgerAccountKey(address))
+	if err == store.ErrNotFound {
+		return account, nil
+	}
+	if err != nil {
+		return account, err
+	}
+	if err = json.Unmarshal(data, &account); err != nil {
+		return account, fmt.Errorf("invalid ledger account %s: %w", address, err)
+	}
+	return account, nil
+}
+
+// GetLedgerPreState returns the pre-state the ledger recorded for the transaction stored as
+// txns-index, or nil if the transaction was not applied to the ledger.
+func GetLedgerPreState(ldt store.Store, index int) (*LedgerPreState, error) {
+	data, err := ldt.Get(ledgerPreStateKey(index))
+	if err == store.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var pre LedgerPreState
+	if err = json.Unmarshal(data, &pre); err != nil {
+		return nil, fmt.Errorf("invalid ledger pre-state of transaction %d: %w", index, err)
+	}
+	return &pre, nil
+}
+
+// ledgerView reads ledger accounts through the changes already added to a batch.
+type ledgerView struct {
+	ldt      store.Store
+	accounts map[string]LedgerAccount
+}
+
+func newLedgerView(ldt store.Store) *ledgerView {
+	return &ledgerView{ldt: ldt, accounts: make(map[string]LedgerAccount)}
+}
+
+func (v *ledgerView) get(address string) (LedgerAccount, error) {
+	if account, ok := v.accounts[normalizeLookupKey(address)]; ok {
+		return account, nil
+	}
+	return GetLedgerAccount(v.ldt, address)
+}
+
+func (v *ledgerView) put(batch store.Batch, address string, account LedgerAccount) error {
+	data, err := json.Marshal(account)
+	if err != nil {
+		return err
+	}
+	v.accounts[normalizeLookupKey(address)] = account
+	batch.Put(ledgerAccountKey(address), data)
+	return nil
+}
+
+// apply adds the ledger changes of the transaction stored as txns-index, and its pre-state, to batch.
+func (v *ledgerView) apply(ledger LedgerIndexer, batch store.Batch, index int, txn []byte) error {
+	transfer, err := ledger.LedgerTransfer(txn)
+	if err != nil || transfer == nil {
+		return err
+	}
+
+	pre := LedgerPreState{Sender: transfer.From, Receiver: transfer.To}
+	if pre.SenderAccount, err = v.get(transfer.From); err != nil {
+		return err
+	}
+	if transfer.To != "" {
+		if pre.ReceiverAccount, err = v.get(transfer.To); err != nil {
+			return err
+		}
+	}
+	data, err := json.Marshal(pre)
+	if err != nil {
+		return err
+	}
+	batch.Put(ledgerPreStateKey(index), data)
+
+	debit := new(big.Int).Set(transfer.Fee)
+	if transfer.Success {
+		debit.Add(debit, transfer.Value)
+	}
+	if err = v.adjust(batch, index, transfer.From, new(big.Int).Neg(debit), &transfer.Nonce); err != nil {
+		return err
+	}
+	if transfer.Success && transfer.To != "" {
+		if err = v.adjust(batch, index, transfer.To, transfer.Value, nil); err != nil {
+			return err
+		}
+	}
+	for _, address := range transfer.Untracked {
+		if err = v.drift(batch, address); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// drift marks the ledger account of address as no longer matching the station.
+func (v *ledgerView) drift(batch store.Batch, address string) error {
+	account, err := v.get(address)
+	if err != nil || account.Drifted {
+		return err
+	}
+	account.Drifted = true
+	return v.put(batch, address, account)
+}
+
+// adjust adds delta to the balance of address and, when nonce is set, moves its nonce past it.
+func (v *ledgerView) adjust(batch store.Batch, index int, address string, delta *big.Int, nonce *uint64) error {
+	account, err := v.get(address)
+	if err != nil {
+		return err
+	}
+	balance, ok := new(big.Int).SetString(account.Balance, 10)
+	if !ok {
+		return fmt.Errorf("invalid ledger balance %q of %s", account.Balance, address)
+	}
+	balance.Add(balance, delta)
+	if balance.Sign() < 0 && !account.Drifted {
+		logs.Log.Error(fmt.Sprintf("Ledger balance of %s is negative after transaction %d, reading its pre-state from the station from now on", address, index))
+		account.Drifted = true
+	}
+	account.Balance = balance.String()
+	if nonce != nil {
+		if *nonce != account.Nonce && !account.Drifted {
+			logs.Log.Error(fmt.Sprintf("Transaction %d has nonce %d but the ledger nonce of %s is %d, reading its pre-state from the station from now on", index, *nonce, address, account.Nonce))
+			account.Drifted = true
+		}
+		account.Nonce = *nonce + 1
+	}
+	return v.put(batch, address, account)
+}
+
+// revert adds the restoration of the accounts the transaction stored as txns-index changed to batch.
+func (v *ledgerView) revert(batch store.Batch, index int) error {
+	pre, err := GetLedgerPreState(v.ldt, index)
+	if err != nil || pre == nil {
+		return err
+	}
+	if pre.Receiver != "" {
+		if err = v.put(batch, pre.Receiver, pre.ReceiverAccount); err != nil {
+			return err
+		}
+	}
+	if err = v.put(batch, pre.Sender, pre.SenderAccount); err != nil {
+		return err
+	}
+	batch.Delete(ledgerPreStateKey(index))
+	return nil
+}
+
+// applyBlock adds the ledger changes of a stored block and of its transactions first.. to batch.
+// The accounts the block credits outside its transactions drift before the transactions are
+// applied, so no transaction of the block gets their pre-state from the ledger.
+func (v *ledgerView) applyBlock(ledger LedgerIndexer, batch store.Batch, block []byte, first int, txns [][]byte) error {
+	if len(block) > 0 {
+		accounts, err := ledger.LedgerBlockAccounts(block)
+		if err != nil {
+			return err
+		}
+		for _, address := range accounts {
+			if err = v.drift(batch, address); err != nil {
+				return err
+			}
+		}
+	}
+	for i, txn := range txns {
+		if err := v.apply(ledger, batch, first+i, txn); err != nil {
+			return fmt.Errorf("error applying transaction %d to the ledger: %w", first+i, err)
+		}
+	}
+	return nil
+}
+
+// appendLedger adds the ledger changes of a block and its transactions first.. to batch when the
+// ledger is seeded and has applied every earlier block.
+func appendLedger(indexer StationIndexer, ldt store.Store, batch store.Batch, block *StationBlock, first int, txns [][]byte) error {
+	ledger, ok := indexer.(LedgerIndexer)
+	if !ok {
+		return nil
+	}
+	height, seeded, err := getLedgerHeight(ldt)
+	if err != nil || !seeded || height != block.Height-1 {
+		return err
+	}
+
+	if err = newLedgerView(ldt).applyBlock(ledger, batch, block.Data, first, txns); err != nil {
+		return err
+	}
+	putLedgerPosition(batch, block.Height, first-1+len(txns))
+	return nil
+}
+
+// rewindLedger adds the reversal of every ledger transaction after the ancestor block to batch.
+// Accounts drifted by the unwound blocks stay drifted. A rewind below the seed snapshot unseeds
+// the ledger, since the snapshot no longer matches the chain.
+func rewindLedger(ldt store.Store, batch store.Batch, ancestor *BlockMeta) error {
+	height, seeded, err := getLedgerHeight(ldt)
+	if err != nil || !seeded || height <= ancestor.Height {
+		return err
+	}
+	seed, err := GetLedgerSeed(ldt)
+	if err != nil {
+		return err
+	}
+	if seed != nil && ancestor.Height < seed.Height {
+		logs.Log.Warn(fmt.Sprintf("Station reorganised below the ledger snapshot at block %d, the ledger needs to be seeded again", seed.Height))
+		batch.Delete([]byte(ledgerHeightKey))
+		batch.Delete([]byte(ledgerTxnIndexKey))
+		batch.Delete([]byte(ledgerSeedKey))
+		return nil
+	}
+
+	applied, _, err := getLedgerTxnIndex(ldt)
+	if err != nil {
+		return err
+	}
+	view := newLedgerView(ldt)
+	for i := applied; i > ancestor.TxnEnd; i-- {
+		if err = view.revert(batch, i); err != nil {
+			return err
+		}
+	}
+	putLedgerPosition(batch, ancestor.Height, ancestor.TxnEnd)
+	return nil
+}
+
+// catchUpLedger replays the stored blocks the ledger has not applied yet, such as those indexed
+// before the ledger was seeded.
+func (s *Syncer) catchUpLedger() error {
+	ledger, ok := s.indexer.(LedgerIndexer)
+	if !ok {
+		return nil
+	}
+	height, seeded, err := getLedgerHeight(s.ldt)
+	if err != nil || !seeded {
+		return err
+	}
+	progress, err := GetProgress(s.ldt)
+	if err != nil || progress == nil {
+		return err
+	}
+	if height < progress.Height {
+		logs.Log.Info(fmt.Sprintf("Replaying blocks %d-%d into the ledger", height+1, progress.Height))
+	}
+
+	for height < progress.Height {
+		batch := s.ldt.NewBatch()
+		view := newLedgerView(s.ldt)
+		replayed, last := 0, height
+		for last < progress.Height && replayed < ledgerCatchUpBatch {
+			last++
+			meta, err := GetBlockMeta(s.ldt, last)
+			if err != nil {
+				return err
+			}
+			if meta == nil {
+				return fmt.Errorf("block %d is not indexed", last)
+			}
+			if meta.Skipped {
+				continue
+			}
+			block, err := s.ldb.Get([]byte(s.indexer.BlockKey(last)))
+			if err != nil && err != store.ErrNotFound {
+				return err
+			}
+			txns := make([][]byte, 0, meta.TxnEnd-meta.TxnStart+1)
+			for i := meta.TxnStart; i <= meta.TxnEnd; i++ {
+				txn, err := GetTxn(s.ldt, i)
+				if err != nil {
+					return err
+				}
+				if txn == nil {
+					return fmt.Errorf("transaction %d is not indexed", i)
+				}
+				txns = append(txns, txn)
+			}
+			if err = view.applyBlock(ledger, batch, block, meta.TxnStart, txns); err != nil {
+				return err
+			}
+			putLedgerPosition(batch, last, meta.TxnEnd)
+			replayed += len(txns)
+		}
+		if err = s.ldt.Write(batch); err != nil {
+			return err
+		}
+		height = last
+	}
+	return nil
+}
+
+// SeedLedger replaces the ledger with the accounts of snapshot. The snapshot block must already
+// be indexed; the transactions indexed after it are replayed into the ledger when the indexer
+// starts.
+func SeedLedger(ldt store.Store, snapshot *LedgerSnapshot) error {
+	meta, err := GetBlockMeta(ldt, snapshot.Height)
+	if err != nil {
+		return err
+	}
+	if meta == nil {
+		return fmt.Errorf("block %d is not indexed yet", snapshot.Height)
+	}
+
+	batch := ldt.NewBatch()
+	for _, prefix := range []string{ledgerAccountPrefix, ledgerPreStatePrefix} {
+		iter := ldt.NewIterator(store.Prefix([]byte(prefix)))
+		for iter.Next() {
+			batch.Delete(append([]byte(nil), iter.Key()...))
+		}
+		iter.Release()
+		if err = iter.Error(); err != nil {
+			return err
+		}
+	}
+
+	view := newLedgerView(ldt)
+	for address, account := range snapshot.Accounts {
+		if _, ok := new(big.Int).SetString(account.Balance, 10); !ok {
+			return fmt.Errorf("invalid balance %q of %s", account.Balance, address)
+		}
+		if err = view.put(batch, address, account); err != nil {
+			return err
+		}
+	}
+
+	seed, err := json.Marshal(LedgerSeed{Height: snapshot.Height, TxnIndex: meta.TxnEnd})
+	if err != nil {
+		return err
+	}
+	batch.Put([]byte(ledgerSeedKey), seed)
+	putLedgerPosition(batch, snapshot.Height, meta.TxnEnd)
+	return ldt.Write(batch)
+}
@@ -0,0 +1,269 @@
+package blocksync
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	stationTypes "github.com/airchains-network/tracks/types"
+)
+
+// fakeLedgerIndexer is a fake station whose transactions move the value the test assigns them.
+type fakeLedgerIndexer struct {
+	*fakeIndexer
+	transfers map[string]*LedgerTransfer // by transaction
+	credited  map[string][]string        // accounts credited outside the transactions, by block hash
+}
+
+func newFakeLedgerIndexer(latest int) *fakeLedgerIndexer {
+	return &fakeLedgerIndexer{
+		fakeIndexer: newFakeIndexer("a", latest, 1),
+		transfers:   make(map[string]*LedgerTransfer),
+		credited:    make(map[string][]string),
+	}
+}
+
+func (f *fakeLedgerIndexer) LedgerTransfer(txn []byte) (*LedgerTransfer, error) {
+	return f.transfers[string(txn)], nil
+}
+
+func (f *fakeLedgerIndexer) LedgerBlockAccounts(data []byte) ([]string, error) {
+	var block fakeBlock
+	if err := json.Unmarshal(data, &block); err != nil {
+		return nil, err
+	}
+	return f.credited[block.Hash], nil
+}
+
+func transfer(from string, to string, value int64, fee int64, nonce uint64) *LedgerTransfer {
+	return &LedgerTransfer{From: from, To: to, Value: big.NewInt(value), Fee: big.NewInt(fee), Nonce: nonce, Success: true}
+}
+
+// ledgerStation returns a station of four blocks with one transfer each after block 1, whose
+// ledger snapshot is taken at block 1.
+func ledgerStation() (*fakeLedgerIndexer, *LedgerSnapshot) {
+	station := newFakeLedgerIndexer(4)
+	station.transfers["a2-0"] = transfer("alice", "bob", 10, 1, 0)
+	station.transfers["a3-0"] = transfer("bob", "carol", 5, 1, 0)
+	station.transfers["a4-0"] = transfer("alice", "bob", 1, 1, 1)
+	snapshot := &LedgerSnapshot{Height: 1, Accounts: map[string]LedgerAccount{
+		"alice": {Balance: "100"},
+	}}
+	return station, snapshot
+}
+
+func ledgerAccount(t *testing.T, s *Syncer, address string) LedgerAccount {
+	t.Helper()
+	account, err := GetLedgerAccount(s.ldt, address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return account
+}
+
+func checkAccounts(t *testing.T, s *Syncer, want map[string]LedgerAccount) {
+	t.Helper()
+	for address, account := range want {
+		if got := ledgerAccount(t, s, address); got != account {
+			t.Errorf("ledger account of %s is %+v, want %+v", address, got, account)
+		}
+	}
+}
+
+func TestLedgerApplyAndRewind(t *testing.T) {
+	station, snapshot := ledgerStation()
+	s := newTestSyncer(t, station, nil)
+	indexBlocks(t, s, 1, 1)
+	if err := SeedLedger(s.ldt, snapshot); err != nil {
+		t.Fatal(err)
+	}
+	indexBlocks(t, s, 2, 4)
+
+	checkAccounts(t, s, map[string]LedgerAccount{
+		"alice": {Balance: "87", Nonce: 2},
+		"bob":   {Balance: "5", Nonce: 1},
+		"carol": {Balance: "5"},
+	})
+	pre, err := GetLedgerPreState(s.ldt, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := LedgerPreState{Sender: "bob", SenderAccount: LedgerAccount{Balance: "10"}, Receiver: "carol", ReceiverAccount: LedgerAccount{Balance: "0"}}
+	if pre == nil || *pre != want {
+		t.Errorf("pre-state of transaction 3 is %+v, want %+v", pre, want)
+	}
+
+	// the station replaces blocks 3 and 4
+	station.extend("b", 3, 4, 1)
+	last, err := s.lastBlockMeta(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ancestor, err := s.findCommonAncestor(context.Background(), last)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = s.rewind(ancestor, 4); err != nil {
+		t.Fatal(err)
+	}
+	checkAccounts(t, s, map[string]LedgerAccount{
+		"alice": {Balance: "89", Nonce: 1},
+		"bob":   {Balance: "10"},
+		"carol": {Balance: "0"},
+	})
+	if pre, _ := GetLedgerPreState(s.ldt, 3); pre != nil {
+		t.Errorf("pre-state of unwound transaction 3 is still stored")
+	}
+	if height, _, _ := getLedgerHeight(s.ldt); height != 2 {
+		t.Errorf("ledger height is %d after the rewind, want 2", height)
+	}
+
+	// the blocks of the new fork are applied on top of the ancestor
+	station.transfers["b3-0"] = transfer("bob", "alice", 4, 1, 0)
+	indexBlocks(t, s, 3, 4)
+	checkAccounts(t, s, map[string]LedgerAccount{
+		"alice": {Balance: "93", Nonce: 1},
+		"bob":   {Balance: "5", Nonce: 1},
+	})
+}
+
+func TestLedgerCatchUpMatchesLiveApply(t *testing.T) {
+	station, snapshot := ledgerStation()
+	live := newTestSyncer(t, station, nil)
+	indexBlocks(t, live, 1, 1)
+	if err := SeedLedger(live.ldt, snapshot); err != nil {
+		t.Fatal(err)
+	}
+	indexBlocks(t, live, 2, 4)
+
+	replayed := newTestSyncer(t, station, nil)
+	indexBlocks(t, replayed, 1, 4)
+	if err := SeedLedger(replayed.ldt, snapshot); err != nil {
+		t.Fatal(err)
+	}
+	if err := replayed.catchUpLedger(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, address := range []string{"alice", "bob", "carol"} {
+		if got, want := ledgerAccount(t, replayed, address), ledgerAccount(t, live, address); got != want {
+			t.Errorf("replayed ledger account of %s is %+v, want %+v", address, got, want)
+		}
+	}
+	if height, _, _ := getLedgerHeight(replayed.ldt); height != 4 {
+		t.Errorf("ledger height is %d after the replay, want 4", height)
+	}
+	if applied, _, _ := getLedgerTxnIndex(replayed.ldt); applied != 4 {
+		t.Errorf("ledger applied %d transactions, want 4", applied)
+	}
+}
+
+func TestLedgerDrift(t *testing.T) {
+	tests := []struct {
+		name     string
+		transfer *LedgerTransfer
+		credited []string
+		drifted  string
+	}{
+		{"negative balance", transfer("bob", "alice", 1, 0, 0), nil, "bob"},
+		{"nonce mismatch", transfer("alice", "bob", 1, 0, 3), nil, "alice"},
+		{"called contract", &LedgerTransfer{From: "alice", To: "pool", Value: big.NewInt(1), Fee: big.NewInt(0), Success: true, Untracked: []string{"pool"}}, nil, "pool"},
+		{"fee recipient", transfer("alice", "bob", 1, 0, 0), []string{"miner"}, "miner"},
+	}
+	for _, tt := range tests {
+		station := newFakeLedgerIndexer(3)
+		station.transfers["a2-0"] = tt.transfer
+		station.transfers["a3-0"] = transfer(tt.drifted, "carol", 0, 0, 0)
+		station.credited["a2"] = tt.credited
+		s := newTestSyncer(t, station, nil)
+		indexBlocks(t, s, 1, 1)
+		if err := SeedLedger(s.ldt, &LedgerSnapshot{Height: 1, Accounts: map[string]LedgerAccount{"alice": {Balance: "100"}}}); err != nil {
+			t.Fatal(err)
+		}
+		indexBlocks(t, s, 2, 3)
+
+		if !ledgerAccount(t, s, tt.drifted).Drifted {
+			t.Errorf("%s: %s is not drifted", tt.name, tt.drifted)
+		}
+		if ledgerAccount(t, s, "carol").Drifted {
+			t.Errorf("%s: carol drifted", tt.name)
+		}
+		// a later transaction leaves the account drifted and records that in its pre-state
+		pre, err := GetLedgerPreState(s.ldt, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pre == nil || !pre.SenderAccount.Drifted {
+			t.Errorf("%s: pre-state of transaction 3 is %+v, want a drifted sender", tt.name, pre)
+		}
+	}
+}
+
+func TestLedgerRewindBelowSeed(t *testing.T) {
+	station, snapshot := ledgerStation()
+	snapshot.Height = 2
+	s := newTestSyncer(t, station, nil)
+	indexBlocks(t, s, 1, 2)
+	if err := SeedLedger(s.ldt, snapshot); err != nil {
+		t.Fatal(err)
+	}
+	indexBlocks(t, s, 3, 4)
+
+	ancestor, err := GetBlockMeta(s.ldt, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = s.rewind(ancestor, 4); err != nil {
+		t.Fatal(err)
+	}
+	if seed, _ := GetLedgerSeed(s.ldt); seed != nil {
+		t.Errorf("ledger is still seeded at %+v after a rewind below the snapshot", seed)
+	}
+	if _, seeded, _ := getLedgerHeight(s.ldt); seeded {
+		t.Error("ledger height is still stored after a rewind below the snapshot")
+	}
+}
+
+func TestEVMLedgerTransfer(t *testing.T) {
+	tests := []struct {
+		name      string
+		tx        stationTypes.TransactionStruct
+		untracked []string
+	}{
+		{"plain transfer", stationTypes.TransactionStruct{To: "0xb0b", GasUsed: "21000"}, nil},
+		{"contract call", stationTypes.TransactionStruct{To: "0xc0de", GasUsed: "46000"}, []string{"0xc0de"}},
+		{"contract creation", stationTypes.TransactionStruct{ContractAddress: "0xc0de", GasUsed: "90000"}, []string{"0xc0de"}},
+		{"failed call", stationTypes.TransactionStruct{To: "0xc0de", GasUsed: "46000", Status: stationTypes.TxStatusReverted}, nil},
+	}
+	for _, tt := range tests {
+		tx := tt.tx
+		tx.From, tx.Nonce, tx.Value, tx.GasPrice = "0xa11ce", "0", "5", "2"
+		if tx.Status == "" {
+			tx.Status = stationTypes.TxStatusSuccess
+		}
+		data, err := json.Marshal(tx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := (&EVMIndexer{}).LedgerTransfer(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got.Untracked) != len(tt.untracked) || (len(got.Untracked) > 0 && got.Untracked[0] != tt.untracked[0]) {
+			t.Errorf("%s: untracked accounts %v, want %v", tt.name, got.Untracked, tt.untracked)
+		}
+	}
+
+	block, err := json.Marshal(stationTypes.BlockStruct{Miner: "0xfee", WithdrawalRecipients: []string{"0xa", "0xb"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	accounts, err := (&EVMIndexer{}).LedgerBlockAccounts(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(accounts) != 3 || accounts[0] != "0xfee" || accounts[2] != "0xb" {
+		t.Errorf("block accounts are %v", accounts)
+	}
+}
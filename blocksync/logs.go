@@ -0,0 +1,136 @@
+package blocksync
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/airchains-network/tracks/store"
+	"github.com/airchains-network/tracks/types"
+)
+
+// TxnIndexer is implemented by indexers that keep secondary indices over the transactions they
+// extract. The Syncer writes the entries together with the transaction and removes them when the
+// transaction is unwound, so entries must be derived from the stored record alone.
+type TxnIndexer interface {
+	// TxnIndexEntries returns the index entries of the transaction stored as txns-index.
+	TxnIndexEntries(index int, txn []byte) (map[string][]byte, error)
+}
+
+// LogFilter selects indexed EVM logs. A log matches when it was emitted in FromBlock..ToBlock, by
+// one of Addresses if any are given, and with one of Topics[i] at every position i that lists
+// topics.
+type LogFilter struct {
+	FromBlock int
+	ToBlock   int
+	Addresses []string
+	Topics    [][]string
+}
+
+func logIndexKey(kind string, value string, blockNumber uint64, logIndex int) string {
+	return fmt.Sprintf("%s-%s-%016d-%08d", kind, strings.ToLower(value), blockNumber, logIndex)
+}
+
+// logIndexEntries returns the address and topic index entries of the logs of an EVM transaction.
+func logIndexEntries(tx *types.TransactionStruct) (map[string][]byte, error) {
+	entries := make(map[string][]byte)
+	for _, l := range tx.Logs {
+		logIndex, err := strconv.Atoi(l.LogIndex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid log index %q in transaction %s: %w", l.LogIndex, tx.Hash, err)
+		}
+		data, err := json.Marshal(l)
+		if err != nil {
+			return nil, err
+		}
+		entries[logIndexKey("logaddr", l.Address, l.BlockNumber, logIndex)] = data
+		for _, topic := range l.Topics {
+			entries[logIndexKey("logtopic", topic, l.BlockNumber, logIndex)] = data
+		}
+	}
+	return entries, nil
+}
+
+// GetLogs returns the indexed logs matching filter in block and log order. The filter needs an
+// address or a topic to select an index to scan.
+func GetLogs(ldt store.Store, filter LogFilter) ([]types.LogStruct, error) {
+	if filter.ToBlock < filter.FromBlock {
+		return nil, fmt.Errorf("invalid block range %d-%d", filter.FromBlock, filter.ToBlock)
+	}
+
+	kind, values := "logaddr", filter.Addresses
+	if len(values) == 0 {
+		kind = "logtopic"
+		for _, topics := range filter.Topics {
+			if len(topics) > 0 {
+				values = topics
+				break
+			}
+		}
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("log filter needs an address or a topic")
+	}
+
+	found := make(map[string]types.LogStruct)
+	for _, value := range values {
+		iter := ldt.NewIterator(store.Range{
+			Start: []byte(logIndexKey(kind, value, uint64(filter.FromBlock), 0)),
+			Limit: []byte(logIndexKey(kind, value, uint64(filter.ToBlock)+1, 0)),
+		})
+		for iter.Next() {
+			var l types.LogStruct
+			if err := json.Unmarshal(iter.Value(), &l); err != nil {
+				iter.Release()
+				return nil, fmt.Errorf("invalid log entry %s: %w", iter.Key(), err)
+			}
+			if filter.matches(&l) {
+				found[l.BlockHash+"-"+l.LogIndex] = l
+			}
+		}
+		iter.Release()
+		if err := iter.Error(); err != nil {
+			return nil, err
+		}
+	}
+
+	result := make([]types.LogStruct, 0, len(found))
+	for _, l := range found {
+		result = append(result, l)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].BlockNumber != result[j].BlockNumber {
+			return result[i].BlockNumber < result[j].BlockNumber
+		}
+		a, _ := strconv.Atoi(result[i].LogIndex)
+		b, _ := strconv.Atoi(result[j].LogIndex)
+		return a < b
+	})
+	return result, nil
+}
+
+func (f *LogFilter) matches(l *types.LogStruct) bool {
+	if len(f.Addresses) > 0 && !containsFold(f.Addresses, l.Address) {
+		return false
+	}
+	for i, topics := range f.Topics {
+		if len(topics) == 0 {
+			continue
+		}
+		if i >= len(l.Topics) || !containsFold(topics, l.Topics[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,141 @@
+package blocksync
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/airchains-network/tracks/types"
+)
+
+const (
+	testAddressA = "0x00000000000000000000000000000000000000aA"
+	testAddressB = "0x00000000000000000000000000000000000000bB"
+	testTopic1   = "0x0000000000000000000000000000000000000000000000000000000000000001"
+	testTopic2   = "0x0000000000000000000000000000000000000000000000000000000000000002"
+	testTopic3   = "0x0000000000000000000000000000000000000000000000000000000000000003"
+)
+
+// evmTestTxn returns the stored record of an EVM transaction of the given block with logs.
+func evmTestTxn(t *testing.T, height int, hash string, logs ...types.LogStruct) []byte {
+	t.Helper()
+	for i := range logs {
+		logs[i].BlockNumber = uint64(height)
+		logs[i].BlockHash = fmt.Sprintf("0x%02d", height)
+		logs[i].TransactionHash = hash
+	}
+	data, err := json.Marshal(types.TransactionStruct{
+		BlockNumber: uint64(height),
+		BlockHash:   fmt.Sprintf("0x%02d", height),
+		Hash:        hash,
+		Status:      types.TxStatusSuccess,
+		Logs:        logs,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// indexEVMTestLogs persists two EVM blocks with logs:
+//
+//	block 1: log 0 by A with topics 1, 2 and log 1 by B with topic 1
+//	block 2: log 0 by A with topic 3
+func indexEVMTestLogs(t *testing.T) *Syncer {
+	t.Helper()
+	s := newTestSyncer(t, &EVMIndexer{}, nil)
+	blocks := map[int][]byte{
+		1: evmTestTxn(t, 1, "0xt1",
+			types.LogStruct{Address: testAddressA, Topics: []string{testTopic1, testTopic2}, LogIndex: "0"},
+			types.LogStruct{Address: testAddressB, Topics: []string{testTopic1}, LogIndex: "1"}),
+		2: evmTestTxn(t, 2, "0xt2",
+			types.LogStruct{Address: testAddressA, Topics: []string{testTopic3}, LogIndex: "0"}),
+	}
+	for height := 1; height <= 2; height++ {
+		block := &StationBlock{Height: height, Hash: fmt.Sprintf("0x%02d", height), ParentHash: fmt.Sprintf("0x%02d", height-1)}
+		if _, err := persistBlock(s.indexer, block, [][]byte{blocks[height]}, s.ldb, s.ldt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return s
+}
+
+func TestLogIndexEntries(t *testing.T) {
+	s := indexEVMTestLogs(t)
+	for _, key := range []string{
+		logIndexKey("logaddr", testAddressA, 1, 0),
+		logIndexKey("logaddr", testAddressB, 1, 1),
+		logIndexKey("logaddr", testAddressA, 2, 0),
+		logIndexKey("logtopic", testTopic1, 1, 0),
+		logIndexKey("logtopic", testTopic2, 1, 0),
+		logIndexKey("logtopic", testTopic1, 1, 1),
+		logIndexKey("logtopic", testTopic3, 2, 0),
+	} {
+		if ok, _ := s.ldt.Has([]byte(key)); !ok {
+			t.Errorf("index entry %s is missing", key)
+		}
+	}
+	if ok, _ := s.ldt.Has([]byte(logIndexKey("logtopic", testTopic2, 1, 1))); ok {
+		t.Error("log 1 of block 1 is indexed under a topic it does not have")
+	}
+}
+
+func TestGetLogs(t *testing.T) {
+	s := indexEVMTestLogs(t)
+	tests := []struct {
+		name    string
+		filter  LogFilter
+		want    []string
+		wantErr bool
+	}{
+		{"address", LogFilter{FromBlock: 1, ToBlock: 2, Addresses: []string{testAddressA}}, []string{"1-0", "2-0"}, false},
+		{"address in any case", LogFilter{FromBlock: 1, ToBlock: 2, Addresses: []string{"0x00000000000000000000000000000000000000AA"}}, []string{"1-0", "2-0"}, false},
+		{"block range", LogFilter{FromBlock: 2, ToBlock: 2, Addresses: []string{testAddressA}}, []string{"2-0"}, false},
+		{"several addresses", LogFilter{FromBlock: 1, ToBlock: 1, Addresses: []string{testAddressA, testAddressB}}, []string{"1-0", "1-1"}, false},
+		{"first topic", LogFilter{FromBlock: 1, ToBlock: 2, Topics: [][]string{{testTopic1}}}, []string{"1-0", "1-1"}, false},
+		{"second topic", LogFilter{FromBlock: 1, ToBlock: 2, Topics: [][]string{{}, {testTopic2}}}, []string{"1-0"}, false},
+		{"topic in another position", LogFilter{FromBlock: 1, ToBlock: 2, Topics: [][]string{{testTopic2}}}, nil, false},
+		{"address and topic", LogFilter{FromBlock: 1, ToBlock: 2, Addresses: []string{testAddressB}, Topics: [][]string{{testTopic1}}}, []string{"1-1"}, false},
+		{"either topic", LogFilter{FromBlock: 1, ToBlock: 2, Topics: [][]string{{testTopic1, testTopic3}}}, []string{"1-0", "1-1", "2-0"}, false},
+		{"no address or topic", LogFilter{FromBlock: 1, ToBlock: 2}, nil, true},
+		{"reversed range", LogFilter{FromBlock: 2, ToBlock: 1, Addresses: []string{testAddressA}}, nil, true},
+	}
+	for _, tt := range tests {
+		logs, err := GetLogs(s.ldt, tt.filter)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: GetLogs returned error %v", tt.name, err)
+			continue
+		}
+		var got []string
+		for _, l := range logs {
+			got = append(got, fmt.Sprintf("%d-%s", l.BlockNumber, l.LogIndex))
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("%s: GetLogs = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRewindRemovesLogIndex(t *testing.T) {
+	s := indexEVMTestLogs(t)
+	ancestor, err := GetBlockMeta(s.ldt, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = s.rewind(ancestor, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	logs, err := GetLogs(s.ldt, LogFilter{FromBlock: 1, ToBlock: 2, Addresses: []string{testAddressA}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logs) != 1 || logs[0].BlockNumber != 1 {
+		t.Errorf("logs of A after the rewind are %+v, want only the one of block 1", logs)
+	}
+	for _, key := range []string{logIndexKey("logaddr", testAddressA, 2, 0), logIndexKey("logtopic", testTopic3, 2, 0)} {
+		if ok, _ := s.ldt.Has([]byte(key)); ok {
+			t.Errorf("index entry %s is still stored after the rewind", key)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package blocksync
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/airchains-network/tracks/store"
+)
+
+// TxnDescriber is implemented by indexers that can tell the hash and the accounts involved in a
+// stored transaction record. The Syncer uses it to maintain the hash and address indices.
+type TxnDescriber interface {
+	// DescribeTxn returns the hash of the transaction and the addresses it involves.
+	DescribeTxn(txn []byte) (hash string, addresses []string, err error)
+}
+
+// normalizeLookupKey lowercases hex encoded hashes and addresses, which are case-insensitive,
+// and leaves other encodings such as base58 untouched.
+func normalizeLookupKey(value string) string {
+	hexPart := strings.TrimPrefix(strings.TrimPrefix(value, "0x"), "0X")
+	if hexPart == "" {
+		return value
+	}
+	for _, c := range hexPart {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", c) {
+			return value
+		}
+	}
+	return strings.ToLower(value)
+}
+
+func txnHashKey(hash string) string {
+	return "txhash-" + normalizeLookupKey(hash)
+}
+
+func addressKeyPrefix(address string) string {
+	return "addr-" + normalizeLookupKey(address) + "-"
+}
+
+func addressKey(address string, index int) string {
+	return fmt.Sprintf("%s%016d", addressKeyPrefix(address), index)
+}
+
+// txnIndexEntries returns every secondary index entry of the transaction stored as txns-index.
+func txnIndexEntries(indexer StationIndexer, index int, txn []byte) (map[string][]byte, error) {
+	entries := make(map[string][]byte)
+	if describer, ok := indexer.(TxnDescriber); ok {
+		hash, addresses, err := describer.DescribeTxn(txn)
+		if err != nil {
+			return nil, err
+		}
+		value := []byte(strconv.Itoa(index))
+		if hash != "" {
+			entries[txnHashKey(hash)] = value
+		}
+		for _, address := range addresses {
+			if address != "" {
+				entries[addressKey(address, index)] = value
+			}
+		}
+	}
+	if txnIndexer, ok := indexer.(TxnIndexer); ok {
+		extra, err := txnIndexer.TxnIndexEntries(index, txn)
+		if err != nil {
+			return nil, err
+		}
+		for key, value := range extra {
+			entries[key] = value
+		}
+	}
+	return entries, nil
+}
+
+// GetTxn returns the transaction record stored as txns-index, or nil if there is none.
+func GetTxn(ldt store.Store, index int) ([]byte, error) {
+	txn, err := ldt.Get([]byte(fmt.Sprintf("txns-%d", index)))
+	if err == store.ErrNotFound {
+		return nil, nil
+	}
+	return txn, err
+}
+
+// GetTxnIndexByHash returns the txns-N index of the transaction with the given hash, or 0 if it
+// is not indexed.
+func GetTxnIndexByHash(ldt store.Store, hash string) (int, error) {
+	value, err := ldt.Get([]byte(txnHashKey(hash)))
+	if err == store.ErrNotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(string(value))
+}
+
+// GetTxnIndicesByAddress returns, in ascending order, the txns-N indices of the transactions
+// involving address, starting after the index after and returning at most limit indices. A
+// non-positive limit returns all of them.
+func GetTxnIndicesByAddress(ldt store.Store, address string, after int, limit int) ([]int, error) {
+	iter := ldt.NewIterator(store.Range{
+		Start: []byte(addressKey(address, after+1)),
+		Limit: store.Prefix([]byte(addressKeyPrefix(address))).Limit,
+	})
+	defer iter.Release()
+
+	var indices []int
+	for iter.Next() && (limit <= 0 || len(indices) < limit) {
+		index, err := strconv.Atoi(string(iter.Value()))
+		if err != nil {
+			return nil, fmt.Errorf("invalid address index entry %s: %w", iter.Key(), err)
+		}
+		indices = append(indices, index)
+	}
+	return indices, iter.Error()
+}
+
+// GetBlockTxnRange returns the first and last txns-N index produced by the block at height. last
+// is first-1 for a block without transactions and ok is false if the block is not indexed.
+func GetBlockTxnRange(ldt store.Store, height int) (first int, last int, ok bool, err error) {
+	meta, err := GetBlockMeta(ldt, height)
+	if err != nil || meta == nil {
+		return 0, 0, false, err
+	}
+	return meta.TxnStart, meta.TxnEnd, true, nil
+}
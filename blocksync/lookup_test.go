@@ -0,0 +1,121 @@
+package blocksync
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// lookupIndexer is a fakeIndexer whose transactions have hex hashes and involve addresses:
+// every transaction involves 0xaa, the first of each block 0xbb and the second one 0xaab.
+type lookupIndexer struct {
+	*fakeIndexer
+}
+
+func (l *lookupIndexer) DescribeTxn(txn []byte) (string, []string, error) {
+	addresses := []string{"0xaa"}
+	switch {
+	case strings.HasSuffix(string(txn), "-0"):
+		addresses = append(addresses, "0xbb")
+	case strings.HasSuffix(string(txn), "-1"):
+		addresses = append(addresses, "0xaab")
+	}
+	return "0x" + hex.EncodeToString(txn), addresses, nil
+}
+
+func hexHash(txn string) string {
+	return "0x" + hex.EncodeToString([]byte(txn))
+}
+
+func TestNormalizeLookupKey(t *testing.T) {
+	tests := []struct{ value, want string }{
+		{"0xABcd", "0xabcd"},
+		{"0XABCD", "0xabcd"},
+		{"ABCD", "abcd"},
+		{"5Kd3NBUAdUnhyzenEwVLy9pBKxSwXvE9FMPyR4UKZvpe6E3AgLr", "5Kd3NBUAdUnhyzenEwVLy9pBKxSwXvE9FMPyR4UKZvpe6E3AgLr"},
+		{"air1Qx7", "air1Qx7"},
+		{"0x", "0x"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := normalizeLookupKey(tt.value); got != tt.want {
+			t.Errorf("normalizeLookupKey(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestTxnLookups(t *testing.T) {
+	station := &lookupIndexer{newFakeIndexer("a", 3, 2)}
+	station.extend("a", 4, 4, 0)
+	s := newTestSyncer(t, station, nil)
+	indexBlocks(t, s, 1, 4)
+
+	hashes := []struct {
+		hash string
+		want int
+	}{
+		{hexHash("a1-0"), 1},
+		{hexHash("a3-1"), 6},
+		{strings.ToUpper(hexHash("a2-0")[2:]), 0},
+		{"0x" + strings.ToUpper(hexHash("a2-0")[2:]), 3},
+		{hexHash("a4-0"), 0},
+	}
+	for _, tt := range hashes {
+		got, err := GetTxnIndexByHash(s.ldt, tt.hash)
+		if err != nil || got != tt.want {
+			t.Errorf("GetTxnIndexByHash(%s) = %d, %v, want %d", tt.hash, got, err, tt.want)
+		}
+	}
+
+	addresses := []struct {
+		address      string
+		after, limit int
+		want         []int
+	}{
+		{"0xaa", 0, 0, []int{1, 2, 3, 4, 5, 6}},
+		{"0xAA", 2, 3, []int{3, 4, 5}},
+		{"0xaa", 6, 0, nil},
+		{"0xBB", 0, 0, []int{1, 3, 5}},
+		{"0xaab", 0, 2, []int{2, 4}},
+		{"0xcc", 0, 0, nil},
+	}
+	for _, tt := range addresses {
+		got, err := GetTxnIndicesByAddress(s.ldt, tt.address, tt.after, tt.limit)
+		if err != nil || fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("GetTxnIndicesByAddress(%s, %d, %d) = %v, %v, want %v", tt.address, tt.after, tt.limit, got, err, tt.want)
+		}
+	}
+
+	blocks := []struct {
+		height      int
+		first, last int
+		ok          bool
+	}{
+		{1, 1, 2, true},
+		{3, 5, 6, true},
+		{4, 7, 6, true},
+		{9, 0, 0, false},
+	}
+	for _, tt := range blocks {
+		first, last, ok, err := GetBlockTxnRange(s.ldt, tt.height)
+		if err != nil || first != tt.first || last != tt.last || ok != tt.ok {
+			t.Errorf("GetBlockTxnRange(%d) = %d, %d, %v, %v, want %d, %d, %v", tt.height, first, last, ok, err, tt.first, tt.last, tt.ok)
+		}
+	}
+
+	// unwound transactions leave the indices
+	ancestor, err := GetBlockMeta(s.ldt, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = s.rewind(ancestor, 4); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := GetTxnIndicesByAddress(s.ldt, "0xaa", 0, 0); fmt.Sprint(got) != "[1 2 3 4]" {
+		t.Errorf("transactions of 0xaa after the rewind are %v, want [1 2 3 4]", got)
+	}
+	if got, _ := GetTxnIndexByHash(s.ldt, hexHash("a3-0")); got != 0 {
+		t.Errorf("unwound transaction a3-0 is still indexed as txns-%d", got)
+	}
+}
@@ -0,0 +1,153 @@
+package blocksync
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/airchains-network/tracks/store"
+	"github.com/airchains-network/tracks/types"
+)
+
+// migrationChunk is how many records a migration rewrites per journal write.
+const migrationChunk = 1000
+
+func init() {
+	RegisterMigration(Migration{
+		Version:     1,
+		Description: "index stored transactions by hash, address and EVM log",
+		Migrate:     migrateTxnIndices,
+	})
+	RegisterMigration(Migration{
+		Version:     2,
+		Description: "re-encode JSON pod records in the protobuf pod encoding",
+		Migrate:     migratePodEncoding,
+	})
+}
+
+// migrationIndex returns the record a chunk starts at, which is first for the first chunk.
+func migrationIndex(cursor []byte, first int) (int, error) {
+	if cursor == nil {
+		return first, nil
+	}
+	index, err := strconv.Atoi(string(cursor))
+	if err != nil {
+		return 0, fmt.Errorf("invalid migration cursor %q: %w", cursor, err)
+	}
+	return index, nil
+}
+
+// DescribingIndexer returns a StationIndexer of the station type for describing and keying stored
+// records offline. It is not connected to the station, so only FirstHeight, BlockKey, DescribeTxn
+// and TxnIndexEntries may be used.
+func DescribingIndexer(stationType string) (StationIndexer, error) {
+	switch strings.ToLower(stationType) {
+	case "evm":
+		return &EVMIndexer{}, nil
+	case "wasm":
+		return &WasmIndexer{}, nil
+	case "svm":
+		return &SVMIndexer{}, nil
+	}
+	return nil, fmt.Errorf("no indexer registered for station type %q", stationType)
+}
+
+// migrateTxnIndices adds the secondary index entries of every transaction indexed before the
+// indices were maintained by the Syncer. The cursor is the next transaction.
+func migrateTxnIndices(m *MigrationContext, batch *store.MultiBatch, cursor []byte) ([]byte, error) {
+	if m.Station == nil {
+		return nil, fmt.Errorf("station config is missing")
+	}
+	indexer, err := DescribingIndexer(m.Station.StationType)
+	if err != nil {
+		return nil, err
+	}
+	ldt := m.Store("tx")
+	txnCount, err := getCounter(ldt, "txnCount")
+	if err != nil {
+		return nil, err
+	}
+	first, err := migrationIndex(cursor, 1)
+	if err != nil {
+		return nil, err
+	}
+	last := min(first+migrationChunk-1, txnCount)
+	txBatch := batch.Store("tx")
+	for i := first; i <= last; i++ {
+		txn, err := GetTxn(ldt, i)
+		if err != nil {
+			return nil, err
+		}
+		if txn == nil {
+			continue
+		}
+		if err = putTxnIndexEntries(indexer, txBatch, i, txn); err != nil {
+			return nil, fmt.Errorf("transaction %d: %w", i, err)
+		}
+	}
+	if last >= txnCount {
+		return nil, nil
+	}
+	return []byte(strconv.Itoa(last + 1)), nil
+}
+
+// migratePodEncoding rewrites the pod state and the pod records still stored as JSON, which
+// types.UnmarshalPodState keeps reading, in the protobuf encoding. The cursor is the next pod.
+func migratePodEncoding(m *MigrationContext, batch *store.MultiBatch, cursor []byte) ([]byte, error) {
+	first, err := migrationIndex(cursor, 0)
+	if err != nil {
+		return nil, err
+	}
+	data, err := m.Store("state").Get([]byte("podState"))
+	if err == store.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	podState, err := types.UnmarshalPodState(data)
+	if err != nil {
+		return nil, fmt.Errorf("podState: %w", err)
+	}
+	if first == 0 {
+		if err = reencodePod(batch.Store("state"), []byte("podState"), data); err != nil {
+			return nil, err
+		}
+		first = 1
+	}
+
+	pods := m.Store("batches")
+	last := min(first+migrationChunk-1, int(podState.LatestPodHeight))
+	for number := first; number <= last; number++ {
+		key := []byte(fmt.Sprintf("pod-%d", number))
+		data, err := pods.Get(key)
+		if err == store.ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err = reencodePod(batch.Store("batches"), key, data); err != nil {
+			return nil, err
+		}
+	}
+	if last >= int(podState.LatestPodHeight) {
+		return nil, nil
+	}
+	return []byte(strconv.Itoa(last + 1)), nil
+}
+
+// reencodePod adds the protobuf encoding of the pod record data stored under key to batch when
+// data is JSON.
+func reencodePod(batch store.Batch, key []byte, data []byte) error {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) == 0 || trimmed[0] != '{' {
+		return nil
+	}
+	pod, err := types.UnmarshalPodState(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+	batch.Put(key, types.MarshalPodState(pod))
+	return nil
+}
@@ -0,0 +1,82 @@
+package blocksync
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/airchains-network/tracks/store"
+	"github.com/rs/zerolog/log"
+)
+
+// progressKey holds the indexer Progress in the txn database.
+const progressKey = "indexerProgress"
+
+// Progress is where the indexer resumes: the last persisted station height, the hash of its
+// block and the index of the last transaction in the txns-N sequence. It is written in the same
+// batch as the transactions and block meta it describes.
+type Progress struct {
+	Height   int    `json:"height"`
+	Hash     string `json:"hash"`
+	TxnIndex int    `json:"txnIndex"`
+}
+
+// GetProgress returns the indexer progress, or nil if the station has not been indexed since the
+// progress record was introduced.
+func GetProgress(ldt store.Store) (*Progress, error) {
+	data, err := ldt.Get([]byte(progressKey))
+	if err == store.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var progress Progress
+	if err = json.Unmarshal(data, &progress); err != nil {
+		return nil, fmt.Errorf("invalid indexer progress: %w", err)
+	}
+	return &progress, nil
+}
+
+func putProgress(batch store.Batch, progress *Progress) error {
+	data, err := json.Marshal(progress)
+	if err != nil {
+		return err
+	}
+	batch.Put([]byte(progressKey), data)
+	return nil
+}
+
+// resumeHeight returns the height the indexer continues at: right after the recorded progress,
+// or fallback for stations indexed before progress was recorded.
+func (s *Syncer) resumeHeight(fallback int) (int, error) {
+	progress, err := GetProgress(s.ldt)
+	if err != nil {
+		return 0, err
+	}
+	if progress == nil {
+		return fallback, nil
+	}
+	txnCount, err := getCounter(s.ldt, "txnCount")
+	if err != nil {
+		return 0, err
+	}
+	if txnCount != progress.TxnIndex {
+		return 0, fmt.Errorf("indexer progress is at txn %d but txnCount is %d", progress.TxnIndex, txnCount)
+	}
+	if err = s.alignBlockCount(progress.Height + 1); err != nil {
+		return 0, err
+	}
+	return progress.Height + 1, nil
+}
+
+// alignBlockCount sets blockCount to height when a block batch was written but the txn batch
+// that follows it, or the block batch of a rewind, was lost.
+func (s *Syncer) alignBlockCount(height int) error {
+	blockCount, err := getCounter(s.ldb, "blockCount")
+	if err != nil || blockCount == height {
+		return err
+	}
+	log.Warn().Str("module", "blocksync").Msg(fmt.Sprintf("blockCount is %d but indexer progress is at block %d, resetting it", blockCount, height-1))
+	return s.ldb.Put([]byte("blockCount"), []byte(strconv.Itoa(height)))
+}
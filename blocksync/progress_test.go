@@ -0,0 +1,101 @@
+package blocksync
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// runUntil runs s until it indexed every block up to height, then stops it.
+func runUntil(t *testing.T, s *Syncer, startHeight int, height int) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- s.Run(ctx, startHeight) }()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for s.Height() <= height && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("Run stopped with %v", err)
+	}
+	if s.Height() != height+1 {
+		t.Fatalf("Syncer is at block %d, want %d", s.Height(), height+1)
+	}
+}
+
+func TestSyncerResumesFromProgress(t *testing.T) {
+	station := newFakeIndexer("a", 25, 3)
+	station.finalized = 20
+	s := newTestSyncer(t, station, nil)
+	s.SetCatchUp(3, 4)
+	runUntil(t, s, 0, 25)
+
+	progress, err := GetProgress(s.ldt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *progress != (Progress{Height: 25, Hash: "a25", TxnIndex: 75}) {
+		t.Fatalf("progress is %+v", progress)
+	}
+	if got := counter(t, s.ldt, finalizedTxnCountKey); got != 60 {
+		t.Errorf("finalizedTxnCount = %d, want the 60 txns of blocks 1-20", got)
+	}
+	for index, want := range map[int]string{1: "a1-0", 38: "a13-1", 75: "a25-2"} {
+		if txn, _ := GetTxn(s.ldt, index); string(txn) != want {
+			t.Errorf("txns-%d = %q, want %q", index, txn, want)
+		}
+	}
+
+	// a restarted Syncer continues after the progress, whatever height it is started at
+	station.extend("a", 26, 30, 1)
+	resumed := NewSyncer(station, s.ldb, s.ldt, nil)
+	runUntil(t, resumed, 1, 30)
+	if got := counter(t, s.ldt, "txnCount"); got != 80 {
+		t.Errorf("txnCount = %d after resuming, want 80", got)
+	}
+}
+
+func TestResumeHeight(t *testing.T) {
+	tests := []struct {
+		name       string
+		progress   *Progress
+		txnCount   string
+		blockCount string
+		want       int
+		wantErr    bool
+	}{
+		{name: "no progress", txnCount: "0", blockCount: "0", want: 7},
+		{name: "progress", progress: &Progress{Height: 9, TxnIndex: 4}, txnCount: "4", blockCount: "10", want: 10},
+		{name: "counter mismatch", progress: &Progress{Height: 9, TxnIndex: 4}, txnCount: "5", blockCount: "10", wantErr: true},
+		{name: "lost txn batch", progress: &Progress{Height: 9, TxnIndex: 4}, txnCount: "4", blockCount: "11", want: 10},
+	}
+	for _, tt := range tests {
+		s := newTestSyncer(t, newFakeIndexer("a", 1, 0), nil)
+		s.ldt.Put([]byte("txnCount"), []byte(tt.txnCount))
+		s.ldb.Put([]byte("blockCount"), []byte(tt.blockCount))
+		if tt.progress != nil {
+			batch := s.ldt.NewBatch()
+			if err := putProgress(batch, tt.progress); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.ldt.Write(batch); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		got, err := s.resumeHeight(7)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("%s: resumeHeight = %d, %v, want %d", tt.name, got, err, tt.want)
+			continue
+		}
+		if !tt.wantErr && tt.progress != nil {
+			if blockCount := counter(t, s.ldb, "blockCount"); blockCount != tt.want {
+				t.Errorf("%s: blockCount = %d, want %d", tt.name, blockCount, tt.want)
+			}
+		}
+	}
+}
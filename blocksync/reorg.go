@@ -0,0 +1,173 @@
+package blocksync
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	logs "github.com/airchains-network/tracks/log"
+	"github.com/airchains-network/tracks/store"
+)
+
+// ErrReorgBeyondCommitted is returned by the Syncer when the station reorganised past
+// transactions that are already part of a generated pod.
+var ErrReorgBeyondCommitted = errors.New("station reorganisation reaches transactions already committed into a pod")
+
+// BlockMeta records where an indexed block sits in the station chain and which part of the
+// txns-N sequence it produced. TxnEnd is TxnStart-1 for a block without transactions.
+type BlockMeta struct {
+	Height     int    `json:"height"`
+	Hash       string `json:"hash"`
+	ParentHash string `json:"parentHash"`
+	Skipped    bool   `json:"skipped,omitempty"`
+	TxnStart   int    `json:"txnStart"`
+	TxnEnd     int    `json:"txnEnd"`
+	// Time is the station block time in unix seconds, zero if the station does not report it.
+	Time int64 `json:"time,omitempty"`
+}
+
+func blockMetaKey(height int) []byte {
+	return []byte(fmt.Sprintf("blockMeta-%d", height))
+}
+
+// GetBlockMeta returns the stored metadata of an indexed block, or nil if the block was indexed
+// before metadata was recorded.
+func GetBlockMeta(ldt store.Store, height int) (*BlockMeta, error) {
+	data, err := ldt.Get(blockMetaKey(height))
+	if err == store.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var meta BlockMeta
+	if err = json.Unmarshal(data, &meta); err != nil {
+		return nil, fmt.Errorf("invalid block meta for block %d: %w", height, err)
+	}
+	return &meta, nil
+}
+
+// lastBlockMeta returns the metadata of the highest indexed block at or below height that is not a
+// skipped height, or nil if there is none.
+func (s *Syncer) lastBlockMeta(height int) (*BlockMeta, error) {
+	for ; height >= s.indexer.FirstHeight(); height-- {
+		meta, err := GetBlockMeta(s.ldt, height)
+		if err != nil || meta == nil || !meta.Skipped {
+			return meta, err
+		}
+	}
+	return nil, nil
+}
+
+func putBlockMeta(batch store.Batch, meta *BlockMeta) error {
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	batch.Put(blockMetaKey(meta.Height), data)
+	return nil
+}
+
+// extendsChain reports whether block builds on the last indexed block. Blocks are assumed to
+// extend the chain when either hash is unknown.
+func extendsChain(last *BlockMeta, block *StationBlock) bool {
+	if last == nil || last.Hash == "" || block.ParentHash == "" {
+		return true
+	}
+	return last.Hash == block.ParentHash
+}
+
+// findCommonAncestor walks back from the last indexed block until the stored block hash matches
+// the station's canonical block at the same height, and returns the metadata of that block.
+func (s *Syncer) findCommonAncestor(ctx context.Context, last *BlockMeta) (*BlockMeta, error) {
+	for height := last.Height; height >= s.indexer.FirstHeight(); height-- {
+		meta, err := GetBlockMeta(s.ldt, height)
+		if err != nil {
+			return nil, err
+		}
+		if meta == nil {
+			logs.Log.Warn(fmt.Sprintf("No block meta for block %d, treating it as the common ancestor", height))
+			return &BlockMeta{Height: height, TxnEnd: last.TxnStart - 1, TxnStart: last.TxnStart}, nil
+		}
+		if meta.Skipped {
+			continue
+		}
+		canonical, err := s.indexer.FetchBlock(ctx, height)
+		if err != nil {
+			return nil, err
+		}
+		if canonical.Hash == meta.Hash {
+			return meta, nil
+		}
+		last = meta
+	}
+	return nil, fmt.Errorf("no common ancestor found with the station chain")
+}
+
+// rewind removes every block above ancestor together with the transactions they produced and
+// resets the counters and the indexer progress to the ancestor. The txn database is updated in a
+// single batch before a second batch removes the unwound blocks from the block database and
+// resets blockCount.
+func (s *Syncer) rewind(ancestor *BlockMeta, top int) error {
+	txnCount, err := getCounter(s.ldt, "txnCount")
+	if err != nil {
+		return err
+	}
+	if s.committedTxns != nil {
+		committed, err := s.committedTxns()
+		if err != nil {
+			return err
+		}
+		if ancestor.TxnEnd < committed {
+			return fmt.Errorf("%w: rewind to block %d needs txn %d, %d txns are committed", ErrReorgBeyondCommitted, ancestor.Height, ancestor.TxnEnd, committed)
+		}
+	}
+
+	batch := s.ldt.NewBatch()
+	for i := ancestor.TxnEnd + 1; i <= txnCount; i++ {
+		if err = deleteTxnIndexEntries(s.indexer, s.ldt, batch, i); err != nil {
+			return err
+		}
+		batch.Delete([]byte(fmt.Sprintf("txns-%d", i)))
+		batch.Delete(txnTimeKey(i))
+	}
+	if err = rewindLedger(s.ldt, batch, ancestor); err != nil {
+		return err
+	}
+	batch.Put([]byte("txnCount"), []byte(strconv.Itoa(ancestor.TxnEnd)))
+
+	finalized, err := GetFinalizedTxnCount(s.ldt)
+	if err != nil {
+		return err
+	}
+	if finalized > ancestor.TxnEnd {
+		logs.Log.Warn(fmt.Sprintf("Station reorganised finalized block transactions %d-%d", ancestor.TxnEnd+1, finalized))
+		batch.Put([]byte(finalizedTxnCountKey), []byte(strconv.Itoa(ancestor.TxnEnd)))
+	}
+	finalizedTime, err := getCounter(s.ldt, finalizedTimeKey)
+	if err != nil {
+		return err
+	}
+	if int64(finalizedTime) > ancestor.Time {
+		batch.Put([]byte(finalizedTimeKey), []byte(strconv.FormatInt(ancestor.Time, 10)))
+	}
+
+	for height := top; height > ancestor.Height; height-- {
+		batch.Delete(blockMetaKey(height))
+	}
+	if err = putProgress(batch, &Progress{Height: ancestor.Height, Hash: ancestor.Hash, TxnIndex: ancestor.TxnEnd}); err != nil {
+		return err
+	}
+	if err = s.ldt.Write(batch); err != nil {
+		return err
+	}
+
+	blockBatch := s.ldb.NewBatch()
+	for height := top; height > ancestor.Height; height-- {
+		blockBatch.Delete([]byte(s.indexer.BlockKey(height)))
+	}
+	blockBatch.Put([]byte("blockCount"), []byte(strconv.Itoa(ancestor.Height+1)))
+	return s.ldb.Write(blockBatch)
+}
@@ -0,0 +1,127 @@
+package blocksync
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestExtendsChain(t *testing.T) {
+	tests := []struct {
+		name  string
+		last  *BlockMeta
+		block *StationBlock
+		want  bool
+	}{
+		{"first block", nil, &StationBlock{ParentHash: "a1"}, true},
+		{"unknown last hash", &BlockMeta{}, &StationBlock{ParentHash: "a1"}, true},
+		{"unknown parent hash", &BlockMeta{Hash: "a1"}, &StationBlock{}, true},
+		{"child", &BlockMeta{Hash: "a1"}, &StationBlock{ParentHash: "a1"}, true},
+		{"fork", &BlockMeta{Hash: "a1"}, &StationBlock{ParentHash: "b1"}, false},
+	}
+	for _, tt := range tests {
+		if got := extendsChain(tt.last, tt.block); got != tt.want {
+			t.Errorf("%s: extendsChain = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRewindToCommonAncestor(t *testing.T) {
+	station := newFakeIndexer("a", 5, 2)
+	s := newTestSyncer(t, station, func() (int, error) { return 4, nil })
+	indexBlocks(t, s, 1, 5)
+	if err := putFinalizedTxnCount(s.ldt, 10); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.ldt.Put([]byte(finalizedTimeKey), []byte("50")); err != nil {
+		t.Fatal(err)
+	}
+
+	// the station replaces blocks 4 and 5
+	station.extend("b", 4, 6, 1)
+	block6, err := station.FetchBlock(context.Background(), 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	last, err := GetBlockMeta(s.ldt, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if extendsChain(last, block6) {
+		t.Fatal("block 6 of the new fork extends the indexed chain")
+	}
+
+	ancestor, err := s.findCommonAncestor(context.Background(), last)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ancestor.Height != 3 || ancestor.Hash != "a3" || ancestor.TxnEnd != 6 {
+		t.Fatalf("common ancestor is %+v, want block a3 ending at txn 6", ancestor)
+	}
+	if err = s.rewind(ancestor, 5); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := counter(t, s.ldt, "txnCount"); got != 6 {
+		t.Errorf("txnCount = %d, want 6", got)
+	}
+	if got := counter(t, s.ldt, finalizedTxnCountKey); got != 6 {
+		t.Errorf("finalizedTxnCount = %d, want 6", got)
+	}
+	if got := counter(t, s.ldt, finalizedTimeKey); got != 30 {
+		t.Errorf("finalizedTime = %d, want the time of block 3", got)
+	}
+	if got := counter(t, s.ldb, "blockCount"); got != 4 {
+		t.Errorf("blockCount = %d, want 4", got)
+	}
+	for _, key := range []string{"txns-7", "txns-10", "txnTime-7", "block-4", "blockMeta-5", txnHashKey("a4-0")} {
+		db := s.ldt
+		if key == "block-4" {
+			db = s.ldb
+		}
+		if ok, _ := db.Has([]byte(key)); ok {
+			t.Errorf("%s is still stored after the rewind", key)
+		}
+	}
+	if ok, _ := s.ldt.Has([]byte(txnHashKey("a3-1"))); !ok {
+		t.Error("the hash index of a transaction below the ancestor was removed")
+	}
+	progress, err := GetProgress(s.ldt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *progress != (Progress{Height: 3, Hash: "a3", TxnIndex: 6}) {
+		t.Errorf("progress is %+v after the rewind", progress)
+	}
+
+	// the new fork is indexed on top of the ancestor
+	indexBlocks(t, s, 4, 6)
+	if got := counter(t, s.ldt, "txnCount"); got != 9 {
+		t.Errorf("txnCount = %d after indexing the new fork, want 9", got)
+	}
+	if txn, _ := GetTxn(s.ldt, 7); string(txn) != "b4-0" {
+		t.Errorf("txns-7 = %q, want b4-0", txn)
+	}
+}
+
+func TestRewindBeyondCommitted(t *testing.T) {
+	station := newFakeIndexer("a", 5, 2)
+	s := newTestSyncer(t, station, func() (int, error) { return 8, nil })
+	indexBlocks(t, s, 1, 5)
+
+	station.extend("b", 4, 5, 1)
+	last, err := GetBlockMeta(s.ldt, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ancestor, err := s.findCommonAncestor(context.Background(), last)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = s.rewind(ancestor, 5); !errors.Is(err, ErrReorgBeyondCommitted) {
+		t.Fatalf("rewind below committed txns returned %v, want ErrReorgBeyondCommitted", err)
+	}
+	if got := counter(t, s.ldt, "txnCount"); got != 10 {
+		t.Errorf("txnCount = %d after a refused rewind, want 10", got)
+	}
+}
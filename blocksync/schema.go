@@ -0,0 +1,187 @@
+package blocksync
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/airchains-network/tracks/config"
+	"github.com/airchains-network/tracks/store"
+)
+
+// Keys of the schema version, of a migration write that was interrupted and of the chunk the
+// pending migration resumes at, all in the static database.
+const (
+	schemaVersionKey    = "schemaVersion"
+	migrationJournalKey = "migrationJournal"
+	migrationCursorKey  = "migrationCursor"
+)
+
+// ErrSchemaOutdated is returned by CheckSchemaVersion when the data directory needs migrating.
+var ErrSchemaOutdated = errors.New("data directory needs migrating")
+
+// Migration upgrades a data directory from schema version Version-1 to Version. Migrate adds the
+// changes of one chunk, starting at cursor (nil for the first chunk), to batch and returns the
+// cursor of the next chunk, or nil once the migration is complete. Every chunk is written together
+// with the cursor in one journal write, and the last one together with the new schema version, so
+// an interrupted migration resumes after the last chunk written.
+type Migration struct {
+	Version     int
+	Description string
+	Migrate     func(m *MigrationContext, batch *store.MultiBatch, cursor []byte) ([]byte, error)
+}
+
+// MigrationContext gives a migration the open databases and the station it belongs to.
+type MigrationContext struct {
+	Station *config.StationConfig
+	stores  map[string]store.Store
+}
+
+// Store returns the database opened under name, e.g. "tx", "blocks" or "static".
+func (m *MigrationContext) Store(name string) store.Store {
+	return m.stores[name]
+}
+
+// migrations holds every registered migration in version order.
+var migrations []Migration
+
+// RegisterMigration adds the migration to the registry. Versions must be registered in order
+// starting at 1; it panics otherwise.
+func RegisterMigration(m Migration) {
+	if m.Version != len(migrations)+1 {
+		panic(fmt.Sprintf("blocksync: migration %d registered after version %d", m.Version, len(migrations)))
+	}
+	migrations = append(migrations, m)
+}
+
+// SchemaVersion returns the schema version this release reads and writes.
+func SchemaVersion() int {
+	return len(migrations)
+}
+
+// GetSchemaVersion returns the schema version recorded in the static database. Data directories
+// written before versioning report 0.
+func GetSchemaVersion(static store.Store) (int, error) {
+	value, err := static.Get([]byte(schemaVersionKey))
+	if err == store.ErrNotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(value)))
+}
+
+// CheckSchemaVersion returns an error wrapping ErrSchemaOutdated when the open data directory is
+// behind SchemaVersion, and an error when it was written by a newer release.
+func CheckSchemaVersion() error {
+	version, err := GetSchemaVersion(staticDbInstance)
+	if err != nil {
+		return err
+	}
+	switch {
+	case version < SchemaVersion():
+		return fmt.Errorf("%w: schema version is %d, this release needs %d, run `tracks migrate`", ErrSchemaOutdated, version, SchemaVersion())
+	case version > SchemaVersion():
+		return fmt.Errorf("data directory has schema version %d, newer than %d supported by this release", version, SchemaVersion())
+	}
+	return nil
+}
+
+// PendingMigrations returns the migrations the open data directory still needs, in order.
+func PendingMigrations() ([]Migration, error) {
+	version, err := GetSchemaVersion(staticDbInstance)
+	if err != nil {
+		return nil, err
+	}
+	if version >= len(migrations) {
+		return nil, nil
+	}
+	return migrations[version:], nil
+}
+
+// migrationStores returns every database opened by InitDb by the name it was opened under.
+func migrationStores() map[string]store.Store {
+	return map[string]store.Store{
+		"tx":            txDbInstance,
+		"blocks":        blockDbInstance,
+		"static":        staticDbInstance,
+		"state":         stateDbInstance,
+		"batches":       batchesDbInstance,
+		"proof":         proofDbInstance,
+		"publicWitness": publicWitnessDbInstance,
+		"da":            daDbInstance,
+		"mock":          mockDbInstance,
+	}
+}
+
+// Migrate runs the pending migrations of the data directory opened by InitDb in order, resuming
+// an interrupted one at its last written chunk. report is called with each migration and the
+// number of writes it made once it is complete. With dryRun nothing is written; every migration
+// is then prepared against the current data, so counts after the first pending migration are
+// only an estimate.
+func Migrate(station *config.StationConfig, dryRun bool, report func(m Migration, writes int)) error {
+	pending, err := PendingMigrations()
+	if err != nil {
+		return err
+	}
+	return runMigrations(&MigrationContext{Station: station, stores: migrationStores()}, pending, dryRun, report)
+}
+
+func runMigrations(ctx *MigrationContext, pending []Migration, dryRun bool, report func(m Migration, writes int)) error {
+	static := ctx.Store("static")
+	journal := store.NewJournal(static, migrationJournalKey, ctx.stores)
+	if !dryRun {
+		if err := journal.Replay(); err != nil {
+			return fmt.Errorf("completing interrupted migration: %w", err)
+		}
+	}
+	cursor, err := static.Get([]byte(migrationCursorKey))
+	if err != nil && err != store.ErrNotFound {
+		return err
+	}
+
+	for _, m := range pending {
+		writes := 0
+		for {
+			batch := journal.NewBatch()
+			next, err := m.Migrate(ctx, batch, cursor)
+			if err != nil {
+				return fmt.Errorf("migration %d (%s): %w", m.Version, m.Description, err)
+			}
+			writes += batch.Len()
+			if !dryRun {
+				if next != nil {
+					batch.Store("static").Put([]byte(migrationCursorKey), next)
+				} else {
+					batch.Store("static").Delete([]byte(migrationCursorKey))
+					batch.Store("static").Put([]byte(schemaVersionKey), []byte(strconv.Itoa(m.Version)))
+				}
+				if err = journal.Write(batch); err != nil {
+					return fmt.Errorf("writing migration %d: %w", m.Version, err)
+				}
+			}
+			if cursor = next; cursor == nil {
+				break
+			}
+		}
+		if report != nil {
+			report(m, writes)
+		}
+	}
+	return nil
+}
+
+// initSchemaVersion records the current schema version in a data directory without a version
+// that has not indexed any transaction yet. Such a directory holds nothing to migrate.
+func initSchemaVersion() error {
+	if _, err := staticDbInstance.Get([]byte(schemaVersionKey)); err != store.ErrNotFound {
+		return err
+	}
+	txnCount, err := getCounter(txDbInstance, "txnCount")
+	if err != nil || txnCount > 0 {
+		return err
+	}
+	return staticDbInstance.Put([]byte(schemaVersionKey), []byte(strconv.Itoa(SchemaVersion())))
+}
@@ -0,0 +1,152 @@
+package blocksync
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/airchains-network/tracks/config"
+	"github.com/airchains-network/tracks/store"
+	"github.com/airchains-network/tracks/types"
+)
+
+// useMemoryDatabases replaces the databases opened by InitDb with empty memory databases for the
+// duration of the test.
+func useMemoryDatabases(t *testing.T) {
+	instances := []*store.Store{&txDbInstance, &blockDbInstance, &staticDbInstance, &stateDbInstance,
+		&batchesDbInstance, &proofDbInstance, &publicWitnessDbInstance, &daDbInstance, &mockDbInstance}
+	saved := make([]store.Store, len(instances))
+	for i, instance := range instances {
+		saved[i], *instance = *instance, store.NewMemory()
+	}
+	t.Cleanup(func() {
+		for i, instance := range instances {
+			*instance = saved[i]
+		}
+	})
+}
+
+func TestMigrateResumesInterruptedMigration(t *testing.T) {
+	useMemoryDatabases(t)
+	var cursors []string
+	fail := true
+	chunked := Migration{Version: 1, Description: "chunked", Migrate: func(m *MigrationContext, batch *store.MultiBatch, cursor []byte) ([]byte, error) {
+		chunk, err := migrationIndex(cursor, 1)
+		if err != nil {
+			return nil, err
+		}
+		cursors = append(cursors, strconv.Itoa(chunk))
+		if chunk == 2 && fail {
+			fail = false
+			return nil, errors.New("interrupted")
+		}
+		batch.Store("tx").Put([]byte(fmt.Sprintf("chunk-%d", chunk)), []byte("done"))
+		if chunk == 3 {
+			return nil, nil
+		}
+		return []byte(strconv.Itoa(chunk + 1)), nil
+	}}
+	ctx := &MigrationContext{stores: migrationStores()}
+
+	if err := runMigrations(ctx, []Migration{chunked}, false, nil); err == nil {
+		t.Fatal("interrupted migration returned no error")
+	}
+	if cursor, _ := staticDbInstance.Get([]byte(migrationCursorKey)); string(cursor) != "2" {
+		t.Fatalf("migration cursor is %q after the interruption, want 2", cursor)
+	}
+	if version, _ := GetSchemaVersion(staticDbInstance); version != 0 {
+		t.Fatalf("schema version is %d after the interruption", version)
+	}
+
+	writes := 0
+	if err := runMigrations(ctx, []Migration{chunked}, false, func(m Migration, n int) { writes = n }); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(cursors) != "[1 2 2 3]" {
+		t.Errorf("chunks migrated in order %v, want [1 2 2 3]", cursors)
+	}
+	if writes != 2 {
+		t.Errorf("resumed migration reported %d writes, want 2", writes)
+	}
+	for chunk := 1; chunk <= 3; chunk++ {
+		if ok, _ := txDbInstance.Has([]byte(fmt.Sprintf("chunk-%d", chunk))); !ok {
+			t.Errorf("chunk %d is not written", chunk)
+		}
+	}
+	if ok, _ := staticDbInstance.Has([]byte(migrationCursorKey)); ok {
+		t.Error("migration cursor is left after the migration completed")
+	}
+	if version, _ := GetSchemaVersion(staticDbInstance); version != 1 {
+		t.Errorf("schema version is %d, want 1", version)
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	useMemoryDatabases(t)
+	txnCount := migrationChunk + 5
+	for i := 1; i <= txnCount; i++ {
+		txn, err := json.Marshal(types.TransactionStruct{Hash: fmt.Sprintf("0x%064x", i), From: "0x00000000000000000000000000000000000000a1", To: "0x00000000000000000000000000000000000000b2"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = txDbInstance.Put([]byte(fmt.Sprintf("txns-%d", i)), txn); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := txDbInstance.Put([]byte("txnCount"), []byte(strconv.Itoa(txnCount))); err != nil {
+		t.Fatal(err)
+	}
+	// pod records written before the protobuf encoding
+	pod := &types.PodState{LatestPodHeight: 2, LatestTxState: "VerifyPod", LatestPodHash: []byte("hash")}
+	legacy, err := json.Marshal(pod)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, put := range []func() error{
+		func() error { return stateDbInstance.Put([]byte("podState"), legacy) },
+		func() error { return batchesDbInstance.Put([]byte("pod-1"), legacy) },
+		func() error { return batchesDbInstance.Put([]byte("pod-2"), types.MarshalPodState(pod)) },
+	} {
+		if err = put(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	station := &config.StationConfig{StationType: "evm"}
+
+	var reported []int
+	if err = Migrate(station, true, func(m Migration, writes int) { reported = append(reported, m.Version) }); err != nil {
+		t.Fatal(err)
+	}
+	if len(reported) != SchemaVersion() {
+		t.Errorf("dry run reported migrations %v", reported)
+	}
+	if ok, _ := txDbInstance.Has([]byte(txnHashKey(fmt.Sprintf("0x%064x", 1)))); ok {
+		t.Error("dry run wrote the transaction indices")
+	}
+
+	if err = Migrate(station, false, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err = CheckSchemaVersion(); err != nil {
+		t.Fatal(err)
+	}
+	for _, i := range []int{1, migrationChunk, txnCount} {
+		if ok, _ := txDbInstance.Has([]byte(txnHashKey(fmt.Sprintf("0x%064x", i)))); !ok {
+			t.Errorf("transaction %d is not indexed by hash", i)
+		}
+	}
+	for _, record := range []struct {
+		db  store.Store
+		key string
+	}{{stateDbInstance, "podState"}, {batchesDbInstance, "pod-1"}, {batchesDbInstance, "pod-2"}} {
+		data, err := record.db.Get([]byte(record.key))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != string(types.MarshalPodState(pod)) {
+			t.Errorf("%s is not in the protobuf encoding after the migration", record.key)
+		}
+	}
+}
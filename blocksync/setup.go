@@ -2,41 +2,69 @@ package blocksync
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"github.com/airchains-network/tracks/config"
 	logs "github.com/airchains-network/tracks/log"
+	"github.com/airchains-network/tracks/store"
 	"github.com/airchains-network/tracks/types"
-	"github.com/syndtr/goleveldb/leveldb"
 	"log"
 	"os"
 	"path/filepath"
 )
 
-var txDbInstance *leveldb.DB
-var blockDbInstance *leveldb.DB
-var staticDbInstance *leveldb.DB
-var stateDbInstance *leveldb.DB
-var batchesDbInstance *leveldb.DB
-var proofDbInstance *leveldb.DB
-var publicWitnessDbInstance *leveldb.DB
-var daDbInstance *leveldb.DB
-var mockDbInstance *leveldb.DB
-
-// InitTxDb This function initializes a LevelDB database for transactions and returns a boolean indicating
-// whether the initialization was successful.
-func InitTxDb() bool {
-	homeDir, _ := os.UserHomeDir()
-	filePath := filepath.Join(homeDir, ".tracks/data/leveldb/tx")
+var txDbInstance store.Store
+var blockDbInstance store.Store
+var staticDbInstance store.Store
+var stateDbInstance store.Store
+var batchesDbInstance store.Store
+var proofDbInstance store.Store
+var publicWitnessDbInstance store.Store
+var daDbInstance store.Store
+var mockDbInstance store.Store
+
+// StorePath returns the directory of the named database under the configured db_path. A relative
+// db_path is resolved against the tracks home, see config.RootDir.
+func StorePath(base *config.BaseConfig, name string) (string, error) {
+	dir := base.DBPath
+	if dir == "" {
+		dir = config.DefaultDataDir
+	}
+	if !filepath.IsAbs(dir) {
+		root, err := config.RootDir(base.RootDir)
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(root, dir)
+	}
+	return filepath.Join(dir, store.BackendDir(base.DBBackend), name), nil
+}
 
-	txDB, err := leveldb.OpenFile(filePath, nil)
+// openStore opens the named database with the configured db_backend.
+func openStore(base *config.BaseConfig, name string) (store.Store, error) {
+	if base == nil {
+		base = config.DefaultBaseConfig()
+	}
+	path, err := StorePath(base, name)
+	if err != nil {
+		return nil, err
+	}
+	return store.Open(base.DBBackend, path)
+}
+
+// InitTxDb This function initializes a database for transactions and returns a boolean indicating
+// whether the initialization was successful.
+func InitTxDb(base *config.BaseConfig) bool {
+	txDB, err := openStore(base, "tx")
 	if err != nil {
-		log.Fatal("Failed to open transaction LevelDB:", err)
+		log.Fatal("Failed to open transaction database:", err)
 		return false
 	}
 	txDbInstance = txDB
 
-	txnNumberByte, err := txDbInstance.Get([]byte("txnCount"), nil)
+	txnNumberByte, err := txDbInstance.Get([]byte("txnCount"))
 	if txnNumberByte == nil || err != nil {
-		err = txDbInstance.Put([]byte("txnCount"), []byte("0"), nil)
+		err = txDbInstance.Put([]byte("txnCount"), []byte("0"))
 		if err != nil {
 			logs.Log.Error(fmt.Sprintf("Error in saving txnCount in txnDb : %s", err.Error()))
 			//return false
@@ -48,14 +76,12 @@ func InitTxDb() bool {
 
 }
 
-// InitBlockDb This function initializes a LevelDB database for storing blocks and returns a boolean indicating
+// InitBlockDb This function initializes a database for storing blocks and returns a boolean indicating
 // whether the initialization was successful.
-func InitBlockDb() bool {
-	homeDir, _ := os.UserHomeDir()
-	filePath := filepath.Join(homeDir, ".tracks/data/leveldb/blocks")
-	blockDB, err := leveldb.OpenFile(filePath, nil)
+func InitBlockDb(base *config.BaseConfig) bool {
+	blockDB, err := openStore(base, "blocks")
 	if err != nil {
-		log.Fatal("Failed to open block LevelDB:", err)
+		log.Fatal("Failed to open block database:", err)
 		return false
 	}
 
@@ -63,10 +89,10 @@ func InitBlockDb() bool {
 
 	// get
 
-	blockNumberByte, err := blockDB.Get([]byte("blockCount"), nil)
+	blockNumberByte, err := blockDB.Get([]byte("blockCount"))
 
 	if blockNumberByte == nil || err != nil {
-		err = blockDB.Put([]byte("blockCount"), []byte("0"), nil)
+		err = blockDB.Put([]byte("blockCount"), []byte("0"))
 		if err != nil {
 			logs.Log.Error(fmt.Sprintf("Error in saving blockCount in blockDatabase : %s", err.Error()))
 			//return false
@@ -77,32 +103,28 @@ func InitBlockDb() bool {
 	return true
 }
 
-// InitStaticDb This function initializes a static LevelDB database and returns a boolean indicating whether the
+// InitStaticDb This function initializes a static database and returns a boolean indicating whether the
 // initialization was successful or not.
-func InitStaticDb() bool {
-	homeDir, _ := os.UserHomeDir()
-	filePath := filepath.Join(homeDir, ".tracks/data/leveldb/static")
-	staticDB, err := leveldb.OpenFile(filePath, nil)
+func InitStaticDb(base *config.BaseConfig) bool {
+	staticDB, err := openStore(base, "static")
 	if err != nil {
-		log.Fatal("Failed to open static LevelDB:", err)
+		log.Fatal("Failed to open static database:", err)
 		return false
 	}
 	staticDbInstance = staticDB
 	return true
 }
 
-func InitStateDb() bool {
-	homeDir, _ := os.UserHomeDir()
-	filePath := filepath.Join(homeDir, ".tracks/data/leveldb/state")
-	stateDB, err := leveldb.OpenFile(filePath, nil)
+func InitStateDb(base *config.BaseConfig) bool {
+	stateDB, err := openStore(base, "state")
 	if err != nil {
-		log.Fatal("Failed to open state LevelDB:", err)
+		log.Fatal("Failed to open state database:", err)
 		return false
 	}
 
 	stateDbInstance = stateDB
 
-	podStateByte, err := stateDB.Get([]byte("podState"), nil)
+	podStateByte, err := stateDB.Get([]byte("podState"))
 	if podStateByte == nil || err != nil {
 
 		emptyPodState := types.PodState{
@@ -117,13 +139,7 @@ func InitStateDb() bool {
 			Batch:               nil,
 			MasterTrackAppHash:  nil,
 		}
-		byteEmptyPodState, err := json.Marshal(emptyPodState)
-		if err != nil {
-			logs.Log.Error(fmt.Sprintf("Error in marshalling emptyPodState : %s", err.Error()))
-			return false
-		}
-
-		err = stateDB.Put([]byte("podState"), byteEmptyPodState, nil)
+		err = stateDB.Put([]byte("podState"), types.MarshalPodState(&emptyPodState))
 		if err != nil {
 			logs.Log.Error(fmt.Sprintf("Error in saving podState in pod database : %s", err.Error()))
 			return false
@@ -134,50 +150,42 @@ func InitStateDb() bool {
 	return true
 }
 
-// InitBatchesDb This function initializes a batches LevelDB database and returns a boolean indicating whether the
+// InitBatchesDb This function initializes a batches database and returns a boolean indicating whether the
 // initialization was successful or not.
-func InitBatchesDb() bool {
-	homeDir, _ := os.UserHomeDir()
-	filePath := filepath.Join(homeDir, ".tracks/data/leveldb/batches")
-	batchesDB, err := leveldb.OpenFile(filePath, nil)
+func InitBatchesDb(base *config.BaseConfig) bool {
+	batchesDB, err := openStore(base, "batches")
 	if err != nil {
-		log.Fatal("Failed to open batches LevelDB:", err)
+		log.Fatal("Failed to open batches database:", err)
 		return false
 	}
 	batchesDbInstance = batchesDB
 	return true
 }
 
-// InitProofDb This function initializes a proof LevelDB database and returns a boolean indicating whether the
+// InitProofDb This function initializes a proof database and returns a boolean indicating whether the
 // initialization was successful or not.
-func InitProofDb() bool {
-	homeDir, _ := os.UserHomeDir()
-	filePath := filepath.Join(homeDir, ".tracks/data/leveldb/proof")
-	proofDB, err := leveldb.OpenFile(filePath, nil)
+func InitProofDb(base *config.BaseConfig) bool {
+	proofDB, err := openStore(base, "proof")
 	if err != nil {
-		log.Fatal("Failed to open proof LevelDB:", err)
+		log.Fatal("Failed to open proof database:", err)
 		return false
 	}
 	proofDbInstance = proofDB
 	return true
 }
 
-func InitPublicWitnessDb() bool {
-	homeDir, _ := os.UserHomeDir()
-	filePath := filepath.Join(homeDir, ".tracks/data/leveldb/publicWitness")
-	publicWitnessDB, err := leveldb.OpenFile(filePath, nil)
+func InitPublicWitnessDb(base *config.BaseConfig) bool {
+	publicWitnessDB, err := openStore(base, "publicWitness")
 	if err != nil {
-		log.Fatal("Failed to open publicWitness LevelDB:", err)
+		log.Fatal("Failed to open publicWitness database:", err)
 		return false
 	}
 	publicWitnessDbInstance = publicWitnessDB
 	return true
 }
 
-func InitDaDb() bool {
-	homeDir, _ := os.UserHomeDir()
-	filePath := filepath.Join(homeDir, ".tracks/data/leveldb/da")
-	daDB, err := leveldb.OpenFile(filePath, nil)
+func InitDaDb(base *config.BaseConfig) bool {
+	daDB, err := openStore(base, "da")
 	da := types.DAStruct{
 		DAKey:             "0",
 		DAClientName:      "0",
@@ -193,9 +201,9 @@ func InitDaDb() bool {
 	}
 
 	daDbInstance = daDB
-	daBytes, err = daDbInstance.Get([]byte("batch_0"), nil)
+	daBytes, err = daDbInstance.Get([]byte("batch_0"))
 	if daBytes == nil || err != nil {
-		err = daDbInstance.Put([]byte("batch_0"), daBytes, nil)
+		err = daDbInstance.Put([]byte("batch_0"), daBytes)
 		if err != nil {
 			logs.Log.Error(fmt.Sprintf("Error in saving daBytes in da Database : %s", err.Error()))
 			return false
@@ -204,13 +212,10 @@ func InitDaDb() bool {
 
 	return true
 }
-func InitMockDb() bool {
-
-	homeDir, _ := os.UserHomeDir()
-	filePath := filepath.Join(homeDir, ".tracks/data/leveldb/mock")
-	mockDB, err := leveldb.OpenFile(filePath, nil)
+func InitMockDb(base *config.BaseConfig) bool {
+	mockDB, err := openStore(base, "mock")
 	if err != nil {
-		log.Fatal("Failed to open mock LevelDB:", err)
+		log.Fatal("Failed to open mock database:", err)
 		return false
 	}
 	mockDbInstance = mockDB
@@ -219,80 +224,98 @@ func InitMockDb() bool {
 
 // InitDb This function  initializes three different databases and returns true if all of them are
 // successfully initialized, otherwise it returns false.
-func InitDb() bool {
-	if !InitTxDb() {
+func InitDb(base *config.BaseConfig) bool {
+	if !InitTxDb(base) {
+		return false
+	}
+	if !InitBlockDb(base) {
 		return false
 	}
-	if !InitBlockDb() {
+	if !InitStaticDb(base) {
 		return false
 	}
-	if !InitStaticDb() {
+	if !InitStateDb(base) {
 		return false
 	}
-	if !InitStateDb() {
+	if !InitBatchesDb(base) {
 		return false
 	}
-	if !InitBatchesDb() {
+	if !InitProofDb(base) {
 		return false
 	}
-	if !InitProofDb() {
+	if !InitPublicWitnessDb(base) {
 		return false
 	}
-	if !InitPublicWitnessDb() {
+	if !InitDaDb(base) {
 		return false
 	}
-	if !InitDaDb() {
+	if !InitMockDb(base) {
 		return false
 	}
-	if !InitMockDb() {
+	if err := initSchemaVersion(); err != nil {
+		logs.Log.Error(fmt.Sprintf("Error in recording the schema version : %s", err.Error()))
 		return false
 	}
 	return true
 }
 
 // GetTxDbInstance This function returns the instance of the air-leveldb database.
-func GetTxDbInstance() *leveldb.DB {
+func GetTxDbInstance() store.Store {
 	return txDbInstance
 }
 
 // GetBlockDbInstance This function returns the instance of the block database.
-func GetBlockDbInstance() *leveldb.DB {
+func GetBlockDbInstance() store.Store {
 	return blockDbInstance
 }
 
-// GetStaticDbInstance This function  is returning the instance of the LevelDB database that was
+// GetStaticDbInstance This function  is returning the instance of the database that was
 // initialized in the InitStaticDb function. This allows other parts of the code to access and use
-// the LevelDB database instance for performing operations such as reading or writing data.
-func GetStaticDbInstance() *leveldb.DB {
+// the database instance for performing operations such as reading or writing data.
+func GetStaticDbInstance() store.Store {
 	return staticDbInstance
 }
 
-func GetStateDbInstance() *leveldb.DB {
+func GetStateDbInstance() store.Store {
 	return stateDbInstance
 }
 
-// GetBatchesDbInstance This function  is returning the instance of the LevelDB database that was
+// GetBatchesDbInstance This function  is returning the instance of the database that was
 // initialized in the InitBatchesDb function. This allows other parts of the code to access and use
-// the LevelDB database instance for performing operations such as reading or writing data.
-func GetBatchesDbInstance() *leveldb.DB {
+// the database instance for performing operations such as reading or writing data.
+func GetBatchesDbInstance() store.Store {
 	return batchesDbInstance
 }
 
-// GetProofDbInstance This function  is returning the instance of the LevelDB database that was
+// GetProofDbInstance This function  is returning the instance of the database that was
 // initialized in the InitProofDb function. This allows other parts of the code to access and use
-// the LevelDB database instance for performing operations such as reading or writing data.
-func GetProofDbInstance() *leveldb.DB {
+// the database instance for performing operations such as reading or writing data.
+func GetProofDbInstance() store.Store {
 	return proofDbInstance
 }
 
-func GetPublicWitnessDbInstance() *leveldb.DB {
+func GetPublicWitnessDbInstance() store.Store {
 	return publicWitnessDbInstance
 }
 
-func GetDaDbInstance() *leveldb.DB {
+func GetDaDbInstance() store.Store {
 	return daDbInstance
 }
 
-func GetMockDbInstance() *leveldb.DB {
+func GetMockDbInstance() store.Store {
 	return mockDbInstance
 }
+
+// CloseDatabases closes every database opened by InitDb. Nothing may use them afterwards.
+func CloseDatabases() error {
+	var errs []error
+	for _, db := range []store.Store{txDbInstance, blockDbInstance, staticDbInstance, stateDbInstance, batchesDbInstance, proofDbInstance, publicWitnessDbInstance, daDbInstance, mockDbInstance} {
+		if db == nil {
+			continue
+		}
+		if err := db.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,120 @@
+package blocksync
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/airchains-network/tracks/store"
+)
+
+// restoreBatchSize is the number of transactions whose index entries RestoreChain writes per batch.
+const restoreBatchSize = 1000
+
+// ExportChain passes the records of every block up to and including the one that produced
+// transaction txnEnd to emit: the block data from the block database ("blocks"), and the block
+// metadata and transactions from the txn database ("tx"). It returns the progress a track
+// restored from these records resumes after. Blocks indexed before block metadata was recorded
+// cannot be exported.
+func ExportChain(ldb store.Store, ldt store.Store, indexer StationIndexer, txnEnd int, emit func(db string, key []byte, value []byte) error) (*Progress, error) {
+	progress, err := GetProgress(ldt)
+	if err != nil {
+		return nil, err
+	}
+	if progress == nil || progress.TxnIndex < txnEnd {
+		return nil, fmt.Errorf("transaction %d is not indexed yet", txnEnd)
+	}
+
+	var last *BlockMeta
+	for height := progress.Height; height >= indexer.FirstHeight() && last == nil; height-- {
+		meta, err := GetBlockMeta(ldt, height)
+		if err != nil {
+			return nil, err
+		}
+		if meta == nil {
+			return nil, fmt.Errorf("block %d has no metadata, it was indexed by an older release", height)
+		}
+		if meta.TxnEnd < txnEnd {
+			return nil, fmt.Errorf("no indexed block produced transaction %d", txnEnd)
+		}
+		if meta.TxnStart <= txnEnd {
+			last = meta
+		}
+	}
+	if last == nil {
+		return nil, fmt.Errorf("no indexed block produced transaction %d", txnEnd)
+	}
+
+	for height := indexer.FirstHeight(); height <= last.Height; height++ {
+		meta, err := ldt.Get(blockMetaKey(height))
+		if err == store.ErrNotFound {
+			return nil, fmt.Errorf("block %d has no metadata, it was indexed by an older release", height)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err = emit("tx", blockMetaKey(height), meta); err != nil {
+			return nil, err
+		}
+
+		key := []byte(indexer.BlockKey(height))
+		data, err := ldb.Get(key)
+		if err == store.ErrNotFound {
+			continue // skipped height
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err = emit("blocks", key, data); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := 1; i <= last.TxnEnd; i++ {
+		txn, err := GetTxn(ldt, i)
+		if err != nil {
+			return nil, err
+		}
+		if txn == nil {
+			return nil, fmt.Errorf("transaction %d is missing", i)
+		}
+		if err = emit("tx", []byte(fmt.Sprintf("txns-%d", i)), txn); err != nil {
+			return nil, err
+		}
+	}
+	return &Progress{Height: last.Height, Hash: last.Hash, TxnIndex: last.TxnEnd}, nil
+}
+
+// RestoreChain completes the records written from ExportChain: it adds the secondary index
+// entries of the transactions, then records the counters and the indexer progress, so the
+// indexer resumes after progress. Every restored transaction is treated as finalized.
+func RestoreChain(ldb store.Store, ldt store.Store, indexer StationIndexer, progress *Progress) error {
+	for first := 1; first <= progress.TxnIndex; first += restoreBatchSize {
+		batch := ldt.NewBatch()
+		for i := first; i < first+restoreBatchSize && i <= progress.TxnIndex; i++ {
+			txn, err := GetTxn(ldt, i)
+			if err != nil {
+				return err
+			}
+			if txn == nil {
+				return fmt.Errorf("transaction %d is missing", i)
+			}
+			if err = putTxnIndexEntries(indexer, batch, i, txn); err != nil {
+				return fmt.Errorf("error indexing transaction %d: %w", i, err)
+			}
+		}
+		if err := ldt.Write(batch); err != nil {
+			return err
+		}
+	}
+
+	batch := ldt.NewBatch()
+	batch.Put([]byte("txnCount"), []byte(strconv.Itoa(progress.TxnIndex)))
+	batch.Put([]byte(finalizedTxnCountKey), []byte(strconv.Itoa(progress.TxnIndex)))
+	if err := putProgress(batch, progress); err != nil {
+		return err
+	}
+	if err := ldt.Write(batch); err != nil {
+		return err
+	}
+	return ldb.Put([]byte("blockCount"), []byte(strconv.Itoa(progress.Height+1)))
+}
@@ -2,9 +2,11 @@ package blocksync
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/airchains-network/tracks/types/svmTypes"
+	"github.com/airchains-network/tracks/utils"
 	"github.com/deadlium/deadlogs"
 	"io"
 	"net/http"
@@ -13,21 +15,44 @@ import (
 
 var SVMChainRPCUrl string
 
+// svmEndpoints spreads the SVM JSON-RPC calls over the configured station endpoints once the SVM
+// indexer is set up.
+var svmEndpoints *utils.EndpointPool
+
 func initSVMRPC(JsonRPC string) {
 	SVMChainRPCUrl = JsonRPC
 }
 
-func svmRPCCall(method string, value any) ([]byte, error) {
-	payload := SVMPayLoad(method, value)
+func initSVMEndpoints(endpoints *utils.EndpointPool) {
+	svmEndpoints = endpoints
+}
+
+func svmRPCCall(ctx context.Context, method string, value any) ([]byte, error) {
+	return svmRPCPost(ctx, SVMPayLoad(method, value))
+}
 
+// svmRPCPost posts a JSON-RPC payload to the station, failing over between the SVM endpoints.
+func svmRPCPost(ctx context.Context, payload any) ([]byte, error) {
 	jsonPayload, jsonPayloadErr := json.Marshal(payload)
 	if jsonPayloadErr != nil {
 		return nil, fmt.Errorf("error marshaling JSON: %v", jsonPayloadErr)
 	}
 
+	if svmEndpoints == nil {
+		return svmRPCPostURL(ctx, SVMChainRPCUrl, jsonPayload)
+	}
+	var body []byte
+	err := svmEndpoints.Do(ctx, func(url string) (err error) {
+		body, err = svmRPCPostURL(ctx, url, jsonPayload)
+		return err
+	})
+	return body, err
+}
+
+func svmRPCPostURL(ctx context.Context, url string, jsonPayload []byte) ([]byte, error) {
 	client := &http.Client{}
 
-	req, reqErr := http.NewRequest("POST", SVMChainRPCUrl, bytes.NewBuffer(jsonPayload))
+	req, reqErr := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(jsonPayload))
 	if reqErr != nil {
 		return nil, fmt.Errorf("error creating request: %v", reqErr)
 	}
@@ -44,6 +69,9 @@ func svmRPCCall(method string, value any) ([]byte, error) {
 		}
 	}(resp.Body)
 
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error rpc call: status %s", resp.Status)
+	}
 	body, bodyErr := io.ReadAll(resp.Body)
 	if bodyErr != nil {
 		return nil, fmt.Errorf("error reading response: %v", bodyErr)
@@ -52,8 +80,21 @@ func svmRPCCall(method string, value any) ([]byte, error) {
 	return body, nil
 }
 
-func SVMLatestBlockCheck() (int, error) {
-	res, resErr := svmRPCCall("getSlot", nil)
+// svmCommitmentFinalized is the Solana commitment of blocks confirmed by a supermajority and rooted.
+const svmCommitmentFinalized = "finalized"
+
+func SVMLatestBlockCheck(ctx context.Context) (int, error) {
+	return SVMSlotCall(ctx, "")
+}
+
+// SVMSlotCall returns the current slot at the given commitment, or at the node's default
+// commitment if commitment is empty.
+func SVMSlotCall(ctx context.Context, commitment string) (int, error) {
+	var value any
+	if commitment != "" {
+		value = commitment
+	}
+	res, resErr := svmRPCCall(ctx, "getSlot", value)
 	if resErr != nil {
 		return 0, fmt.Errorf("error rpc call: %v", resErr)
 	}
@@ -67,9 +108,9 @@ func SVMLatestBlockCheck() (int, error) {
 	return latestBlock.Result, nil
 }
 
-func SVMBlockCall(height int) (*svmTypes.BlockResponseStruct, error) {
+func SVMBlockCall(ctx context.Context, height int) (*svmTypes.BlockResponseStruct, error) {
 
-	res, resErr := svmRPCCall("getBlock", height)
+	res, resErr := svmRPCCall(ctx, "getBlock", height)
 	if resErr != nil {
 		return nil, fmt.Errorf("error rpc call: %v", resErr)
 	}
@@ -83,9 +124,64 @@ func SVMBlockCall(height int) (*svmTypes.BlockResponseStruct, error) {
 	return &blockData, nil
 }
 
-func SVMBlockLeaderCall(height int) (*svmTypes.SlotLeaderResponseStruct, error) {
+// SVMBlocksInRange returns the finalized slots in from..to that produced a block. Slots of the
+// range missing from the result were skipped by the station.
+func SVMBlocksInRange(ctx context.Context, from int, to int) ([]int, error) {
+	res, resErr := svmRPCCall(ctx, "getBlocks", []int{from, to})
+	if resErr != nil {
+		return nil, fmt.Errorf("error rpc call: %v", resErr)
+	}
+
+	var blocksData svmTypes.BlocksResponseStruct
+	blocksDataErr := json.Unmarshal(res, &blocksData)
+	if blocksDataErr != nil {
+		return nil, fmt.Errorf("error decoding response: %v", blocksDataErr)
+	}
+	if blocksData.Error != nil {
+		return nil, fmt.Errorf("error getting blocks %d-%d: %s", from, to, blocksData.Error.Message)
+	}
+
+	return blocksData.Result, nil
+}
+
+// SVMBlocksCall fetches the given slots with a single JSON-RPC batch request, returning the
+// blocks in the order of the slots.
+func SVMBlocksCall(ctx context.Context, heights []int) ([]*svmTypes.BlockResponseStruct, error) {
+	payloads := make([]svmTypes.PayloadStruct, len(heights))
+	for i, height := range heights {
+		payloads[i] = SVMPayLoad("getBlock", height)
+		payloads[i].ID = i
+	}
+
+	res, resErr := svmRPCPost(ctx, payloads)
+	if resErr != nil {
+		return nil, fmt.Errorf("error rpc call: %v", resErr)
+	}
+
+	var blocksData []*svmTypes.BlockResponseStruct
+	blocksDataErr := json.Unmarshal(res, &blocksData)
+	if blocksDataErr != nil {
+		return nil, fmt.Errorf("error decoding response: %v", blocksDataErr)
+	}
+
+	blocks := make([]*svmTypes.BlockResponseStruct, len(heights))
+	for _, blockData := range blocksData {
+		if blockData.ID < 0 || blockData.ID >= len(blocks) {
+			return nil, fmt.Errorf("unexpected response id %d", blockData.ID)
+		}
+		blocks[blockData.ID] = blockData
+	}
+	for i, blockData := range blocks {
+		if blockData == nil {
+			return nil, fmt.Errorf("batch response is missing slot %d", heights[i])
+		}
+	}
+	return blocks, nil
+}
+
+func SVMBlockLeaderCall(ctx context.Context, height int) (*svmTypes.SlotLeaderResponseStruct, error) {
 
-	res, resErr := svmRPCCall("getSlotLeaders", height)
+	res, resErr := svmRPCCall(ctx, "getSlotLeaders", height)
 	if resErr != nil {
 		return nil, fmt.Errorf("error rpc call: %v", resErr)
 	}
@@ -99,7 +195,7 @@ func SVMBlockLeaderCall(height int) (*svmTypes.SlotLeaderResponseStruct, error)
 	return &leaderData, nil
 }
 
-func SVMAccountListCall() ([]svmTypes.AccountDetailsResponseStruct, error) {
+func SVMAccountListCall(ctx context.Context) ([]svmTypes.AccountDetailsResponseStruct, error) {
 
 	var leaderCircleData svmTypes.LargeAccountStruct
 	var leaderNonCircleData svmTypes.LargeAccountStruct
@@ -109,7 +205,7 @@ func SVMAccountListCall() ([]svmTypes.AccountDetailsResponseStruct, error) {
 
 	go func() {
 		defer wg.Done()
-		res, resErr := svmRPCCall("getLargestAccounts", "circulating")
+		res, resErr := svmRPCCall(ctx, "getLargestAccounts", "circulating")
 		if resErr != nil {
 			deadlogs.Warn(fmt.Sprintf("error rpc call: %v", resErr))
 		}
@@ -122,7 +218,7 @@ func SVMAccountListCall() ([]svmTypes.AccountDetailsResponseStruct, error) {
 
 	go func() {
 		defer wg.Done()
-		res, resErr := svmRPCCall("getLargestAccounts", "nonCirculating")
+		res, resErr := svmRPCCall(ctx, "getLargestAccounts", "nonCirculating")
 		if resErr != nil {
 			deadlogs.Warn(fmt.Sprintf("error rpc call: %v", resErr))
 		}
@@ -144,7 +240,7 @@ func SVMAccountListCall() ([]svmTypes.AccountDetailsResponseStruct, error) {
 		accountArray = append(accountArray, account.Address)
 	}
 
-	details, detailsErr := SVMAccountDetailsCall(accountArray)
+	details, detailsErr := SVMAccountDetailsCall(ctx, accountArray)
 	if detailsErr != nil {
 		return nil, fmt.Errorf("error fetching account details: %v", detailsErr)
 	}
@@ -161,9 +257,9 @@ func SVMAccountListCall() ([]svmTypes.AccountDetailsResponseStruct, error) {
 	return accountDetails, nil
 }
 
-func SVMAccountDetailsCall(address []string) (*svmTypes.AccountDetailsStruct, error) {
+func SVMAccountDetailsCall(ctx context.Context, address []string) (*svmTypes.AccountDetailsStruct, error) {
 
-	res, resErr := svmRPCCall("getMultipleAccounts", address)
+	res, resErr := svmRPCCall(ctx, "getMultipleAccounts", address)
 	if resErr != nil {
 		return nil, fmt.Errorf("error rpc call: %v", resErr)
 	}
@@ -179,11 +275,37 @@ func SVMAccountDetailsCall(address []string) (*svmTypes.AccountDetailsStruct, er
 
 func SVMPayLoad(method string, value any) svmTypes.PayloadStruct {
 	if method == "getSlot" {
+		params := make([]interface{}, 0)
+		if value != nil {
+			params = append(params, struct {
+				Commitment string `json:"commitment"`
+			}{
+				Commitment: value.(string),
+			})
+		}
 		return svmTypes.PayloadStruct{
 			JsonRPC: "2.0",
 			ID:      1,
 			Method:  method,
-			Params:  make([]interface{}, 0),
+			Params:  params,
+		}
+	}
+
+	if method == "getBlocks" {
+		slots := value.([]int)
+		return svmTypes.PayloadStruct{
+			JsonRPC: "2.0",
+			ID:      1,
+			Method:  method,
+			Params: []interface{}{
+				slots[0],
+				slots[1],
+				struct {
+					Commitment string `json:"commitment"`
+				}{
+					Commitment: svmCommitmentFinalized,
+				},
+			},
 		}
 	}
 
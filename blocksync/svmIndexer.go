@@ -0,0 +1,157 @@
+package blocksync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/airchains-network/tracks/config"
+	"github.com/airchains-network/tracks/types/svmTypes"
+)
+
+func init() {
+	RegisterIndexer("svm", NewSVMIndexer)
+}
+
+// SVMIndexer indexes SVM stations over the Solana JSON-RPC.
+type SVMIndexer struct{}
+
+// NewSVMIndexer points the SVM JSON-RPC client at the station and returns an SVM StationIndexer.
+// SVM stations only support the finalized policy.
+func NewSVMIndexer(station *config.StationConfig) (StationIndexer, error) {
+	if _, err := FinalityPolicy(station); err != nil {
+		return nil, err
+	}
+	initSVMRPC(station.StationRPC)
+	initSVMEndpoints(StationRPCEndpoints(station))
+	return &SVMIndexer{}, nil
+}
+
+func (s *SVMIndexer) FirstHeight() int {
+	return 1
+}
+
+// LatestHeight returns the latest finalized slot. Blocks and skipped slots are only known for
+// certain once finalized, so the SVM indexer never reads past it.
+func (s *SVMIndexer) LatestHeight(ctx context.Context) (int, error) {
+	return SVMSlotCall(ctx, svmCommitmentFinalized)
+}
+
+// FinalizedHeight returns the latest finalized slot.
+func (s *SVMIndexer) FinalizedHeight(ctx context.Context) (int, error) {
+	return SVMSlotCall(ctx, svmCommitmentFinalized)
+}
+
+func (s *SVMIndexer) FetchBlock(ctx context.Context, height int) (*StationBlock, error) {
+	blocks, err := s.FetchBlocks(ctx, height, height)
+	if err != nil {
+		return nil, err
+	}
+	return blocks[0], nil
+}
+
+// FetchBlocks lists the slots from..to that produced a block with getBlocks, fetches those with
+// a single getBlock batch request and returns a skipped marker for every other slot.
+func (s *SVMIndexer) FetchBlocks(ctx context.Context, from int, to int) ([]*StationBlock, error) {
+	produced, err := SVMBlocksInRange(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var responses []*svmTypes.BlockResponseStruct
+	if len(produced) > 0 {
+		if responses, err = SVMBlocksCall(ctx, produced); err != nil {
+			return nil, err
+		}
+	}
+
+	blocks := make([]*StationBlock, 0, to-from+1)
+	next := 0
+	for height := from; height <= to; height++ {
+		if next < len(produced) && produced[next] == height {
+			block, err := svmStationBlock(height, responses[next])
+			if err != nil {
+				return blocks, err
+			}
+			blocks = append(blocks, block)
+			next++
+			continue
+		}
+
+		data, err := json.Marshal(svmTypes.SkippedSlotStruct{Slot: height, Skipped: true})
+		if err != nil {
+			return blocks, err
+		}
+		blocks = append(blocks, &StationBlock{Height: height, Skipped: true, Data: data})
+	}
+	return blocks, nil
+}
+
+func svmStationBlock(height int, res *svmTypes.BlockResponseStruct) (*StationBlock, error) {
+	if res.Error != nil {
+		return nil, fmt.Errorf("error getting slot %d: %s", height, res.Error.Message)
+	}
+	if res.Result.Blockhash == "" {
+		return nil, fmt.Errorf("station returned no block for slot %d", height)
+	}
+	if res.Result.ParentSlot >= height {
+		return nil, fmt.Errorf("station returned parent slot %d for slot %d", res.Result.ParentSlot, height)
+	}
+
+	resJson, err := json.Marshal(res.Result)
+	if err != nil {
+		return nil, err
+	}
+
+	block := &StationBlock{
+		Height:     height,
+		Hash:       res.Result.Blockhash,
+		ParentHash: res.Result.PreviousBlockhash,
+		Data:       resJson,
+		Payload:    res,
+	}
+	if res.Result.BlockTime > 0 {
+		block.Time = time.Unix(int64(res.Result.BlockTime), 0)
+	}
+	return block, nil
+}
+
+func (s *SVMIndexer) ExtractTxns(_ context.Context, block *StationBlock) ([][]byte, error) {
+	res, ok := block.Payload.(*svmTypes.BlockResponseStruct)
+	if !ok {
+		return nil, fmt.Errorf("unexpected payload %T for svm block %d", block.Payload, block.Height)
+	}
+
+	txns := make([][]byte, 0, len(res.Result.Transactions))
+	for _, txn := range res.Result.Transactions {
+		data, err := json.Marshal(txn)
+		if err != nil {
+			return nil, err
+		}
+		txns = append(txns, data)
+	}
+	return txns, nil
+}
+
+// DescribeTxn returns the first signature of a Solana transaction with its account keys.
+func (s *SVMIndexer) DescribeTxn(txn []byte) (string, []string, error) {
+	var tx svmTypes.SVMTransactionStruct
+	if err := json.Unmarshal(txn, &tx); err != nil {
+		return "", nil, err
+	}
+	var hash string
+	if len(tx.Transaction.Signatures) > 0 {
+		hash = tx.Transaction.Signatures[0]
+	}
+	addresses := make([]string, 0, len(tx.Transaction.Message.AccountKeys))
+	for _, key := range tx.Transaction.Message.AccountKeys {
+		addresses = append(addresses, key.Pubkey)
+	}
+	return hash, addresses, nil
+}
+
+func (s *SVMIndexer) BlockKey(height int) string {
+	return "Block" + strconv.Itoa(height)
+}
@@ -0,0 +1,213 @@
+package blocksync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/airchains-network/tracks/types/svmTypes"
+)
+
+// fakeSVMStation serves getBlocks and batched getBlock requests for the slots in produced. Every
+// produced slot holds one transaction and builds on the previous produced slot. getBlock fails
+// for the slots in failing.
+type fakeSVMStation struct {
+	produced []int
+	failing  map[int]bool
+
+	mu            sync.Mutex
+	getBlockCalls int
+}
+
+func (f *fakeSVMStation) parent(slot int) int {
+	parent := 0
+	for _, produced := range f.produced {
+		if produced < slot {
+			parent = produced
+		}
+	}
+	return parent
+}
+
+func (f *fakeSVMStation) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var request svmTypes.PayloadStruct
+	if json.Unmarshal(body, &request) == nil && request.Method == "getBlocks" {
+		from, to := int(request.Params[0].(float64)), int(request.Params[1].(float64))
+		slots := []int{}
+		for _, slot := range f.produced {
+			if slot >= from && slot <= to {
+				slots = append(slots, slot)
+			}
+		}
+		json.NewEncoder(w).Encode(svmTypes.BlocksResponseStruct{Jsonrpc: "2.0", Result: slots, ID: request.ID})
+		return
+	}
+
+	var requests []svmTypes.PayloadStruct
+	if err := json.Unmarshal(body, &requests); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	f.mu.Lock()
+	f.getBlockCalls++
+	f.mu.Unlock()
+	responses := make([]*svmTypes.BlockResponseStruct, 0, len(requests))
+	for _, req := range requests {
+		slot := int(req.Params[0].(float64))
+		res := &svmTypes.BlockResponseStruct{JsonRpc: "2.0", ID: req.ID}
+		if f.failing[slot] {
+			res.Error = &svmTypes.Error{Code: -32004, Message: fmt.Sprintf("Block not available for slot %d", slot)}
+		} else {
+			res.Result.Blockhash = fmt.Sprintf("hash%d", slot)
+			res.Result.ParentSlot = f.parent(slot)
+			res.Result.PreviousBlockhash = fmt.Sprintf("hash%d", f.parent(slot))
+			res.Result.Transactions = make([]svmTypes.SVMTransactionStruct, 1)
+			res.Result.Transactions[0].Transaction.Signatures = []string{fmt.Sprintf("sig%d", slot)}
+		}
+		responses = append(responses, res)
+	}
+	json.NewEncoder(w).Encode(responses)
+}
+
+// newTestSVMStation points the SVM client at a fake station for the duration of the test.
+func newTestSVMStation(t *testing.T, station *fakeSVMStation) {
+	t.Helper()
+	server := httptest.NewServer(station)
+	previous := SVMChainRPCUrl
+	initSVMRPC(server.URL)
+	t.Cleanup(func() {
+		initSVMRPC(previous)
+		server.Close()
+	})
+}
+
+func TestSVMFetchBlocks(t *testing.T) {
+	tests := []struct {
+		name         string
+		produced     []int
+		failing      map[int]bool
+		from, to     int
+		want         string
+		wantErr      bool
+		wantGetBlock int
+	}{
+		{"no skipped slots", []int{1, 2, 3, 4}, nil, 1, 4, "1 2 3 4", false, 1},
+		{"skipped slots", []int{1, 3, 4}, nil, 1, 5, "1 2s 3 4 5s", false, 1},
+		{"range starts in a gap", []int{1, 4}, nil, 2, 4, "2s 3s 4", false, 1},
+		{"all slots skipped", []int{1}, nil, 2, 4, "2s 3s 4s", false, 0},
+		{"block not available", []int{1, 3, 4}, map[int]bool{3: true}, 1, 4, "1 2s", true, 1},
+	}
+	for _, tt := range tests {
+		station := &fakeSVMStation{produced: tt.produced, failing: tt.failing}
+		newTestSVMStation(t, station)
+
+		blocks, err := (&SVMIndexer{}).FetchBlocks(context.Background(), tt.from, tt.to)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: FetchBlocks returned error %v", tt.name, err)
+		}
+		var got string
+		for i, block := range blocks {
+			if i > 0 {
+				got += " "
+			}
+			got += fmt.Sprint(block.Height)
+			if block.Skipped {
+				got += "s"
+				var marker svmTypes.SkippedSlotStruct
+				if err = json.Unmarshal(block.Data, &marker); err != nil || marker != (svmTypes.SkippedSlotStruct{Slot: block.Height, Skipped: true}) {
+					t.Errorf("%s: slot %d is stored as %s", tt.name, block.Height, block.Data)
+				}
+				continue
+			}
+			if block.Hash != fmt.Sprintf("hash%d", block.Height) || block.ParentHash != fmt.Sprintf("hash%d", station.parent(block.Height)) {
+				t.Errorf("%s: slot %d is %s on %s", tt.name, block.Height, block.Hash, block.ParentHash)
+			}
+		}
+		if got != tt.want {
+			t.Errorf("%s: fetched %q, want %q", tt.name, got, tt.want)
+		}
+		if station.getBlockCalls != tt.wantGetBlock {
+			t.Errorf("%s: %d getBlock batch requests, want %d", tt.name, station.getBlockCalls, tt.wantGetBlock)
+		}
+	}
+}
+
+func TestSVMSkippedSlotMarkers(t *testing.T) {
+	newTestSVMStation(t, &fakeSVMStation{produced: []int{1, 3, 4, 6}})
+	s := newTestSyncer(t, &SVMIndexer{}, nil)
+	s.SetCatchUp(2, 2)
+
+	fetched, err := s.fetchRange(context.Background(), 1, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range fetched {
+		if _, err = persistBlock(s.indexer, f.block, f.txns, s.ldb, s.ldt); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := counter(t, s.ldt, "txnCount"); got != 3 {
+		t.Errorf("txnCount = %d, want one transaction of each of slots 1, 3 and 4", got)
+	}
+	if got := counter(t, s.ldb, "blockCount"); got != 6 {
+		t.Errorf("blockCount = %d, want 6", got)
+	}
+	meta, err := GetBlockMeta(s.ldt, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta == nil || !meta.Skipped || meta.TxnStart != 4 || meta.TxnEnd != 3 {
+		t.Errorf("meta of skipped slot 5 is %+v", meta)
+	}
+
+	// the next produced slot builds on the last produced slot, not on the skipped marker
+	last, err := s.lastBlockMeta(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last == nil || last.Height != 4 {
+		t.Fatalf("last produced slot is %+v, want slot 4", last)
+	}
+	next, err := s.indexer.FetchBlock(context.Background(), 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !extendsChain(last, next) {
+		t.Errorf("slot 6 on %s does not extend slot 4 %s", next.ParentHash, last.Hash)
+	}
+
+	// a reorganisation walks past skipped markers to the last produced slot and removes them
+	ancestor, err := s.findCommonAncestor(context.Background(), last)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ancestor.Height != 4 {
+		t.Errorf("common ancestor is slot %d, want 4", ancestor.Height)
+	}
+	first, err := GetBlockMeta(s.ldt, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = s.rewind(first, 5); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"blockMeta-2", "blockMeta-5"} {
+		if ok, _ := s.ldt.Has([]byte(key)); ok {
+			t.Errorf("%s is still stored after the rewind", key)
+		}
+	}
+	if ok, _ := s.ldb.Has([]byte(s.indexer.BlockKey(2))); ok {
+		t.Error("the skipped marker of slot 2 is still stored after the rewind")
+	}
+}
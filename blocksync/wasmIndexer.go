@@ -0,0 +1,416 @@
+package blocksync
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/airchains-network/tracks/config"
+	"github.com/airchains-network/tracks/utils"
+	"github.com/gorilla/websocket"
+	"github.com/rs/zerolog/log"
+)
+
+func init() {
+	RegisterIndexer("wasm", NewWasmIndexer)
+}
+
+// WasmIndexer indexes CosmWasm stations over the Tendermint RPC and the Cosmos REST API.
+type WasmIndexer struct {
+	rpc           *utils.EndpointPool
+	api           *utils.EndpointPool
+	finality      string
+	confirmations int
+}
+
+// NewWasmIndexer returns a CosmWasm StationIndexer.
+func NewWasmIndexer(station *config.StationConfig) (StationIndexer, error) {
+	finality, err := FinalityPolicy(station)
+	if err != nil {
+		return nil, err
+	}
+	return &WasmIndexer{
+		rpc:           StationRPCEndpoints(station),
+		api:           StationAPIEndpoints(station),
+		finality:      finality,
+		confirmations: station.Confirmations,
+	}, nil
+}
+
+func (w *WasmIndexer) FirstHeight() int {
+	return 1
+}
+
+func (w *WasmIndexer) LatestHeight(ctx context.Context) (int, error) {
+	var height int
+	err := w.api.Do(ctx, func(url string) error {
+		latestBlock, err := GetWasmCurrentBlock(ctx, url)
+		if err != nil {
+			return err
+		}
+		height, err = strconv.Atoi(latestBlock.Block.Header.Height)
+		return err
+	})
+	return height, err
+}
+
+// FinalizedHeight returns the latest committed height, Tendermint blocks are final once committed.
+func (w *WasmIndexer) FinalizedHeight(ctx context.Context) (int, error) {
+	latest, err := w.LatestHeight(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if w.finality == config.FinalityConfirmations {
+		return confirmedHeight(latest, w.confirmations, w.FirstHeight()), nil
+	}
+	return latest, nil
+}
+
+func (w *WasmIndexer) FetchBlock(ctx context.Context, height int) (*StationBlock, error) {
+	var block *StationBlock
+	err := w.rpc.Do(ctx, func(url string) error {
+		body, err := httpGet(ctx, fmt.Sprintf("%s/block?height=%d", url, height))
+		if err != nil {
+			return err
+		}
+		block, err = decodeWasmBlock(height, body)
+		return err
+	})
+	return block, err
+}
+
+// FetchBlocks fetches the blocks from..to with a single JSON-RPC batch request to the
+// Tendermint RPC.
+func (w *WasmIndexer) FetchBlocks(ctx context.Context, from int, to int) ([]*StationBlock, error) {
+	type blockRequest struct {
+		JsonRPC string            `json:"jsonrpc"`
+		ID      int               `json:"id"`
+		Method  string            `json:"method"`
+		Params  map[string]string `json:"params"`
+	}
+	requests := make([]blockRequest, 0, to-from+1)
+	for height := from; height <= to; height++ {
+		requests = append(requests, blockRequest{
+			JsonRPC: "2.0",
+			ID:      height,
+			Method:  "block",
+			Params:  map[string]string{"height": strconv.Itoa(height)},
+		})
+	}
+	payload, err := json.Marshal(requests)
+	if err != nil {
+		return nil, err
+	}
+	var responses []json.RawMessage
+	err = w.rpc.Do(ctx, func(url string) error {
+		body, err := httpPost(ctx, url, payload)
+		if err != nil {
+			return err
+		}
+		if err = json.Unmarshal(body, &responses); err != nil {
+			return fmt.Errorf("error decoding batch response of blocks %d-%d: %w", from, to, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	byHeight := make(map[int]json.RawMessage, len(responses))
+	for _, response := range responses {
+		var envelope struct {
+			ID int `json:"id"`
+		}
+		if err = json.Unmarshal(response, &envelope); err != nil {
+			return nil, err
+		}
+		byHeight[envelope.ID] = response
+	}
+
+	blocks := make([]*StationBlock, 0, len(requests))
+	for height := from; height <= to; height++ {
+		response, ok := byHeight[height]
+		if !ok {
+			return blocks, fmt.Errorf("batch response is missing block %d", height)
+		}
+		block, err := decodeWasmBlock(height, response)
+		if err != nil {
+			return blocks, err
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks, nil
+}
+
+// decodeWasmBlock decodes the JSON-RPC response of the block at the given height.
+func decodeWasmBlock(height int, body []byte) (*StationBlock, error) {
+	var blockData Response
+	if err := json.Unmarshal(body, &blockData); err != nil {
+		return nil, fmt.Errorf("error decoding block %d: %w", height, err)
+	}
+	if blockData.Result.Block.Header.Height != strconv.Itoa(height) {
+		return nil, fmt.Errorf("station returned block %q for height %d", blockData.Result.Block.Header.Height, height)
+	}
+
+	var responseMap map[string]interface{}
+	if err := json.Unmarshal(body, &responseMap); err != nil {
+		return nil, fmt.Errorf("error in response: %w", err)
+	}
+	result, ok := responseMap["result"]
+	if !ok {
+		return nil, fmt.Errorf("result key not found in response of block %d", height)
+	}
+	resultJSON, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	blockTime, err := time.Parse(time.RFC3339Nano, blockData.Result.Block.Header.Time)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding the time of block %d: %w", height, err)
+	}
+
+	return &StationBlock{
+		Height:     height,
+		Hash:       blockData.Result.BlockID.Hash,
+		ParentHash: blockData.Result.Block.Header.LastBlockID.Hash,
+		Time:       blockTime,
+		Data:       resultJSON,
+		Payload:    &blockData,
+	}, nil
+}
+
+func (w *WasmIndexer) ExtractTxns(ctx context.Context, block *StationBlock) ([][]byte, error) {
+	blockData, ok := block.Payload.(*Response)
+	if !ok {
+		return nil, fmt.Errorf("unexpected payload %T for wasm block %d", block.Payload, block.Height)
+	}
+
+	var txns [][]byte
+	for _, tx := range blockData.Result.Block.Data.Txs {
+		encodedTx, ok := tx.(string)
+		if !ok {
+			return nil, fmt.Errorf("unexpected transaction encoding in block %d", block.Height)
+		}
+		hash, err := ComputeTransactionHash(encodedTx)
+		if err != nil {
+			return nil, fmt.Errorf("error computing transaction hash: %w", err)
+		}
+
+		var bodyTxnHash []byte
+		var txn Transaction
+		err = w.api.Do(ctx, func(url string) (err error) {
+			bodyTxnHash, err = httpGet(ctx, fmt.Sprintf("%s/cosmos/tx/v1beta1/txs/%s", url, hash))
+			if err != nil {
+				return err
+			}
+			if err = json.Unmarshal(bodyTxnHash, &txn); err != nil {
+				return fmt.Errorf("failed to unmarshal transaction %s: %w", hash, err)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(txn.TxResponse.Tx.Body.Messages) == 0 {
+			log.Warn().Str("module", "blocksync").Msg(fmt.Sprintf("Skipping transaction %s without messages", hash))
+			continue
+		}
+		txns = append(txns, bodyTxnHash)
+	}
+	return txns, nil
+}
+
+// DescribeTxn returns the hash of a Cosmos transaction with the addresses found in its messages.
+func (w *WasmIndexer) DescribeTxn(txn []byte) (string, []string, error) {
+	var tx Transaction
+	if err := json.Unmarshal(txn, &tx); err != nil {
+		return "", nil, err
+	}
+	var addresses []string
+	for _, msg := range tx.TxResponse.Tx.Body.Messages {
+		fields, ok := msg.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for key, value := range fields {
+			address, ok := value.(string)
+			if ok && (strings.HasSuffix(key, "address") || key == "sender" || key == "contract" || key == "receiver") {
+				addresses = append(addresses, address)
+			}
+		}
+	}
+	return tx.TxResponse.TxHash, addresses, nil
+}
+
+// SubscribeHeads subscribes to NewBlock events over the CometBFT websocket of the station RPC.
+// It dials the endpoints of the RPC pool in order of preference, so a subscription that ends
+// because its endpoint went down is opened again on the next healthy one.
+func (w *WasmIndexer) SubscribeHeads(ctx context.Context) (<-chan int, error) {
+	var conn *websocket.Conn
+	err := w.rpc.Do(ctx, func(url string) (err error) {
+		conn, err = subscribeNewBlocks(ctx, url)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	heads := make(chan int, 1)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-done:
+		}
+		conn.Close()
+	}()
+	go func() {
+		defer close(heads)
+		defer close(done)
+		for {
+			var event struct {
+				Error *struct {
+					Message string `json:"message"`
+					Data    string `json:"data"`
+				} `json:"error"`
+				Result struct {
+					Data struct {
+						Value struct {
+							Block struct {
+								Header struct {
+									Height string `json:"height"`
+								} `json:"header"`
+							} `json:"block"`
+						} `json:"value"`
+					} `json:"data"`
+				} `json:"result"`
+			}
+			if err := conn.ReadJSON(&event); err != nil {
+				if ctx.Err() == nil {
+					log.Warn().Str("module", "blocksync").Err(err).Msg("Station websocket closed")
+				}
+				return
+			}
+			if event.Error != nil {
+				log.Warn().Str("module", "blocksync").Msg(fmt.Sprintf("Station websocket error: %s %s", event.Error.Message, event.Error.Data))
+				return
+			}
+			height, err := strconv.Atoi(event.Result.Data.Value.Block.Header.Height)
+			if err != nil {
+				// the subscription confirmation carries no block
+				continue
+			}
+			select {
+			case heads <- height:
+			default:
+			}
+		}
+	}()
+	return heads, nil
+}
+
+// subscribeNewBlocks opens the CometBFT websocket of an RPC endpoint and subscribes to NewBlock
+// events.
+func subscribeNewBlocks(ctx context.Context, rpcURL string) (*websocket.Conn, error) {
+	wsURL, err := websocketURL(rpcURL)
+	if err != nil {
+		return nil, err
+	}
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, wsURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to %s: %w", wsURL, err)
+	}
+	subscribe := map[string]any{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "subscribe",
+		"params":  map[string]string{"query": "tm.event='NewBlock'"},
+	}
+	if err = conn.WriteJSON(subscribe); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("error subscribing to new blocks: %w", err)
+	}
+	return conn, nil
+}
+
+// websocketURL returns the CometBFT websocket endpoint of an RPC address.
+func websocketURL(rpcURL string) (string, error) {
+	u, err := url.Parse(rpcURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid station rpc %q: %w", rpcURL, err)
+	}
+	switch u.Scheme {
+	case "https", "wss":
+		u.Scheme = "wss"
+	default:
+		u.Scheme = "ws"
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/websocket"
+	return u.String(), nil
+}
+
+func (w *WasmIndexer) BlockKey(height int) string {
+	return "Block" + strconv.Itoa(height)
+}
+
+// GetWasmCurrentBlock returns the latest block of the Cosmos API at JsonAPI.
+func GetWasmCurrentBlock(ctx context.Context, JsonAPI string) (BlockObject, error) {
+	body, err := httpGet(ctx, fmt.Sprintf("%s/cosmos/base/tendermint/v1beta1/blocks/latest", JsonAPI))
+	if err != nil {
+		return BlockObject{}, err
+	}
+
+	var data BlockObject
+	if err := json.Unmarshal(body, &data); err != nil {
+		return BlockObject{}, err
+	}
+
+	return data, nil
+}
+
+func ComputeTransactionHash(base64Tx string) (string, error) {
+	txBytes, err := base64.StdEncoding.DecodeString(base64Tx)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(txBytes)
+	txHash := hex.EncodeToString(hash[:])
+	return txHash, nil
+}
+
+func httpGet(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+func httpPost(ctx context.Context, url string, payload []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
@@ -0,0 +1,87 @@
+package blocksync
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/airchains-network/tracks/utils"
+	"github.com/gorilla/websocket"
+)
+
+// newBlockServer serves a CometBFT websocket that announces block height after a subscription.
+func newBlockServer(t *testing.T, height string) *httptest.Server {
+	upgrader := websocket.Upgrader{}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/websocket" {
+			http.NotFound(w, r)
+			return
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		var subscribe map[string]any
+		if err = conn.ReadJSON(&subscribe); err != nil {
+			return
+		}
+		conn.WriteJSON(map[string]any{"jsonrpc": "2.0", "id": 1, "result": map[string]any{}})
+		conn.WriteJSON(map[string]any{"result": map[string]any{"data": map[string]any{"value": map[string]any{
+			"block": map[string]any{"header": map[string]any{"height": height}},
+		}}}})
+		conn.ReadMessage()
+	}))
+}
+
+func TestWasmSubscribeHeads(t *testing.T) {
+	server := newBlockServer(t, "42")
+	defer server.Close()
+
+	w := &WasmIndexer{rpc: utils.NewEndpointPool("station rpc", []string{server.URL}, nil)}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	heads, err := w.SubscribeHeads(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case height := <-heads:
+		if height != 42 {
+			t.Errorf("subscription announced block %d, want 42", height)
+		}
+	case <-ctx.Done():
+		t.Fatal("no block announced by the station")
+	}
+}
+
+func TestWasmSubscribeHeadsFailsOver(t *testing.T) {
+	down := newBlockServer(t, "1")
+	down.Close()
+	up := newBlockServer(t, "42")
+	defer up.Close()
+
+	w := &WasmIndexer{rpc: utils.NewEndpointPool("station rpc", []string{down.URL, up.URL}, nil)}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	heads, err := w.SubscribeHeads(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case height := <-heads:
+		if height != 42 {
+			t.Errorf("subscription announced block %d, want 42", height)
+		}
+	case <-ctx.Done():
+		t.Fatal("no block announced by the healthy endpoint")
+	}
+	if status := w.rpc.Status(); status[0].Failures != 1 {
+		t.Errorf("the endpoint that is down has %d failures, want 1", status[0].Failures)
+	}
+}
@@ -10,6 +10,7 @@ import (
 	v1 "github.com/airchains-network/tracks/zk/v1EVM"
 	"github.com/google/uuid"
 	"github.com/spf13/cobra"
+	"strings"
 )
 
 type StationArgs struct {
@@ -18,6 +19,7 @@ type StationArgs struct {
 	jsonRPC       string
 	tracks        []string
 	bootstrapNode []string
+	podSize       int
 }
 
 func parseCmdArgs(cmd *cobra.Command) (*StationArgs, error) {
@@ -35,6 +37,13 @@ func parseCmdArgs(cmd *cobra.Command) (*StationArgs, error) {
 	if err != nil {
 		return nil, fmt.Errorf(" Failed to get 'bootstarpNode' flag values: %w", err)
 	}
+	args.podSize, err = cmd.Flags().GetInt("podSize")
+	if err != nil {
+		return nil, fmt.Errorf(" Failed to get 'podSize' flag value: %w", err)
+	}
+	if args.podSize < 1 {
+		return nil, fmt.Errorf(" --podSize must be at least 1")
+	}
 
 	return args, nil
 }
@@ -55,15 +64,17 @@ var CreateStation = &cobra.Command{
 			return
 		}
 
-		_, verificationKey, err := v1.GetVkPk()
+		_, verificationKey, err := v1.GetVkPk(stationArgs.podSize)
 		//The Unused variable is the proving key
 		if err != nil {
 			logs.Log.Error("Failed to read Proving Key & Verification key: " + err.Error())
+			logs.Log.Error(fmt.Sprintf("Generate the keys for pods of %d transactions with tracks prover v1%s --podSize %d", stationArgs.podSize, strings.ToUpper(conf.Station.StationType), stationArgs.podSize))
 			return
 		}
 
 		stationInfo := types.StationInfo{
 			StationType: conf.Station.StationType,
+			PodSize:     stationArgs.podSize,
 		}
 
 		extraArg := junctionTypes.StationArg{
@@ -0,0 +1,71 @@
+package command
+
+import (
+	"fmt"
+
+	"github.com/airchains-network/tracks/blocksync"
+	"github.com/airchains-network/tracks/dbcheck"
+	logger "github.com/airchains-network/tracks/log"
+	"github.com/airchains-network/tracks/node/shared"
+	"github.com/spf13/cobra"
+)
+
+var DbCmd = &cobra.Command{
+	Use:   "db",
+	Short: "Inspect the track databases",
+	Run: func(cmd *cobra.Command, _ []string) {
+		if err := cmd.Help(); err != nil {
+			cmd.Println("Unable to display help:", err)
+		}
+	},
+}
+
+var DbCheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Verify the database counters against their contents and the pod hash chain",
+	Long: `Verify txnCount, finalizedTxnCount, blockCount, batchCount and batchStartIndex against the
+transactions, the indexer progress and the saved pods, and the saved pods against the pod hash
+chain. The sequencer must be stopped while checking.
+
+With --repair the counters that disagree are set to the values derived from the contents. Other
+errors, such as a broken pod hash chain, have to be resolved by a rollback or a snapshot import.`,
+	Run: runDbCheckCommand,
+}
+
+func runDbCheckCommand(cmd *cobra.Command, _ []string) {
+	repair, _ := cmd.Flags().GetBool("repair")
+
+	conf, err := shared.LoadConfig()
+	if err != nil {
+		logger.Log.Error(fmt.Sprintf("Error in loading config : %s", err.Error()))
+		return
+	}
+	if !blocksync.InitDb(conf.BaseConfig) {
+		logger.Log.Error("Error in opening the databases")
+		return
+	}
+
+	report, err := dbcheck.Check(conf)
+	if err != nil {
+		logger.Log.Error(fmt.Sprintf("Error in checking the databases : %s", err.Error()))
+		return
+	}
+	for _, issue := range report.Issues {
+		if issue.Warning {
+			logger.Log.Warn(issue.String())
+		} else {
+			logger.Log.Error(issue.String())
+		}
+	}
+	logger.Log.Info(fmt.Sprintf("Checked %d transactions, %d blocks and %d pods, found %d issues", report.TxnCount, report.BlockCount, report.Pods, len(report.Issues)))
+
+	if !repair {
+		return
+	}
+	repaired, err := dbcheck.Repair(report)
+	if err != nil {
+		logger.Log.Error(fmt.Sprintf("Error in repairing counters : %s", err.Error()))
+		return
+	}
+	logger.Log.Info(fmt.Sprintf("Repaired %d counters", repaired))
+}
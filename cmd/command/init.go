@@ -2,14 +2,15 @@ package command
 
 import (
 	"fmt"
+	"github.com/airchains-network/tracks/blocksync"
 	"github.com/airchains-network/tracks/config"
 	logs "github.com/airchains-network/tracks/log"
 
 	//logs "github.com/airchains-network/tracks/log"
 	"github.com/airchains-network/tracks/p2p"
+	"github.com/airchains-network/tracks/store"
 	"github.com/spf13/cobra"
-	"os"
-	"path/filepath"
+	"time"
 )
 
 type Configs struct {
@@ -20,6 +21,15 @@ type Configs struct {
 	daKey       string
 	stationRPC  string
 	stationAPI  string
+	stationRPCs []string
+	stationAPIs []string
+
+	finality       string
+	confirmations  int
+	maxPodInterval time.Duration
+	provingQueue   int
+
+	dbBackend string
 }
 
 func InitConfigs(cmd *cobra.Command) (*Configs, error) {
@@ -72,6 +82,56 @@ func InitConfigs(cmd *cobra.Command) (*Configs, error) {
 		return nil, fmt.Errorf("failed to get flag 'stationAPI': %w", err)
 	}
 
+	configs.stationRPCs, err = cmd.Flags().GetStringSlice("stationRpcs")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get flag 'stationRpcs': %w", err)
+	}
+
+	configs.stationAPIs, err = cmd.Flags().GetStringSlice("stationAPIs")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get flag 'stationAPIs': %w", err)
+	}
+
+	configs.finality, err = cmd.Flags().GetString("finality")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get flag 'finality': %w", err)
+	}
+
+	configs.confirmations, err = cmd.Flags().GetInt("confirmations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get flag 'confirmations': %w", err)
+	}
+	station := &config.StationConfig{StationType: configs.stationType, Finality: configs.finality, Confirmations: configs.confirmations}
+	if _, err = blocksync.FinalityPolicy(station); err != nil {
+		return nil, err
+	}
+
+	configs.maxPodInterval, err = cmd.Flags().GetDuration("maxPodInterval")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get flag 'maxPodInterval': %w", err)
+	}
+	if configs.maxPodInterval < 0 {
+		return nil, fmt.Errorf("--maxPodInterval must not be negative")
+	}
+
+	configs.provingQueue, err = cmd.Flags().GetInt("provingQueue")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get flag 'provingQueue': %w", err)
+	}
+	if configs.provingQueue <= 0 {
+		return nil, fmt.Errorf("--provingQueue must be positive")
+	}
+
+	configs.dbBackend, err = cmd.Flags().GetString("dbBackend")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get flag 'dbBackend': %w", err)
+	}
+	switch configs.dbBackend {
+	case store.BackendGoLevelDB, store.BackendPebble, store.BackendMemory:
+	default:
+		return nil, fmt.Errorf("unknown --dbBackend %q", configs.dbBackend)
+	}
+
 	return &configs, nil
 }
 
@@ -85,25 +145,30 @@ var InitCmd = &cobra.Command{
 			return
 		}
 
-		homeDir, err := os.UserHomeDir()
+		tracksDir, err := config.HomeDir()
 		if err != nil {
-			logs.Log.Error("Failed to get user home directory:" + err.Error())
+			logs.Log.Error("Failed to get tracks home directory:" + err.Error())
 			return
 		}
 
-		tracksDir := filepath.Join(homeDir, config.DefaultTracksDir)
-
 		conf := config.DefaultConfig()
 		peerGen := p2p.NewPeerGenerator("/ip4/0.0.0.0/tcp/2300", false)
 		peerID, err := peerGen.GeneratePeerID()
 
 		conf.BaseConfig.RootDir = tracksDir
+		conf.BaseConfig.DBBackend = configs.dbBackend
 		conf.DA.DaType = configs.daType
 		conf.DA.DaRPC = configs.daRPC
 		conf.DA.DaKey = configs.daKey
 		conf.Station.StationType = configs.stationType
 		conf.Station.StationRPC = configs.stationRPC
 		conf.Station.StationAPI = configs.stationAPI
+		conf.Station.StationRPCs = configs.stationRPCs
+		conf.Station.StationAPIs = configs.stationAPIs
+		conf.Station.Finality = configs.finality
+		conf.Station.Confirmations = configs.confirmations
+		conf.Station.MaxPodInterval = configs.maxPodInterval
+		conf.Station.ProvingQueue = configs.provingQueue
 		conf.P2P.NodeId = peerID
 		conf.SetRoot(conf.BaseConfig.RootDir)
 
@@ -0,0 +1,68 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/airchains-network/tracks/blocksync"
+	logger "github.com/airchains-network/tracks/log"
+	"github.com/airchains-network/tracks/node/shared"
+	"github.com/spf13/cobra"
+)
+
+var LedgerCmd = &cobra.Command{
+	Use:   "ledger",
+	Short: "Manage the local account ledger used for pod pre-state",
+	Run: func(cmd *cobra.Command, _ []string) {
+		if err := cmd.Help(); err != nil {
+			cmd.Println("Unable to display help:", err)
+		}
+	},
+}
+
+var LedgerSeedCmd = &cobra.Command{
+	Use:   "seed",
+	Short: "Seed the account ledger from a snapshot of the station accounts at an indexed height",
+	Long: `Seed the account ledger from a JSON snapshot of the station accounts as of the end of an
+indexed block:
+
+  {"height": 1200, "accounts": {"0x...": {"balance": "1000000000000000000", "nonce": 3}}}
+
+Transactions indexed after the snapshot height are replayed into the ledger the next time the
+sequencer starts. Every tracks seeded from the same snapshot derives the same pod pre-state.`,
+	Run: runLedgerSeedCommand,
+}
+
+func runLedgerSeedCommand(cmd *cobra.Command, _ []string) {
+	snapshotPath, _ := cmd.Flags().GetString("snapshot")
+
+	data, err := os.ReadFile(snapshotPath)
+	if err != nil {
+		logger.Log.Error(fmt.Sprintf("Error in reading ledger snapshot : %s", err.Error()))
+		return
+	}
+	var snapshot blocksync.LedgerSnapshot
+	if err = json.Unmarshal(data, &snapshot); err != nil {
+		logger.Log.Error(fmt.Sprintf("Error in decoding ledger snapshot : %s", err.Error()))
+		return
+	}
+
+	conf, err := shared.LoadConfig()
+	if err != nil {
+		logger.Log.Error(fmt.Sprintf("Error in loading config : %s", err.Error()))
+		return
+	}
+	if !blocksync.InitTxDb(conf.BaseConfig) {
+		logger.Log.Error("Error in opening the transaction database")
+		return
+	}
+	txnDB := blocksync.GetTxDbInstance()
+	defer txnDB.Close()
+
+	if err = blocksync.SeedLedger(txnDB, &snapshot); err != nil {
+		logger.Log.Error(fmt.Sprintf("Error in seeding the ledger : %s", err.Error()))
+		return
+	}
+	logger.Log.Info(fmt.Sprintf("Ledger seeded with %d accounts at block %d", len(snapshot.Accounts), snapshot.Height))
+}
@@ -0,0 +1,65 @@
+package command
+
+import (
+	"fmt"
+
+	"github.com/airchains-network/tracks/blocksync"
+	logger "github.com/airchains-network/tracks/log"
+	"github.com/airchains-network/tracks/node/shared"
+	"github.com/spf13/cobra"
+)
+
+var MigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Upgrade the data directory to the schema version of this release",
+	Long: `Upgrade the data directory to the schema version of this release by running the pending
+migrations in order. Each migration is written in one step together with the new schema version,
+so an interrupted run can simply be repeated. The sequencer does not start until the data
+directory is migrated.
+
+With --dry-run the pending migrations and the number of writes each makes are listed without
+changing anything.`,
+	Run: runMigrateCommand,
+}
+
+func runMigrateCommand(cmd *cobra.Command, _ []string) {
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+	conf, err := shared.LoadConfig()
+	if err != nil {
+		logger.Log.Error(fmt.Sprintf("Error in loading config : %s", err.Error()))
+		return
+	}
+	if !blocksync.InitDb(conf.BaseConfig) {
+		logger.Log.Error("Error in opening the databases")
+		return
+	}
+
+	version, err := blocksync.GetSchemaVersion(blocksync.GetStaticDbInstance())
+	if err != nil {
+		logger.Log.Error(fmt.Sprintf("Error in reading the schema version : %s", err.Error()))
+		return
+	}
+	if version >= blocksync.SchemaVersion() {
+		logger.Log.Info(fmt.Sprintf("Data directory is at schema version %d, nothing to migrate", version))
+		return
+	}
+	logger.Log.Info(fmt.Sprintf("Data directory is at schema version %d, this release uses %d", version, blocksync.SchemaVersion()))
+
+	err = blocksync.Migrate(conf.Station, dryRun, func(m blocksync.Migration, writes int) {
+		if dryRun {
+			logger.Log.Info(fmt.Sprintf("Would apply migration %d: %s (%d writes)", m.Version, m.Description, writes))
+		} else {
+			logger.Log.Info(fmt.Sprintf("Applied migration %d: %s (%d writes)", m.Version, m.Description, writes))
+		}
+	})
+	if err != nil {
+		logger.Log.Error(fmt.Sprintf("Error in migrating the data directory : %s", err.Error()))
+		return
+	}
+	if dryRun {
+		logger.Log.Info("Dry run finished, nothing was written")
+		return
+	}
+	logger.Log.Info(fmt.Sprintf("Data directory migrated to schema version %d", blocksync.SchemaVersion()))
+}
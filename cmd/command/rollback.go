@@ -1,9 +1,7 @@
 package command
 
 import (
-	"encoding/json"
 	"fmt"
-	"github.com/airchains-network/tracks/config"
 	logger "github.com/airchains-network/tracks/log"
 	"github.com/airchains-network/tracks/node/shared"
 	"github.com/airchains-network/tracks/p2p"
@@ -28,8 +26,6 @@ func runRollbackCommand(_ *cobra.Command, _ []string) {
 	}
 
 	batchDB := shared.Node.NodeConnections.GetPodsDatabaseConnection()
-	staticDBConnection := shared.Node.NodeConnections.GetStaticDatabaseConnection()
-	stateConnection := shared.Node.NodeConnections.GetStateDatabaseConnection()
 
 	podStateData, err := p2p.GetPodStateFromDatabase()
 	if err != nil {
@@ -45,34 +41,27 @@ func runRollbackCommand(_ *cobra.Command, _ []string) {
 	processingPodNumber := podStateData.LatestPodHeight
 	requiredPodNumberInt := int(processingPodNumber - 1)
 	podKey := fmt.Sprintf("pod-%d", requiredPodNumberInt)
-	oldPodStateByte, err := batchDB.Get([]byte(podKey), nil)
+	oldPodStateByte, err := batchDB.Get([]byte(podKey))
 	if err != nil {
 		logger.Log.Error("Error in getting old pod state data from database")
 		return
 	}
 	// unmarshal
-	var oldPodStateData types.PodState
-	err = json.Unmarshal(oldPodStateByte, &oldPodStateData)
+	oldPodStateData, err := types.UnmarshalPodState(oldPodStateByte)
 	if err != nil {
 		logger.Log.Error("Error in unmarshalling old pod state data")
 		return
 	}
 
-	err = stateConnection.Put([]byte("podState"), oldPodStateByte, nil)
-	if err != nil {
-		logger.Log.Error("Error in updating podState in state db")
-		return
-	}
-
-	err = staticDBConnection.Put([]byte("batchStartIndex"), []byte(strconv.Itoa(config.PODSize*(requiredPodNumberInt))), nil)
-	if err != nil {
-		logger.Log.Error("Error in updating batchStartIndex in static db")
-		return
-	}
-
-	err = staticDBConnection.Put([]byte("batchCount"), []byte(strconv.Itoa(requiredPodNumberInt)), nil)
-	if err != nil {
-		logger.Log.Error("Error in updating batchCount in static db")
+	// restore the pod state and both counters together
+	journal := shared.Node.NodeConnections.GetPodJournal()
+	batch := journal.NewBatch()
+	batch.Store(shared.PodStoreState).Put([]byte("podState"), types.MarshalPodState(oldPodStateData))
+	static := batch.Store(shared.PodStoreStatic)
+	static.Put([]byte("batchStartIndex"), []byte(strconv.Itoa(shared.PodTxnEnd(oldPodStateData.Batch, requiredPodNumberInt, shared.Node.Config.Station.GetPodSize()))))
+	static.Put([]byte("batchCount"), []byte(strconv.Itoa(requiredPodNumberInt)))
+	if err = journal.Write(batch); err != nil {
+		logger.Log.Error("Error in updating podState, batchStartIndex and batchCount")
 		return
 	}
 
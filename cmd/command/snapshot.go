@@ -0,0 +1,97 @@
+package command
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/airchains-network/tracks/blocksync"
+	logger "github.com/airchains-network/tracks/log"
+	"github.com/airchains-network/tracks/node/shared"
+	"github.com/airchains-network/tracks/snapshot"
+	"github.com/spf13/cobra"
+)
+
+var SnapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "Export or import an archive of the track data up to a saved pod",
+	Run: func(cmd *cobra.Command, _ []string) {
+		if err := cmd.Help(); err != nil {
+			cmd.Println("Unable to display help:", err)
+		}
+	},
+}
+
+var SnapshotExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Write the blocks, transactions, pods, DA pointers and pod state up to a saved pod to an archive",
+	Long: `Write the blocks, transactions, pods, DA pointers and pod state up to a saved pod to a
+versioned, checksummed archive. The sequencer must be stopped while exporting. The account
+ledger is not exported; seed it again with "tracks ledger seed" after importing.`,
+	Run: runSnapshotExportCommand,
+}
+
+var SnapshotImportCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Restore an archive into a new data directory, verifying it against the pod hash chain",
+	Run:   runSnapshotImportCommand,
+}
+
+func runSnapshotExportCommand(cmd *cobra.Command, _ []string) {
+	podHeight, _ := cmd.Flags().GetInt("height")
+	output, _ := cmd.Flags().GetString("output")
+
+	conf, err := shared.LoadConfig()
+	if err != nil {
+		logger.Log.Error(fmt.Sprintf("Error in loading config : %s", err.Error()))
+		return
+	}
+	if !blocksync.InitDb(conf.BaseConfig) {
+		logger.Log.Error("Error in opening the databases")
+		return
+	}
+	if err = blocksync.CheckSchemaVersion(); err != nil {
+		logger.Log.Error(err.Error())
+		return
+	}
+
+	file, err := os.Create(output)
+	if err != nil {
+		logger.Log.Error(fmt.Sprintf("Error in creating snapshot file : %s", err.Error()))
+		return
+	}
+	manifest, err := snapshot.Export(file, conf, podHeight)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		logger.Log.Error(fmt.Sprintf("Error in exporting snapshot : %s", err.Error()))
+		_ = os.Remove(output)
+		return
+	}
+	logger.Log.Info(fmt.Sprintf("Exported pod %d, block %d and %d transactions to %s", manifest.PodHeight, manifest.Progress.Height, manifest.Progress.TxnIndex, output))
+}
+
+func runSnapshotImportCommand(cmd *cobra.Command, _ []string) {
+	input, _ := cmd.Flags().GetString("input")
+
+	conf, err := shared.LoadConfig()
+	if err != nil {
+		logger.Log.Error(fmt.Sprintf("Error in loading config : %s", err.Error()))
+		return
+	}
+	if !blocksync.InitDb(conf.BaseConfig) {
+		logger.Log.Error("Error in opening the databases")
+		return
+	}
+	if err = blocksync.CheckSchemaVersion(); err != nil {
+		logger.Log.Error(err.Error())
+		return
+	}
+
+	manifest, err := snapshot.Import(input, conf)
+	if err != nil {
+		logger.Log.Error(fmt.Sprintf("Error in importing snapshot : %s", err.Error()))
+		return
+	}
+	logger.Log.Info(fmt.Sprintf("Imported pod %d, block %d and %d transactions, the indexer resumes at block %d", manifest.PodHeight, manifest.Progress.Height, manifest.Progress.TxnIndex, manifest.Progress.Height+1))
+}
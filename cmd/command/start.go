@@ -25,11 +25,15 @@ func initSequencer() error {
 		return err
 	}
 
-	if success := blocksync.InitDb(); !success {
+	if success := blocksync.InitDb(config.BaseConfig); !success {
 		return errors.New("failed to initialize database")
 	}
 	logger.Log.Info("Database Initialized")
 
+	if err = blocksync.CheckSchemaVersion(); err != nil {
+		return err
+	}
+
 	if config.Junction.StationId == "" {
 		return errors.New("create station before stating sequencer")
 	}
@@ -1,18 +1,48 @@
 package zkpCmd
 
 import (
+	logs "github.com/airchains-network/tracks/log"
+	"github.com/airchains-network/tracks/node/shared"
 	v1 "github.com/airchains-network/tracks/zk/v1EVM"
+	v1Svm "github.com/airchains-network/tracks/zk/v1SVM"
 	v1Wasm "github.com/airchains-network/tracks/zk/v1WASM"
 	"github.com/spf13/cobra"
+	"strconv"
 )
 
-func runV1ZKPCommand(_ *cobra.Command, _ []string) {
-	v1.CreateVkPkNew()
+// podSize returns the --podSize flag, or the pod size of the configured station when it is not
+// given.
+func podSize(cmd *cobra.Command) (int, bool) {
+	size, _ := cmd.Flags().GetInt("podSize")
+	if !cmd.Flags().Changed("podSize") {
+		if conf, err := shared.LoadConfig(); err == nil && conf.Station != nil {
+			size = conf.Station.GetPodSize()
+		}
+	}
+	if size < 1 {
+		logs.Log.Error("--podSize must be at least 1")
+		return 0, false
+	}
+	logs.Log.Info("Generating keys for pods of " + strconv.Itoa(size) + " transactions")
+	return size, true
 }
 
-func runV1WasmZKPCommand(_ *cobra.Command, _ []string) {
-	v1Wasm.CreateVkPkWasm()
+func runV1ZKPCommand(cmd *cobra.Command, _ []string) {
+	if size, ok := podSize(cmd); ok {
+		v1.CreateVkPkNew(size)
+	}
+}
+
+func runV1WasmZKPCommand(cmd *cobra.Command, _ []string) {
+	if size, ok := podSize(cmd); ok {
+		v1Wasm.CreateVkPkWasm(size)
+	}
+}
 
+func runV1SvmZKPCommand(cmd *cobra.Command, _ []string) {
+	if size, ok := podSize(cmd); ok {
+		v1Svm.CreateVkPkSvm(size)
+	}
 }
 
 var V1ZKP = &cobra.Command{
@@ -25,3 +55,8 @@ var V1ZKPWasm = &cobra.Command{
 	Short: "Initialize the Wasm Version 1  Zero Knowledge Prover",
 	Run:   runV1WasmZKPCommand,
 }
+var V1ZKPSvm = &cobra.Command{
+	Use:   "v1SVM",
+	Short: "Initialize the SVM Version 1  Zero Knowledge Prover",
+	Run:   runV1SvmZKPCommand,
+}
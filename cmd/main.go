@@ -7,6 +7,8 @@ import (
 	"github.com/airchains-network/tracks/cmd/command"
 	"github.com/airchains-network/tracks/cmd/command/keys"
 	"github.com/airchains-network/tracks/cmd/command/zkpCmd"
+	"github.com/airchains-network/tracks/config"
+	"github.com/airchains-network/tracks/store"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/spf13/cobra"
 )
@@ -23,7 +25,12 @@ func main() {
 	var rootCmd = &cobra.Command{
 		Use:   "track",
 		Short: "Decentralized Sequencer for Stations",
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			home, _ := cmd.Flags().GetString("home")
+			config.SetHomeDir(home)
+		},
 	}
+	rootCmd.PersistentFlags().String("home", "", "Home directory of the Tracks (default $"+config.HomeEnv+" or ~/.tracks)")
 
 	// Define version command
 	var versionCmd = &cobra.Command{
@@ -41,6 +48,10 @@ func main() {
 	rootCmd.AddCommand(command.ProverGenCMD)
 	rootCmd.AddCommand(command.CreateStation)
 	rootCmd.AddCommand(command.Rollback)
+	rootCmd.AddCommand(command.LedgerCmd)
+	rootCmd.AddCommand(command.MigrateCmd)
+	rootCmd.AddCommand(command.SnapshotCmd)
+	rootCmd.AddCommand(command.DbCmd)
 	rootCmd.AddCommand(versionCmd) // Add version command
 
 	// Add subcommands to keygen and provergen
@@ -48,6 +59,11 @@ func main() {
 	command.KeyGenCmd.AddCommand(keys.JunctionKeyImportCmd)
 	command.ProverGenCMD.AddCommand(zkpCmd.V1ZKP)
 	command.ProverGenCMD.AddCommand(zkpCmd.V1ZKPWasm)
+	command.ProverGenCMD.AddCommand(zkpCmd.V1ZKPSvm)
+	command.LedgerCmd.AddCommand(command.LedgerSeedCmd)
+	command.SnapshotCmd.AddCommand(command.SnapshotExportCmd)
+	command.SnapshotCmd.AddCommand(command.SnapshotImportCmd)
+	command.DbCmd.AddCommand(command.DbCheckCmd)
 
 	// Define flags for JunctionKeyGenCmd
 	keys.JunctionKeyGenCmd.Flags().String("accountName", "", "Account Name")
@@ -71,6 +87,13 @@ func main() {
 	command.InitCmd.Flags().String("daKey", "", "DA Key for the Tracks")
 	command.InitCmd.Flags().String("stationRpc", "", "Station RPC for the Tracks")
 	command.InitCmd.Flags().String("stationAPI", "", "Station API for the Tracks")
+	command.InitCmd.Flags().StringSlice("stationRpcs", []string{}, "Additional Station RPC endpoints to fail over to")
+	command.InitCmd.Flags().StringSlice("stationAPIs", []string{}, "Additional Station API endpoints to fail over to")
+	command.InitCmd.Flags().String("finality", "", "Station finality policy for the Tracks (latest | confirmations | safe | finalized | committed), defaults per station type; SVM stations only support finalized")
+	command.InitCmd.Flags().Int("confirmations", 0, "Confirmations before a station block is final, used with --finality confirmations")
+	command.InitCmd.Flags().Duration("maxPodInterval", config.DefaultStationConfig().MaxPodInterval, "Time after which a pod with fewer than the pod size transactions is sealed, 0 waits for full pods")
+	command.InitCmd.Flags().Int("provingQueue", config.DefaultStationConfig().ProvingQueue, "Number of pods proven ahead while the previous pod is settled on junction")
+	command.InitCmd.Flags().String("dbBackend", store.BackendGoLevelDB, "Database backend for the Tracks (goleveldb | pebbledb | memdb)")
 	command.InitCmd.MarkFlagRequired("moniker")
 	command.InitCmd.MarkFlagRequired("daRpc")
 	command.InitCmd.MarkFlagRequired("daKey")
@@ -78,6 +101,23 @@ func main() {
 	command.InitCmd.MarkFlagRequired("stationRpc")
 	command.InitCmd.MarkFlagRequired("stationAPI")
 
+	command.LedgerSeedCmd.Flags().String("snapshot", "", "Path of the JSON account snapshot to seed the ledger from")
+	command.LedgerSeedCmd.MarkFlagRequired("snapshot")
+
+	command.MigrateCmd.Flags().Bool("dry-run", false, "List the pending migrations without applying them")
+
+	command.SnapshotExportCmd.Flags().Int("height", 0, "Saved pod to export up to, defaults to the latest saved pod")
+	command.SnapshotExportCmd.Flags().String("output", "", "Path of the snapshot archive to write")
+	command.SnapshotExportCmd.MarkFlagRequired("output")
+	command.SnapshotImportCmd.Flags().String("input", "", "Path of the snapshot archive to import")
+	command.SnapshotImportCmd.MarkFlagRequired("input")
+
+	command.DbCheckCmd.Flags().Bool("repair", false, "Set counters that disagree with the database contents")
+
+	zkpCmd.V1ZKP.Flags().Int("podSize", config.DefaultPodSize, "Transactions per pod the keys are generated for, defaults to the station's pod size")
+	zkpCmd.V1ZKPWasm.Flags().Int("podSize", config.DefaultPodSize, "Transactions per pod the keys are generated for, defaults to the station's pod size")
+	zkpCmd.V1ZKPSvm.Flags().Int("podSize", config.DefaultPodSize, "Transactions per pod the keys are generated for, defaults to the station's pod size")
+
 	// Define flags for CreateStation
 	command.CreateStation.Flags().String("info", "", "Station information")
 	command.CreateStation.Flags().String("accountName", "", "Station Account Name")
@@ -85,6 +125,7 @@ func main() {
 	command.CreateStation.Flags().String("jsonRPC", "", "Station JSON RPC")
 	command.CreateStation.Flags().StringSlice("tracks", []string{}, "tracks array for this station")
 	command.CreateStation.Flags().StringSlice("bootstrapNode", []string{}, "Bootstrap Node for the Tracks")
+	command.CreateStation.Flags().Int("podSize", config.DefaultPodSize, "Transactions per pod, keys for it must be generated with tracks prover first")
 
 	command.CreateStation.MarkFlagRequired("info")
 	command.CreateStation.MarkFlagRequired("accountName")
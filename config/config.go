@@ -9,7 +9,8 @@ import (
 )
 
 const (
-	PODSize                       = 25 // P0D Size
+	DefaultPodSize                = 25 // pod size of stations that did not choose one at create-station
+	DefaultProvingQueue           = 2  // pods proven ahead for configs that do not set ProvingQueue
 	defaultMoniker                = "tracks"
 	DefaultTracksDir              = ".tracks"
 	DefaultConfigDir              = "config"
@@ -227,21 +228,101 @@ func DefaultDAConfig() *DAConfig {
 	}
 }
 
+// Finality policies a station can be indexed with. A block is handed to the pod generator
+// only once it is final under the station's policy.
+const (
+	// FinalityLatest treats every block at the station head as final.
+	FinalityLatest = "latest"
+	// FinalityConfirmations treats a block as final once Confirmations blocks are built on top of it.
+	FinalityConfirmations = "confirmations"
+	// FinalitySafe follows the EVM "safe" block tag.
+	FinalitySafe = "safe"
+	// FinalityFinalized follows the EVM "finalized" block tag or the Solana "finalized" commitment.
+	FinalityFinalized = "finalized"
+	// FinalityCommitted follows the Tendermint committed height.
+	FinalityCommitted = "committed"
+)
+
 type StationConfig struct {
 	StationType string
 	StationRPC  string
 	StationAPI  string
+
+	// StationRPCs and StationAPIs are additional endpoints of the same station that calls fail
+	// over to.
+	StationRPCs []string
+	StationAPIs []string
+
+	// Finality is the finality policy of the station, see the Finality* constants. An empty
+	// policy selects the default of the station type.
+	Finality      string
+	Confirmations int
+
+	// SyncWorkers is the number of concurrent station requests while catching up with the
+	// station head, SyncBatchSize the number of blocks fetched per request.
+	SyncWorkers   int
+	SyncBatchSize int
+
+	// PodSize is the number of transactions in a full pod, chosen at create-station. The pod
+	// circuit and its keys are built for it, and it must not change once pods are produced.
+	PodSize int
+
+	// MaxPodInterval is how much station block time a pod waits for PodSize transactions, from
+	// the block of its first transaction, before it is sealed with the transactions it has. Zero
+	// waits for a full pod.
+	MaxPodInterval time.Duration
+
+	// ProvingQueue is the number of proven pods that wait while the pod before them takes its
+	// junction steps.
+	ProvingQueue int
 }
 
 // DefaultStationConfig returns a default configuration for the station.
 func DefaultStationConfig() *StationConfig {
 	return &StationConfig{
-		StationType: "",
-		StationRPC:  "",
-		StationAPI:  "",
+		StationType:    "",
+		StationRPC:     "",
+		StationAPI:     "",
+		StationRPCs:    []string{},
+		StationAPIs:    []string{},
+		Finality:       "",
+		Confirmations:  0,
+		PodSize:        DefaultPodSize,
+		SyncWorkers:    4,
+		SyncBatchSize:  10,
+		MaxPodInterval: 10 * time.Minute,
+		ProvingQueue:   DefaultProvingQueue,
 	}
 }
 
+// GetPodSize returns PodSize, or DefaultPodSize for a config written before the pod size was
+// configurable.
+func (c *StationConfig) GetPodSize() int {
+	if c.PodSize <= 0 {
+		return DefaultPodSize
+	}
+	return c.PodSize
+}
+
+// GetProvingQueue returns ProvingQueue, or DefaultProvingQueue for a config written before pods
+// were proven ahead.
+func (c *StationConfig) GetProvingQueue() int {
+	if c.ProvingQueue <= 0 {
+		return DefaultProvingQueue
+	}
+	return c.ProvingQueue
+}
+
+// RPCEndpoints returns StationRPC followed by the additional StationRPCs.
+func (c *StationConfig) RPCEndpoints() []string {
+	return append([]string{c.StationRPC}, c.StationRPCs...)
+}
+
+// APIEndpoints returns StationAPI followed by the additional StationAPIs.
+func (c *StationConfig) APIEndpoints() []string {
+	return append([]string{c.StationAPI}, c.StationAPIs...)
+}
+
 type JunctionConfig struct {
 	JunctionRPC   string
 	JunctionAPI   string
@@ -0,0 +1,54 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// HomeEnv is the environment variable that sets the tracks home directory when --home is not given.
+const HomeEnv = "TRACKS_HOME"
+
+// homeDir is the directory set with the global --home flag.
+var homeDir string
+
+// SetHomeDir sets the tracks home directory for the rest of the process. An empty dir falls back
+// to TRACKS_HOME and then to ~/.tracks.
+func SetHomeDir(dir string) {
+	homeDir = dir
+}
+
+// HomeDir returns the tracks home directory: the --home flag, else TRACKS_HOME, else ~/.tracks.
+// Every config file, key and database path is resolved under it.
+func HomeDir() (string, error) {
+	return RootDir("")
+}
+
+// RootDir returns the directory relative paths of the config resolve against: the --home flag,
+// else TRACKS_HOME, else the configured root_dir, else ~/.tracks. root_dir only records where the
+// home was at init time, so a home moved with --home or TRACKS_HOME still finds its databases.
+func RootDir(configured string) (string, error) {
+	dir := homeDir
+	if dir == "" {
+		dir = os.Getenv(HomeEnv)
+	}
+	if dir == "" {
+		dir = configured
+	}
+	if dir != "" {
+		return filepath.Abs(dir)
+	}
+	userHome, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(userHome, DefaultTracksDir), nil
+}
+
+// ConfigDirPath returns the config directory under HomeDir.
+func ConfigDirPath() (string, error) {
+	home, err := HomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, DefaultConfigDir), nil
+}
@@ -0,0 +1,34 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestRootDir(t *testing.T) {
+	tests := []struct {
+		name       string
+		flag       string
+		env        string
+		configured string
+		want       string
+	}{
+		{"flag", "/flag", "/env", "/configured", "/flag"},
+		{"environment", "", "/env", "/configured", "/env"},
+		{"root_dir", "", "", "/configured", "/configured"},
+		{"default", "", "", "", filepath.Join("/user", DefaultTracksDir)},
+	}
+	t.Setenv("HOME", "/user")
+	for _, tt := range tests {
+		SetHomeDir(tt.flag)
+		t.Setenv(HomeEnv, tt.env)
+		got, err := RootDir(tt.configured)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: RootDir = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+	SetHomeDir("")
+}
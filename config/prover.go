@@ -0,0 +1,18 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// ProverKeyPaths returns the proving and verification key files of the pod circuit for pods of
+// podSize transactions. Each pod size has its own circuit and therefore its own keys.
+func ProverKeyPaths(podSize int) (provingKey string, verificationKey string, err error) {
+	configDir, err := ConfigDirPath()
+	if err != nil {
+		return "", "", err
+	}
+	provingKey = filepath.Join(configDir, fmt.Sprintf("provingKey_%d.txt", podSize))
+	verificationKey = filepath.Join(configDir, fmt.Sprintf("verificationKey_%d.json", podSize))
+	return provingKey, verificationKey, nil
+}
@@ -0,0 +1,26 @@
+package config
+
+import "testing"
+
+func TestProverKeyPaths(t *testing.T) {
+	SetHomeDir("/tracks")
+	defer SetHomeDir("")
+
+	tests := []struct {
+		podSize             int
+		wantProvingKey      string
+		wantVerificationKey string
+	}{
+		{DefaultPodSize, "/tracks/config/provingKey_25.txt", "/tracks/config/verificationKey_25.json"},
+		{10, "/tracks/config/provingKey_10.txt", "/tracks/config/verificationKey_10.json"},
+	}
+	for _, tt := range tests {
+		provingKey, verificationKey, err := ProverKeyPaths(tt.podSize)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if provingKey != tt.wantProvingKey || verificationKey != tt.wantVerificationKey {
+			t.Errorf("keys of pod size %d are %s and %s, want %s and %s", tt.podSize, provingKey, verificationKey, tt.wantProvingKey, tt.wantVerificationKey)
+		}
+	}
+}
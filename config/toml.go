@@ -74,7 +74,7 @@ func WriteConfigFile(configFilePath string, config *Config) {
 */
 
 const defaultConfigTemplate = `[base_config]
-db_backend="goleveldb"
+db_backend="{{ .BaseConfig.DBBackend }}"
 db_path="{{ .BaseConfig.DBPath }}"
 filter_peers={{ .BaseConfig.FilterPeers }}
 moniker="{{ .BaseConfig.Moniker }}"
@@ -157,5 +157,14 @@ temp_dir = "{{ .StateSync.TempDir }}"
 stationAPI = "{{ .Station.StationAPI }}"
 stationRPC = "{{ .Station.StationRPC }}"
 stationType = "{{ .Station.StationType }}"
+stationRPCs = [{{ range .Station.StationRPCs }} "{{ . }}", {{ end }}]
+stationAPIs = [{{ range .Station.StationAPIs }} "{{ . }}", {{ end }}]
+finality = "{{ .Station.Finality }}"
+confirmations = {{ .Station.Confirmations }}
+syncWorkers = {{ .Station.SyncWorkers }}
+syncBatchSize = {{ .Station.SyncBatchSize }}
+podSize = {{ .Station.PodSize }}
+maxPodInterval = "{{ .Station.MaxPodInterval }}"
+provingQueue = {{ .Station.ProvingQueue }}
 
 `
@@ -4,12 +4,12 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"github.com/airchains-network/tracks/store"
 	"github.com/airchains-network/tracks/types"
-	"github.com/syndtr/goleveldb/leveldb"
 )
 
-// MockDA is a function that mocks the functionality of storing data in a mock database (leveldb). It takes the following parameters:
-// - mdb: a pointer to a leveldb.DB instance representing the mock database
+// MockDA is a function that mocks the functionality of storing data in a mock database. It takes the following parameters:
+// - mdb: the store representing the mock database
 // - daData: a byte slice containing the data to be stored
 // - batchNumber: an integer representing the batch number
 //
@@ -22,7 +22,7 @@ import (
 // 6. Stores the byteMockData in the mock database using the dbName as the key.
 // 7. Returns the dbName and nil error if the operation is successful.
 // 8. Otherwise, returns an empty string and an error message indicating the failure.
-func MockDA(mdb *leveldb.DB, daData []byte, batchNumber int) (string, error) {
+func MockDA(mdb store.Store, daData []byte, batchNumber int) (string, error) {
 
 	hash := sha256.Sum256(daData)
 	hashString := hex.EncodeToString(hash[:])
@@ -36,12 +36,12 @@ func MockDA(mdb *leveldb.DB, daData []byte, batchNumber int) (string, error) {
 	byteMockData := []byte(fmt.Sprintf("%v", mockData))
 
 	dbName := fmt.Sprintf("mockda-%d", batchNumber)
-	dbErr := mdb.Put([]byte(dbName), byteMockData, nil)
+	dbErr := mdb.Put([]byte(dbName), byteMockData)
 	if dbErr != nil {
 		return "", fmt.Errorf("error putting data into mock db: %v", dbErr)
 	}
 
-	_ = fmt.Sprintf("da_id : %d, commitment : %s", dbName, hashString)
+	_ = fmt.Sprintf("da_id : %s, commitment : %s", dbName, hashString)
 
 	return dbName, nil
 }
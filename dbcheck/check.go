@@ -0,0 +1,322 @@
+// Package dbcheck verifies that the counters of a track data directory agree with the records
+// they count and that the saved pods form an unbroken pod hash chain.
+package dbcheck
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/airchains-network/tracks/blocksync"
+	"github.com/airchains-network/tracks/config"
+	"github.com/airchains-network/tracks/node/shared"
+	"github.com/airchains-network/tracks/store"
+	"github.com/airchains-network/tracks/types"
+)
+
+// Issue is one inconsistency found by Check. Issues with a Key are repaired by writing Value to
+// Key; the others have to be resolved by hand, e.g. by rolling back or restoring a snapshot.
+type Issue struct {
+	DB      string
+	Message string
+	Warning bool
+
+	Key   string
+	Value string
+	db    store.Store
+}
+
+// Repairable reports whether Repair can resolve the issue.
+func (i Issue) Repairable() bool {
+	return i.Key != ""
+}
+
+func (i Issue) String() string {
+	level := "error"
+	if i.Warning {
+		level = "warning"
+	}
+	s := fmt.Sprintf("%s: %s db: %s", level, i.DB, i.Message)
+	if i.Repairable() {
+		s += fmt.Sprintf(" (repair: set %s to %s)", i.Key, i.Value)
+	}
+	return s
+}
+
+// Report lists the issues found by Check and what it checked.
+type Report struct {
+	Issues []Issue
+
+	TxnCount   int
+	BlockCount int
+	BatchCount int
+	Pods       int
+}
+
+// OK reports whether the data directory has no errors. Warnings do not count.
+func (r *Report) OK() bool {
+	for _, issue := range r.Issues {
+		if !issue.Warning {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *Report) add(db string, format string, args ...interface{}) {
+	r.Issues = append(r.Issues, Issue{DB: db, Message: fmt.Sprintf(format, args...)})
+}
+
+func (r *Report) warn(db string, format string, args ...interface{}) {
+	r.Issues = append(r.Issues, Issue{DB: db, Message: fmt.Sprintf(format, args...), Warning: true})
+}
+
+func (r *Report) repair(db string, s store.Store, key string, value int, format string, args ...interface{}) {
+	r.Issues = append(r.Issues, Issue{DB: db, Message: fmt.Sprintf(format, args...), Key: key, Value: strconv.Itoa(value), db: s})
+}
+
+// Check walks the databases opened by blocksync.InitDb. It never writes; pass the report to
+// Repair to fix the counters.
+func Check(conf *config.Config) (*Report, error) {
+	r := &Report{}
+	indexer, err := blocksync.DescribingIndexer(conf.Station.StationType)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkTxns(r); err != nil {
+		return nil, err
+	}
+	if err = checkBlocks(r, indexer); err != nil {
+		return nil, err
+	}
+	if err = checkPods(r, conf.Station.GetPodSize()); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Repair writes the counter values of every repairable issue and returns how many it wrote.
+func Repair(r *Report) (int, error) {
+	repaired := 0
+	for _, issue := range r.Issues {
+		if !issue.Repairable() {
+			continue
+		}
+		if err := issue.db.Put([]byte(issue.Key), []byte(issue.Value)); err != nil {
+			return repaired, fmt.Errorf("setting %s: %w", issue.Key, err)
+		}
+		repaired++
+	}
+	return repaired, nil
+}
+
+// readCounter returns the counter stored under key, with ok false if it is missing or not a number.
+func readCounter(db store.Store, key string) (value int, ok bool, err error) {
+	data, err := db.Get([]byte(key))
+	if err == store.ErrNotFound {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	value, err = strconv.Atoi(strings.TrimSpace(string(data)))
+	return value, err == nil, nil
+}
+
+// checkTxns checks txnCount and finalizedTxnCount against the txns-N records and the indexer
+// progress, which is written in the same batch as the transactions and is trusted over txnCount.
+func checkTxns(r *Report) error {
+	ldt := blocksync.GetTxDbInstance()
+	txnCount, ok, err := readCounter(ldt, "txnCount")
+	if err != nil {
+		return err
+	}
+
+	contiguous, highest := 0, 0
+	iter := ldt.NewIterator(store.Prefix([]byte("txns-")))
+	present := make(map[int]bool)
+	for iter.Next() {
+		index, err := strconv.Atoi(strings.TrimPrefix(string(iter.Key()), "txns-"))
+		if err != nil {
+			continue
+		}
+		present[index] = true
+		highest = max(highest, index)
+	}
+	iter.Release()
+	if err = iter.Error(); err != nil {
+		return err
+	}
+	for present[contiguous+1] {
+		contiguous++
+	}
+
+	progress, err := blocksync.GetProgress(ldt)
+	if err != nil {
+		return err
+	}
+	expected := contiguous
+	if progress != nil {
+		expected = progress.TxnIndex
+		if progress.TxnIndex > contiguous {
+			r.add("tx", "indexer progress is at txn %d but txns-%d is missing", progress.TxnIndex, contiguous+1)
+		}
+	}
+	switch {
+	case !ok:
+		r.repair("tx", ldt, "txnCount", expected, "txnCount is missing or invalid")
+	case txnCount != expected:
+		r.repair("tx", ldt, "txnCount", expected, "txnCount is %d but %d transactions are indexed", txnCount, expected)
+	}
+	if highest > expected {
+		r.warn("tx", "txns-N records up to txn %d are past txn %d, they were left by an interrupted write or a reorganisation and will be overwritten", highest, expected)
+	}
+	r.TxnCount = expected
+
+	finalized, ok, err := readCounter(ldt, "finalizedTxnCount")
+	if err != nil {
+		return err
+	}
+	if ok && finalized > expected {
+		r.repair("tx", ldt, "finalizedTxnCount", expected, "finalizedTxnCount %d is above the %d indexed transactions", finalized, expected)
+	}
+	return nil
+}
+
+// checkBlocks checks blockCount against the indexer progress and that the last indexed block is
+// stored.
+func checkBlocks(r *Report, indexer blocksync.StationIndexer) error {
+	ldb, ldt := blocksync.GetBlockDbInstance(), blocksync.GetTxDbInstance()
+	blockCount, ok, err := readCounter(ldb, "blockCount")
+	if err != nil {
+		return err
+	}
+	r.BlockCount = blockCount
+
+	progress, err := blocksync.GetProgress(ldt)
+	if err != nil || progress == nil {
+		if progress == nil && ok && blockCount > 0 {
+			r.warn("blocks", "no indexer progress recorded, blockCount %d cannot be verified", blockCount)
+		}
+		return err
+	}
+	expected := progress.Height + 1
+	switch {
+	case !ok:
+		r.repair("blocks", ldb, "blockCount", expected, "blockCount is missing or invalid")
+	case blockCount != expected:
+		r.repair("blocks", ldb, "blockCount", expected, "blockCount is %d but the indexer progress is at block %d", blockCount, progress.Height)
+	}
+	r.BlockCount = expected
+
+	meta, err := blocksync.GetBlockMeta(ldt, progress.Height)
+	if err != nil {
+		return err
+	}
+	if meta == nil {
+		r.add("tx", "block %d of the indexer progress has no metadata", progress.Height)
+	} else if meta.Hash != progress.Hash || meta.TxnEnd != progress.TxnIndex {
+		r.add("tx", "metadata of block %d does not match the indexer progress", progress.Height)
+	}
+	if meta != nil && !meta.Skipped {
+		if ok, err := ldb.Has([]byte(indexer.BlockKey(progress.Height))); err != nil {
+			return err
+		} else if !ok {
+			r.add("blocks", "block %d of the indexer progress is not stored", progress.Height)
+		}
+	}
+	return nil
+}
+
+// checkPods verifies the pod hash chain of the saved pods and checks batchCount and
+// batchStartIndex against the last saved pod and the pod state.
+func checkPods(r *Report, podSize int) error {
+	static, pods, state := blocksync.GetStaticDbInstance(), blocksync.GetBatchesDbInstance(), blocksync.GetStateDbInstance()
+	batchCount, batchCountOK, err := readCounter(static, "batchCount")
+	if err != nil {
+		return err
+	}
+	batchStartIndex, batchStartIndexOK, err := readCounter(static, "batchStartIndex")
+	if err != nil {
+		return err
+	}
+	r.BatchCount = batchCount
+
+	data, err := state.Get([]byte("podState"))
+	if err == store.ErrNotFound {
+		r.add("state", "podState is missing")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	podState, err := types.UnmarshalPodState(data)
+	if err != nil {
+		r.add("state", "podState is invalid: %s", err)
+		return nil
+	}
+
+	// walk the chain up to the pod in the pod state; pods above it were left by a rollback
+	var last *shared.PodState
+	lastEnd := 0
+	for number := 1; uint64(number) <= podState.LatestPodHeight; number++ {
+		data, err := pods.Get([]byte(fmt.Sprintf("pod-%d", number)))
+		if err == store.ErrNotFound {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		pod, err := types.UnmarshalPodState(data)
+		if err != nil {
+			r.add("batches", "pod-%d is invalid: %s", number, err)
+			break
+		}
+		if err = shared.VerifyPodRecord(last, pod, number); err != nil {
+			r.add("batches", "%s", err)
+			break
+		}
+		end := shared.PodTxnEnd(pod.Batch, number, podSize)
+		if end <= lastEnd {
+			r.add("batches", "pod-%d ends at txn %d, not after pod-%d at txn %d", number, end, number-1, lastEnd)
+			break
+		}
+		last, lastEnd = pod, end
+	}
+	saved := 0
+	if last != nil {
+		saved = int(last.LatestPodHeight)
+	}
+	r.Pods = saved
+
+	if stray, err := pods.Has([]byte(fmt.Sprintf("pod-%d", podState.LatestPodHeight+1))); err != nil {
+		return err
+	} else if stray {
+		r.warn("batches", "pod-%d and above are past the pod state, they were left by a rollback", podState.LatestPodHeight+1)
+	}
+
+	switch {
+	case podState.LatestPodHeight != uint64(saved) && podState.LatestPodHeight != uint64(saved+1):
+		r.add("state", "podState is at pod %d but the pod chain ends at pod %d", podState.LatestPodHeight, saved)
+	case podState.LatestPodHeight == uint64(saved+1) && last != nil && podState.PreviousPodHash != nil &&
+		string(podState.PreviousPodHash) != string(last.LatestPodHash):
+		r.add("state", "podState previous pod hash does not match pod-%d", saved)
+	}
+
+	switch {
+	case !batchCountOK:
+		r.repair("static", static, "batchCount", saved, "batchCount is missing or invalid")
+	case batchCount != saved:
+		r.repair("static", static, "batchCount", saved, "batchCount is %d but the pod chain ends at pod %d", batchCount, saved)
+	}
+	switch {
+	case !batchStartIndexOK:
+		r.repair("static", static, "batchStartIndex", lastEnd, "batchStartIndex is missing or invalid")
+	case batchStartIndex != lastEnd:
+		r.repair("static", static, "batchStartIndex", lastEnd, "batchStartIndex is %d but pod %d ends at txn %d", batchStartIndex, saved, lastEnd)
+	}
+	if lastEnd > r.TxnCount {
+		r.add("tx", "pod %d ends at txn %d but only %d transactions are indexed", saved, lastEnd, r.TxnCount)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package dbcheck_test
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/airchains-network/tracks/blocksync"
+	"github.com/airchains-network/tracks/config"
+	"github.com/airchains-network/tracks/dbcheck"
+	"github.com/airchains-network/tracks/node/shared"
+	"github.com/airchains-network/tracks/store"
+	"github.com/airchains-network/tracks/types"
+)
+
+func put(t *testing.T, db store.Store, key string, value string) {
+	t.Helper()
+	if err := db.Put([]byte(key), []byte(value)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func putPod(t *testing.T, number int, prev []byte) *shared.PodState {
+	t.Helper()
+	pod := &shared.PodState{
+		LatestPodHeight:     uint64(number),
+		LatestTxState:       shared.TxStatePreInit,
+		LatestPodHash:       []byte(fmt.Sprintf("root-%d", number)),
+		PreviousPodHash:     prev,
+		LatestPodProof:      []byte("proof"),
+		LatestPublicWitness: []byte("witness"),
+		Batch:               &types.BatchStruct{TxnEndIndex: 2 * number},
+	}
+	pod.TracksAppHash = shared.PodHash(pod.LatestPublicWitness, pod.LatestPodProof, pod.LatestPodHash, []byte(strconv.Itoa(number-1)))
+	put(t, blocksync.GetBatchesDbInstance(), fmt.Sprintf("pod-%d", number), string(types.MarshalPodState(pod)))
+	return pod
+}
+
+func TestCheckAndRepairCounters(t *testing.T) {
+	conf := config.DefaultConfig()
+	conf.BaseConfig.DBBackend = store.BackendMemory
+	conf.Station.StationType = "evm"
+	if !blocksync.InitDb(conf.BaseConfig) {
+		t.Fatal("InitDb failed")
+	}
+
+	ldt := blocksync.GetTxDbInstance()
+	for i := 1; i <= 5; i++ {
+		put(t, ldt, fmt.Sprintf("txns-%d", i), "{}")
+	}
+	put(t, ldt, "txnCount", "3")
+	pod1 := putPod(t, 1, nil)
+	pod2 := putPod(t, 2, pod1.LatestPodHash)
+	put(t, blocksync.GetStateDbInstance(), "podState", string(types.MarshalPodState(pod2)))
+	put(t, blocksync.GetStaticDbInstance(), "batchCount", "1")
+	put(t, blocksync.GetStaticDbInstance(), "batchStartIndex", "2")
+
+	report, err := dbcheck.Check(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.OK() {
+		t.Fatal("Check found no errors")
+	}
+	for _, issue := range report.Issues {
+		if !issue.Warning && !issue.Repairable() {
+			t.Errorf("unexpected unrepairable issue: %s", issue)
+		}
+	}
+	if _, err = dbcheck.Repair(report); err != nil {
+		t.Fatal(err)
+	}
+
+	if report, err = dbcheck.Check(conf); err != nil {
+		t.Fatal(err)
+	}
+	if !report.OK() {
+		t.Errorf("issues after repair: %v", report.Issues)
+	}
+	if report.TxnCount != 5 || report.Pods != 2 {
+		t.Errorf("checked %d transactions and %d pods, want 5 and 2", report.TxnCount, report.Pods)
+	}
+
+	// a pod that does not follow the one before it breaks the chain
+	putPod(t, 2, []byte("other root"))
+	if report, err = dbcheck.Check(conf); err != nil {
+		t.Fatal(err)
+	}
+	if report.OK() {
+		t.Error("Check accepted a broken pod hash chain")
+	}
+}
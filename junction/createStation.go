@@ -159,13 +159,13 @@ func CreateStation(extraArg junctionTypes.StationArg, stationId string, stationI
 	}
 	logs.Log.Info("Successfully Created VRF public and private Keys")
 
-	homeDir, err := os.UserHomeDir()
+	configDir, err := config.ConfigDirPath()
 	if err != nil {
 		logs.Log.Error("Error in getting home dir path: " + err.Error())
 		return false
 	}
 
-	ConfigFilePath := filepath.Join(homeDir, config.DefaultTracksDir, config.DefaultConfigDir, config.DefaultConfigFileName)
+	ConfigFilePath := filepath.Join(configDir, config.DefaultConfigFileName)
 	bytes, err := os.ReadFile(ConfigFilePath)
 	if err != nil {
 		logs.Log.Error("Error reading sequencer.toml")
@@ -273,6 +273,7 @@ func CreateStation(extraArg junctionTypes.StationArg, stationId string, stationI
 	conf.Junction.AccountPath = accountPath
 	conf.Junction.AccountName = accountName
 	conf.Junction.Tracks = tracks
+	conf.Station.PodSize = stationInfo.PodSize
 
 	// Marshal the struct to TOML
 	f, err := os.Create(ConfigFilePath)
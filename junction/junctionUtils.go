@@ -41,13 +41,13 @@ func CreateGenesisJson(stationInfo types.StationInfo, verificationKey groth16.Ve
 		return false
 	}
 
-	homeDir, err := os.UserHomeDir()
+	configDir, err := config.ConfigDirPath()
 	if err != nil {
 		logs.Log.Error("Error in getting home dir path: " + err.Error())
 		return false
 	}
 
-	GenesisFilePath := filepath.Join(homeDir, config.DefaultGenesisFilePath)
+	GenesisFilePath := filepath.Join(configDir, config.DefaultGenesisFileName)
 
 	// Write the JSON data to a file
 	err = os.WriteFile(GenesisFilePath, jsonBytes, 0644)
@@ -75,12 +75,11 @@ func NewKeyPair() (privateKeyX kyber.Scalar, publicKeyX kyber.Point) {
 }
 
 func SetVRFPubKey(pubKey string) {
-	homeDir, err := os.UserHomeDir()
+	ConfigFilePath, err := config.ConfigDirPath()
 	if err != nil {
 		logs.Log.Error("Error in getting home dir path: " + err.Error())
 	}
 
-	ConfigFilePath := filepath.Join(homeDir, config.DefaultTracksDir, config.DefaultConfigDir)
 	VRFPubKeyPath := filepath.Join(ConfigFilePath, "vrfPubKey.txt")
 	file, err := os.Create(VRFPubKeyPath)
 	if err != nil {
@@ -111,11 +110,11 @@ func SetVRFPubKey(pubKey string) {
 }
 
 func SetVRFPrivKey(privateKey string) {
-	homeDir, err := os.UserHomeDir()
+	ConfigFilePath, err := config.ConfigDirPath()
 	if err != nil {
 		logs.Log.Error("Error in getting home dir path: " + err.Error())
 	}
-	ConfigFilePath := filepath.Join(homeDir, config.DefaultTracksDir, config.DefaultConfigDir)
+
 	VRFPrivKeyPath := filepath.Join(ConfigFilePath, "vrfPrivKey.txt")
 	file, err := os.Create(VRFPrivKeyPath)
 	if err != nil {
@@ -146,12 +145,11 @@ func SetVRFPrivKey(privateKey string) {
 }
 
 func GetVRFPrivateKey() (privateKey string) {
-	homeDir, err := os.UserHomeDir()
+	ConfigFilePath, err := config.ConfigDirPath()
 	if err != nil {
 		logs.Log.Error("Error in getting home dir path: " + err.Error())
 	}
 
-	ConfigFilePath := filepath.Join(homeDir, config.DefaultTracksDir, config.DefaultConfigDir)
 	VRFPrivKeyPath := filepath.Join(ConfigFilePath, "vrfPrivKey.txt")
 	file, err := os.Open(VRFPrivKeyPath)
 	if err != nil {
@@ -177,12 +175,11 @@ func GetVRFPrivateKey() (privateKey string) {
 
 func GetVRFPubKey() (pubKey string) {
 
-	homeDir, err := os.UserHomeDir()
+	ConfigFilePath, err := config.ConfigDirPath()
 	if err != nil {
 		logs.Log.Error("Error in getting home dir path: " + err.Error())
 	}
 
-	ConfigFilePath := filepath.Join(homeDir, config.DefaultTracksDir, config.DefaultConfigDir)
 	VRFPubKeyPath := filepath.Join(ConfigFilePath, "vrfPubKey.txt")
 
 	// get private Key
@@ -8,74 +8,81 @@ import (
 	"github.com/airchains-network/tracks/node/shared"
 	"github.com/airchains-network/tracks/p2p"
 	"github.com/airchains-network/tracks/rpc"
-	"github.com/ethereum/go-ethereum/ethclient"
-	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/airchains-network/tracks/store"
+	"os"
+	"os/signal"
 	"sync"
+	"syscall"
 	"time"
 )
 
+// Start runs the track until it receives SIGINT or SIGTERM. On shutdown it waits for the
+// indexer to finish the block it is writing, for pod generation to finish its junction step and
+// for the RPC server to stop, then closes the databases.
 func Start() {
-	var wg1 sync.WaitGroup
-	wg1.Add(2)
-	go configureP2P(&wg1)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	go func() {
-		time.Sleep(5 * time.Second)
-		ticker := time.NewTicker(4 * time.Second) // adjust the check frequency as needed
-		defer ticker.Stop()
-		for {
-			select {
-			case <-ticker.C:
-				if p2p.PeerConnectionStatus(p2p.Node) {
-					beginDBIndexingOperations(&wg1)
-					return
-				}
+	go configureP2P(ctx)
+
+	select {
+	case <-ctx.Done():
+		return
+	case <-time.After(5 * time.Second):
+	}
+	ticker := time.NewTicker(4 * time.Second) // adjust the check frequency as needed
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if p2p.PeerConnectionStatus(p2p.Node) {
+				beginDBIndexingOperations(ctx)
+				return
 			}
 		}
-	}()
-	wg1.Wait()
+	}
 }
 
-func configureP2P(wg *sync.WaitGroup) {
-	defer wg.Done()
-	p2p.P2PConfiguration()
+func configureP2P(ctx context.Context) {
+	p2p.P2PConfiguration(ctx)
 }
 
-func beginDBIndexingOperations(wg *sync.WaitGroup) {
-	defer wg.Done()
+func beginDBIndexingOperations(ctx context.Context) {
 	connection := shared.Node.NodeConnections
 	staticDB := connection.GetStaticDatabaseConnection()
 	blockDB := connection.GetBlockDatabaseConnection()
 	txnDB := connection.GetTxnDatabaseConnection()
 	shared.CheckAndInitializeDBCounters(staticDB)
 	latestBlock := shared.GetLatestBlock(blockDB)
-	baseConfig, err := shared.LoadConfig()
 
-	client, err := ethclient.Dial(baseConfig.Station.StationRPC) // viper.GetString("station.stationRPC"))
-	if err != nil {
-		logs.Log.Error("Error in connecting to the network")
-		return
-	}
 	initializeCounter(staticDB, "batchCount")
 	initializeCounter(staticDB, "batchStartIndex")
 
-	var ctx context.Context
-	ctx = context.Background()
-	var wgnm *sync.WaitGroup
-	wgnm = &sync.WaitGroup{}
-	//wgnm.Add(1)
-	wgnm.Add(3)
+	var indexerWg sync.WaitGroup
+	indexerWg.Add(1)
+	go blocksync.StartIndexer(&indexerWg, ctx, blockDB, txnDB, latestBlock, shared.ProvenTxnCount)
 
-	go blocksync.StartIndexer(wgnm, client, ctx, blockDB, txnDB, latestBlock)
-	go p2p.BatchGeneration(wgnm)
-	go rpc.StartRPC(wgnm)
+	wgnm := &sync.WaitGroup{}
+	wgnm.Add(2)
+	go p2p.BatchGeneration(ctx, wgnm)
+	go rpc.StartRPC(ctx, wgnm)
+
+	<-ctx.Done()
+	logs.Log.Info("Shutting down, waiting for the indexer to store the current block")
+	indexerWg.Wait()
+	logs.Log.Info("Waiting for pod generation and the RPC server to stop")
 	wgnm.Wait()
+	if err := blocksync.CloseDatabases(); err != nil {
+		logs.Log.Error(fmt.Sprintf("Error in closing the databases: %s", err.Error()))
+	}
 }
 
-func initializeCounter(staticDB *leveldb.DB, counterName string) {
-	_, err := staticDB.Get([]byte(counterName), nil)
+func initializeCounter(staticDB store.Store, counterName string) {
+	_, err := staticDB.Get([]byte(counterName))
 	if err != nil {
-		err = staticDB.Put([]byte(counterName), []byte("0"), nil)
+		err = staticDB.Put([]byte(counterName), []byte("0"))
 		if err != nil {
 			logs.Log.Error(fmt.Sprintf("Error in saving %s in static db: %s", counterName, err.Error()))
 		}
@@ -0,0 +1,318 @@
+package shared
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/airchains-network/tracks/config"
+	"github.com/airchains-network/tracks/store"
+	"github.com/airchains-network/tracks/types"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrPodTransition is returned by Fire for a transition the lifecycle does not declare.
+var ErrPodTransition = errors.New("pod transition is not declared")
+
+// PodTransition declares a step of the pod lifecycle from one tx state to the next.
+type PodTransition struct {
+	From string
+	To   string
+}
+
+// PodTransitions is the pod lifecycle. A pod is generated in TxStatePreInit and takes the
+// junction steps in order; verifying it saves it and returns the lifecycle to TxStatePreInit.
+var PodTransitions = []PodTransition{
+	{From: TxStatePreInit, To: TxStateInitVRF},
+	{From: TxStateInitVRF, To: TxStateVerifyVRF},
+	{From: TxStateVerifyVRF, To: TxStateSubmitPod},
+	{From: TxStateSubmitPod, To: TxStateVerifyPod},
+	{From: TxStateVerifyPod, To: TxStatePreInit},
+}
+
+// PodTransitionRecord is the journal entry of a transition, kept in the state database under
+// podTransition-<pod>-<seq>.
+type PodTransitionRecord struct {
+	Pod    uint64    `json:"pod"`
+	From   string    `json:"from"`
+	To     string    `json:"to"`
+	TxHash string    `json:"txHash,omitempty"`
+	Time   time.Time `json:"time"`
+}
+
+// PodStep is a transition being taken. Actions may change Pod and add writes to Batch; both are
+// committed with the transition in one journal write, or not at all if an action fails.
+type PodStep struct {
+	PodTransitionRecord
+	Pod   *PodState
+	Batch *store.MultiBatch
+}
+
+// PodAction is an entry or exit action of a tx state.
+type PodAction func(step *PodStep) error
+
+// PodLifecycle is the state machine of the pod being processed. Its state is the LatestTxState
+// of the podState record, so after a crash it resumes at the last transition that was written.
+type PodLifecycle struct {
+	mu      sync.Mutex
+	journal *store.Journal
+	state   store.Store
+	next    map[string]string
+	onEnter map[string][]PodAction
+	onExit  map[string][]PodAction
+
+	current string
+	height  uint64
+	seq     int
+}
+
+// NewPodLifecycle returns the lifecycle of the podState record in state, writing through
+// journal, which must include state as PodStoreState. Every state must have exactly one
+// declared successor.
+func NewPodLifecycle(journal *store.Journal, state store.Store, transitions []PodTransition) (*PodLifecycle, error) {
+	l := &PodLifecycle{
+		journal: journal,
+		state:   state,
+		next:    make(map[string]string, len(transitions)),
+		onEnter: make(map[string][]PodAction),
+		onExit:  make(map[string][]PodAction),
+		current: TxStatePreInit,
+	}
+	for _, t := range transitions {
+		if _, ok := l.next[t.From]; ok {
+			return nil, fmt.Errorf("pod lifecycle declares two transitions from %s", t.From)
+		}
+		l.next[t.From] = t.To
+	}
+	for from, to := range l.next {
+		if _, ok := l.next[to]; !ok {
+			return nil, fmt.Errorf("pod lifecycle declares no transition from %s, entered from %s", to, from)
+		}
+	}
+
+	data, err := state.Get([]byte("podState"))
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		return nil, err
+	}
+	if err == nil {
+		pod, err := types.UnmarshalPodState(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pod state: %w", err)
+		}
+		if pod.LatestTxState != "" {
+			l.current = pod.LatestTxState
+		}
+		l.height = pod.LatestPodHeight
+	}
+	if _, ok := l.next[l.current]; !ok {
+		return nil, fmt.Errorf("pod state is in undeclared tx state %q", l.current)
+	}
+	records, err := PodTransitionRecords(state, l.height)
+	if err != nil {
+		return nil, err
+	}
+	l.seq = len(records)
+	return l, nil
+}
+
+// OnEnter adds an action run when the lifecycle enters state.
+func (l *PodLifecycle) OnEnter(state string, action PodAction) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.onEnter[state] = append(l.onEnter[state], action)
+}
+
+// OnExit adds an action run when the lifecycle leaves state.
+func (l *PodLifecycle) OnExit(state string, action PodAction) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.onExit[state] = append(l.onExit[state], action)
+}
+
+// State returns the tx state of the last transition written.
+func (l *PodLifecycle) State() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.current
+}
+
+// Fire takes the declared transition from the current state to to, persisting pod with the
+// changes of the actions as the new podState record, which it returns. txHash is the junction
+// transaction that completed the step, if any. pod itself is not changed, so readers holding it
+// never see a half written record; the caller stores the returned state with SetPodState.
+func (l *PodLifecycle) Fire(pod *PodState, to string, txHash string) (*PodState, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.next[l.current] != to {
+		return nil, fmt.Errorf("%w: %s to %s", ErrPodTransition, l.current, to)
+	}
+	return l.fire(pod, to, txHash)
+}
+
+// Advance fires the declared transitions from the current state up to to, recording txHash
+// with the last of them, and returns the pod state after the transitions that were written. It
+// stays within the pod being processed: it reports false, without firing, when the lifecycle is
+// in TxStatePreInit, already is in to or has passed it. Like Fire it does not change pod.
+func (l *PodLifecycle) Advance(pod *PodState, to string, txHash string) (*PodState, bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var path []string
+	for state := l.current; state != TxStatePreInit && state != to && len(path) < len(l.next); {
+		state = l.next[state]
+		path = append(path, state)
+	}
+	if len(path) == 0 || path[len(path)-1] != to {
+		return pod, false, nil
+	}
+	for i, state := range path {
+		hash := ""
+		if i == len(path)-1 {
+			hash = txHash
+		}
+		next, err := l.fire(pod, state, hash)
+		if err != nil {
+			return pod, i > 0, err
+		}
+		pod = next
+	}
+	return pod, true, nil
+}
+
+func (l *PodLifecycle) fire(pod *PodState, to string, txHash string) (*PodState, error) {
+	next := *pod
+	next.LatestTxState = to
+	step := &PodStep{
+		PodTransitionRecord: PodTransitionRecord{
+			From:   l.current,
+			To:     to,
+			TxHash: txHash,
+			Time:   time.Now().UTC(),
+		},
+		Pod:   &next,
+		Batch: l.journal.NewBatch(),
+	}
+	for _, action := range l.onExit[l.current] {
+		if err := action(step); err != nil {
+			return nil, fmt.Errorf("leaving %s: %w", l.current, err)
+		}
+	}
+	for _, action := range l.onEnter[to] {
+		if err := action(step); err != nil {
+			return nil, fmt.Errorf("entering %s: %w", to, err)
+		}
+	}
+	step.Pod.LatestTxState = to
+	step.PodTransitionRecord.Pod = step.Pod.LatestPodHeight
+
+	seq := l.seq
+	if step.Pod.LatestPodHeight != l.height {
+		records, err := PodTransitionRecords(l.state, step.Pod.LatestPodHeight)
+		if err != nil {
+			return nil, err
+		}
+		seq = len(records)
+	}
+	record, err := json.Marshal(step.PodTransitionRecord)
+	if err != nil {
+		return nil, err
+	}
+	states := step.Batch.Store(PodStoreState)
+	states.Put(podTransitionKey(step.Pod.LatestPodHeight, seq+1), record)
+	states.Put([]byte("podState"), types.MarshalPodState(step.Pod))
+	if err = l.journal.Write(step.Batch); err != nil {
+		return nil, fmt.Errorf("writing %s to %s transition: %w", l.current, to, err)
+	}
+
+	l.current = to
+	l.height = step.Pod.LatestPodHeight
+	l.seq = seq + 1
+	return step.Pod, nil
+}
+
+func podTransitionPrefix(pod uint64) []byte {
+	return []byte("podTransition-" + strconv.FormatUint(pod, 10) + "-")
+}
+
+func podTransitionKey(pod uint64, seq int) []byte {
+	return append(podTransitionPrefix(pod), fmt.Sprintf("%06d", seq)...)
+}
+
+// PodTransitionRecords returns the transitions recorded for a pod, oldest first.
+func PodTransitionRecords(state store.Reader, pod uint64) ([]PodTransitionRecord, error) {
+	iter := state.NewIterator(store.Prefix(podTransitionPrefix(pod)))
+	defer iter.Release()
+
+	var records []PodTransitionRecord
+	for iter.Next() {
+		var record PodTransitionRecord
+		if err := json.Unmarshal(iter.Value(), &record); err != nil {
+			return nil, fmt.Errorf("invalid transition %s: %w", iter.Key(), err)
+		}
+		records = append(records, record)
+	}
+	return records, iter.Error()
+}
+
+// newNodePodLifecycle returns the lifecycle of the node with the actions that keep the pod record
+// and the pod databases consistent with the tx state.
+func newNodePodLifecycle(c *Connections, conf *config.Config) (*PodLifecycle, error) {
+	l, err := NewPodLifecycle(c.PodJournal, c.StateDatabaseConnection, PodTransitions)
+	if err != nil {
+		return nil, err
+	}
+
+	// a pod only starts the junction steps with transactions and their proof
+	l.OnExit(TxStatePreInit, func(step *PodStep) error {
+		if step.Pod.Batch == nil || len(step.Pod.Batch.TransactionHash) == 0 || len(step.Pod.LatestPodProof) == 0 {
+			return fmt.Errorf("pod %d has no proven transactions", step.Pod.LatestPodHeight)
+		}
+		if step.Pod.Votes == nil {
+			step.Pod.Votes = make(map[string]Votes)
+		}
+		return nil
+	})
+
+	// each junction step records the hash of its transaction on the pod
+	l.OnEnter(TxStateVerifyVRF, func(step *PodStep) error {
+		if step.TxHash != "" {
+			step.Pod.VRFInitiationTxHash = step.TxHash
+		}
+		return nil
+	})
+	l.OnEnter(TxStateSubmitPod, func(step *PodStep) error {
+		if step.TxHash != "" {
+			step.Pod.VRFValidationTxHash = step.TxHash
+		}
+		return nil
+	})
+	l.OnEnter(TxStateVerifyPod, func(step *PodStep) error {
+		if step.TxHash != "" {
+			step.Pod.InitPodTxHash = step.TxHash
+		}
+		return nil
+	})
+
+	// a verified pod is saved as pod-N and the counters move past it
+	l.OnEnter(TxStatePreInit, func(step *PodStep) error {
+		if step.TxHash != "" {
+			step.Pod.VerifyPodTxHash = step.TxHash
+		}
+		timestamp := step.Time
+		step.Pod.Timestamp = &timestamp
+		step.Pod.LatestTxState = TxStatePreInit
+
+		podNumber := int(step.Pod.LatestPodHeight)
+		static := step.Batch.Store(PodStoreStatic)
+		static.Put([]byte("batchStartIndex"), []byte(strconv.Itoa(PodTxnEnd(step.Pod.Batch, podNumber, conf.Station.GetPodSize()))))
+		static.Put([]byte("batchCount"), []byte(strconv.Itoa(podNumber)))
+		step.Batch.Store(PodStorePods).Put([]byte(fmt.Sprintf("pod-%d", podNumber)), types.MarshalPodState(step.Pod))
+
+		// pod-N keeps the master and the votes of the pod, the next pod starts without them
+		step.Pod.MasterTrackAppHash = nil
+		step.Pod.Votes = make(map[string]Votes)
+		return nil
+	})
+	return l, nil
+}
@@ -0,0 +1,154 @@
+package shared
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/airchains-network/tracks/config"
+	"github.com/airchains-network/tracks/store"
+	"github.com/airchains-network/tracks/types"
+)
+
+func testConnections() *Connections {
+	c := &Connections{
+		StateDatabaseConnection:  store.NewMemory(),
+		StaticDatabaseConnection: store.NewMemory(),
+		PodsDatabaseConnection:   store.NewMemory(),
+	}
+	c.PodJournal = store.NewJournal(c.StateDatabaseConnection, podJournalKey, map[string]store.Store{
+		PodStoreState:  c.StateDatabaseConnection,
+		PodStoreStatic: c.StaticDatabaseConnection,
+		PodStorePods:   c.PodsDatabaseConnection,
+	})
+	return c
+}
+
+func testPod(height uint64) *PodState {
+	return &PodState{
+		LatestPodHeight: height,
+		LatestPodProof:  []byte("proof"),
+		Batch:           &types.BatchStruct{TransactionHash: []string{"0xa", "0xb"}, TxnEndIndex: 7},
+	}
+}
+
+func TestPodLifecycleDeclaredTransitions(t *testing.T) {
+	c := testConnections()
+	l, err := newNodePodLifecycle(c, config.DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var order []string
+	l.OnExit(TxStateInitVRF, func(step *PodStep) error { order = append(order, "exit "+step.From); return nil })
+	l.OnEnter(TxStateVerifyVRF, func(step *PodStep) error { order = append(order, "enter "+step.To); return nil })
+
+	pod := testPod(3)
+	pod.MasterTrackAppHash = []byte("master")
+	if _, err = l.Fire(pod, TxStateVerifyVRF, ""); !errors.Is(err, ErrPodTransition) {
+		t.Fatalf("Fire from PreInit to VerifyVRF returned %v, want ErrPodTransition", err)
+	}
+	if _, err = l.Fire(&PodState{LatestPodHeight: 3}, TxStateInitVRF, ""); err == nil {
+		t.Fatal("Fire started a pod without proven transactions")
+	}
+	started, err := l.Fire(pod, TxStateInitVRF, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pod.LatestTxState != "" || pod.Votes != nil {
+		t.Error("Fire changed the pod state it was given")
+	}
+	started.Votes["peer"] = Votes{PeerID: "peer", Vote: true}
+	if pod, err = l.Fire(started, TxStateVerifyVRF, "vrf init"); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"exit InitVRF", "enter VerifyVRF"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("actions ran as %v, want %v", order, want)
+	}
+	if pod.LatestTxState != TxStateVerifyVRF || pod.VRFInitiationTxHash != "vrf init" {
+		t.Errorf("pod is in %s with VRF init hash %q", pod.LatestTxState, pod.VRFInitiationTxHash)
+	}
+	if started.LatestTxState != TxStateInitVRF || started.VRFInitiationTxHash != "" {
+		t.Error("Fire changed the pod state it was given")
+	}
+
+	// a track that missed steps catches up to the one it is told about
+	pod, moved, err := l.Advance(pod, TxStateVerifyPod, "init pod")
+	if err != nil || !moved {
+		t.Fatalf("Advance to VerifyPod = %v, %v", moved, err)
+	}
+	if _, moved, _ := l.Advance(pod, TxStateSubmitPod, "late"); moved {
+		t.Error("Advance moved back to a step the pod has passed")
+	}
+	if pod.InitPodTxHash != "init pod" || pod.VRFValidationTxHash != "" {
+		t.Errorf("pod has init pod hash %q and VRF validation hash %q", pod.InitPodTxHash, pod.VRFValidationTxHash)
+	}
+
+	// resuming reads the state of the last transition written
+	resumed, err := NewPodLifecycle(c.PodJournal, c.StateDatabaseConnection, PodTransitions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resumed.State() != TxStateVerifyPod {
+		t.Fatalf("resumed lifecycle is in %s, want %s", resumed.State(), TxStateVerifyPod)
+	}
+
+	verified, moved, err := l.Advance(pod, TxStatePreInit, "verify pod")
+	if err != nil || !moved {
+		t.Fatalf("Advance to PreInit = %v, %v", moved, err)
+	}
+	if _, moved, _ := l.Advance(verified, TxStatePreInit, "verify pod"); moved {
+		t.Error("Advance saved the pod twice")
+	}
+
+	// the saved pod keeps its master and votes, the pod state does not carry them to the next pod
+	if verified.MasterTrackAppHash != nil || len(verified.Votes) != 0 {
+		t.Errorf("pod state after saving has master %q and votes %v", verified.MasterTrackAppHash, verified.Votes)
+	}
+	stored, err := c.StateDatabaseConnection.Get([]byte("podState"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if storedPod, err := types.UnmarshalPodState(stored); err != nil || storedPod.MasterTrackAppHash != nil || len(storedPod.Votes) != 0 {
+		t.Errorf("stored pod state after saving is %+v, %v", storedPod, err)
+	}
+
+	saved, err := c.PodsDatabaseConnection.Get([]byte("pod-3"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	savedPod, err := types.UnmarshalPodState(saved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if savedPod.LatestTxState != TxStatePreInit || savedPod.VerifyPodTxHash != "verify pod" || savedPod.Timestamp == nil {
+		t.Errorf("saved pod is in %s with verify pod hash %q", savedPod.LatestTxState, savedPod.VerifyPodTxHash)
+	}
+	if string(savedPod.MasterTrackAppHash) != "master" || !savedPod.Votes["peer"].Vote {
+		t.Errorf("saved pod has master %q and votes %v", savedPod.MasterTrackAppHash, savedPod.Votes)
+	}
+	for key, want := range map[string]string{"batchCount": "3", "batchStartIndex": "7"} {
+		if got, _ := c.StaticDatabaseConnection.Get([]byte(key)); string(got) != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+
+	records, err := PodTransitionRecords(c.StateDatabaseConnection, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var steps []string
+	for _, record := range records {
+		steps = append(steps, record.From+">"+record.To+":"+record.TxHash)
+	}
+	want := []string{
+		"PreInit>InitVRF:",
+		"InitVRF>VerifyVRF:vrf init",
+		"VerifyVRF>InitPod:",
+		"InitPod>VerifyPod:init pod",
+		"VerifyPod>PreInit:verify pod",
+	}
+	if !reflect.DeepEqual(steps, want) {
+		t.Errorf("journal holds %v, want %v", steps, want)
+	}
+}
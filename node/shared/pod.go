@@ -2,20 +2,21 @@
 package shared
 
 import (
-	"encoding/json"
+	"bytes"
+	"crypto/sha256"
 	"fmt"
 	"github.com/airchains-network/tracks/blocksync"
 	"github.com/airchains-network/tracks/config"
 	logs "github.com/airchains-network/tracks/log"
+	"github.com/airchains-network/tracks/store"
 	"github.com/airchains-network/tracks/types"
 	"github.com/pelletier/go-toml"
-	"github.com/syndtr/goleveldb/leveldb"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
-	"time"
+	"sync/atomic"
 )
 
 var (
@@ -30,60 +31,60 @@ var (
 	TxStateVerifyPod = "VerifyPod"
 )
 
-type Votes struct {
-	PeerID string // TODO change this type to proper Peer ID Type
-	//Commitment string
-	Vote bool
-}
-type PodState struct {
-	LatestPodHeight     uint64
-	LatestTxState       string // InitVRF / VerifyVRF / InitPod / VerifyPod
-	LatestPodHash       []byte
-	PreviousPodHash     []byte
-	LatestPodProof      []byte
-	LatestPublicWitness []byte
-	Votes               map[string]Votes
-	TracksAppHash       []byte
-	Batch               *types.BatchStruct
-	MasterTrackAppHash  []byte
-	Timestamp           *time.Time `json:"timestamp,omitempty"`
-
-	VRFInitiationTxHash string
-	VRFValidationTxHash string
-	InitPodTxHash       string
-	VerifyPodTxHash     string
-}
+// PodState and Votes are the canonical pod record of the types package, which every package
+// reading or writing pods uses.
+type (
+	PodState = types.PodState
+	Votes    = types.Votes
+)
+
 type Connections struct {
 	mu                                 sync.Mutex
-	BlockDatabaseConnection            *leveldb.DB
-	TxnDatabaseConnection              *leveldb.DB
-	PodsDatabaseConnection             *leveldb.DB
-	DataAvailabilityDatabaseConnection *leveldb.DB
-	StaticDatabaseConnection           *leveldb.DB
-	StateDatabaseConnection            *leveldb.DB
-	MockDatabaseConnection             *leveldb.DB
-	PublicWitnessConnection            *leveldb.DB
+	BlockDatabaseConnection            store.Store
+	TxnDatabaseConnection              store.Store
+	PodsDatabaseConnection             store.Store
+	DataAvailabilityDatabaseConnection store.Store
+	StaticDatabaseConnection           store.Store
+	StateDatabaseConnection            store.Store
+	MockDatabaseConnection             store.Store
+	PublicWitnessConnection            store.Store
+
+	// PodJournal writes the changes of one pod transition to the state, static and pods
+	// databases as a single unit.
+	PodJournal *store.Journal
 }
 
+// Names of the databases a PodJournal write can change, and the state database key holding a
+// pending write.
+const (
+	PodStoreState  = "state"
+	PodStoreStatic = "static"
+	PodStorePods   = "pods"
+
+	podJournalKey = "podJournal"
+)
+
 type NodeS struct {
 	Config          *config.Config
 	podState        *PodState
 	NodeConnections *Connections
+
+	// PodLifecycle advances the tx state of the pod and journals every transition.
+	PodLifecycle *PodLifecycle
 }
 
-func InitializePodState(stateConnection *leveldb.DB) *PodState {
+func InitializePodState(stateConnection store.Store) *PodState {
 
 	// sync pod state from database
-	podStateByte, err := stateConnection.Get([]byte("podState"), nil)
+	podStateByte, err := stateConnection.Get([]byte("podState"))
 	if err != nil {
 		fmt.Println(err)
 		logs.Log.Error("Pod should be already initiated/updated by now")
 		os.Exit(0)
 	}
-	var podState *PodState
-	err = json.Unmarshal(podStateByte, &podState)
+	podState, err := types.UnmarshalPodState(podStateByte)
 	if err != nil {
-		logs.Log.Error("Error in unmarshal  pod state")
+		logs.Log.Error(fmt.Sprintf("Error in unmarshal pod state: %s", err.Error()))
 		os.Exit(0)
 	}
 	return podState
@@ -100,8 +101,21 @@ func SetPodState(podState *PodState) {
 	Node.podState = podState
 }
 
+// UpdatePodState replaces the pod state with the one update returns for it, holding the pod
+// state lock throughout so updates racing each other apply in turn. update returns nil to keep
+// the pod state, and its error is returned either way.
+func UpdatePodState(update func(podState *PodState) (*PodState, error)) error {
+	mu.Lock()
+	defer mu.Unlock()
+	next, err := update(Node.podState)
+	if next != nil {
+		Node.podState = next
+	}
+	return err
+}
+
 func InitializeDatabaseConnections() *Connections {
-	return &Connections{
+	c := &Connections{
 		BlockDatabaseConnection:            blocksync.GetBlockDbInstance(),
 		StateDatabaseConnection:            blocksync.GetStateDbInstance(),
 		TxnDatabaseConnection:              blocksync.GetTxDbInstance(),
@@ -111,67 +125,215 @@ func InitializeDatabaseConnections() *Connections {
 		MockDatabaseConnection:             blocksync.GetMockDbInstance(),
 		PublicWitnessConnection:            blocksync.GetPublicWitnessDbInstance(),
 	}
+	c.PodJournal = store.NewJournal(c.StateDatabaseConnection, podJournalKey, map[string]store.Store{
+		PodStoreState:  c.StateDatabaseConnection,
+		PodStoreStatic: c.StaticDatabaseConnection,
+		PodStorePods:   c.PodsDatabaseConnection,
+	})
+	return c
 }
 
-func (c *Connections) GetBlockDatabaseConnection() *leveldb.DB {
+func (c *Connections) GetBlockDatabaseConnection() store.Store {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	return c.BlockDatabaseConnection
 }
 
-func (c *Connections) GetTxnDatabaseConnection() *leveldb.DB {
+func (c *Connections) GetTxnDatabaseConnection() store.Store {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	return c.TxnDatabaseConnection
 }
 
-func (c *Connections) GetPodsDatabaseConnection() *leveldb.DB {
+func (c *Connections) GetPodsDatabaseConnection() store.Store {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	return c.PodsDatabaseConnection
 }
 
-func (c *Connections) GetDataAvailabilityDatabaseConnection() *leveldb.DB {
+func (c *Connections) GetDataAvailabilityDatabaseConnection() store.Store {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	return c.DataAvailabilityDatabaseConnection
 }
 
-func (c *Connections) GetStaticDatabaseConnection() *leveldb.DB {
+func (c *Connections) GetStaticDatabaseConnection() store.Store {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	return c.StaticDatabaseConnection
 }
 
-func (c *Connections) GetStateDatabaseConnection() *leveldb.DB {
+func (c *Connections) GetStateDatabaseConnection() store.Store {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	return c.StateDatabaseConnection
 }
 
-func (c *Connections) GetPublicWitnessDbInstance() *leveldb.DB {
+func (c *Connections) GetPublicWitnessDbInstance() store.Store {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	return c.PublicWitnessConnection
 }
 
-func CheckAndInitializeDBCounters(staticDB *leveldb.DB) {
+func (c *Connections) GetPodJournal() *store.Journal {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.PodJournal
+}
+
+func CheckAndInitializeDBCounters(staticDB store.Store) {
 	ensureCounter(staticDB, "batchStartIndex")
 	ensureCounter(staticDB, "batchCount")
 }
 
-func ensureCounter(db *leveldb.DB, counterKey string) {
+func ensureCounter(db store.Store, counterKey string) {
 	//fmt.Println(db)
-	if _, err := db.Get([]byte(counterKey), nil); err != nil {
-		if err = db.Put([]byte(counterKey), []byte("0"), nil); err != nil {
+	if _, err := db.Get([]byte(counterKey)); err != nil {
+		if err = db.Put([]byte(counterKey), []byte("0")); err != nil {
 			logs.Log.Error(fmt.Sprintf("Error in saving %s in static db: %s", counterKey, err.Error()))
 			return
 		}
 	}
 }
 
-func GetLatestBlock(blockDB *leveldb.DB) int {
-	latestBlockBytes, err := blockDB.Get([]byte("blockCount"), nil)
+// CommittedTxnCount returns how many transactions of the txns-N sequence belong to a saved pod
+// or to the pod currently being processed.
+func CommittedTxnCount() (int, error) {
+	staticDB := Node.NodeConnections.GetStaticDatabaseConnection()
+	batchStartIndexBytes, err := staticDB.Get([]byte("batchStartIndex"))
+	if err != nil {
+		return 0, fmt.Errorf("error in getting batchStartIndex from static db: %w", err)
+	}
+	committed, err := strconv.Atoi(strings.TrimSpace(string(batchStartIndexBytes)))
+	if err != nil {
+		return 0, fmt.Errorf("invalid batchStartIndex: %w", err)
+	}
+
+	podState := GetPodState()
+	if podState != nil && podState.LatestTxState != TxStatePreInit && podState.Batch != nil {
+		if podState.Batch.TxnEndIndex > 0 {
+			return podState.Batch.TxnEndIndex, nil
+		}
+		committed += len(podState.Batch.TransactionHash)
+	}
+	return committed, nil
+}
+
+// provenTxnEnd reports the last txns-N record covered by the pods proven ahead of the pod being
+// processed. It is set by the pod pipeline.
+var provenTxnEnd atomic.Value
+
+// SetProvenTxnEnd registers how ProvenTxnCount learns about the pods proven ahead.
+func SetProvenTxnEnd(end func() int) {
+	provenTxnEnd.Store(end)
+}
+
+// ProvenTxnCount returns how many transactions of the txns-N sequence are in a proven pod: a
+// saved pod, the pod being processed or a pod proven ahead of it. The indexer refuses to unwind
+// them.
+func ProvenTxnCount() (int, error) {
+	committed, err := CommittedTxnCount()
+	if err != nil {
+		return 0, err
+	}
+	if end, ok := provenTxnEnd.Load().(func() int); ok {
+		if proven := end(); proven > committed {
+			return proven, nil
+		}
+	}
+	return committed, nil
+}
+
+// FindPodByTxnIndex returns the number of the pod whose batch covers the txns-N record at index,
+// or 0 if no saved pod or the pod being processed covers it yet.
+func FindPodByTxnIndex(index int) (int, error) {
+	staticDB := Node.NodeConnections.GetStaticDatabaseConnection()
+	batchCountBytes, err := staticDB.Get([]byte("batchCount"))
+	if err != nil {
+		return 0, fmt.Errorf("error in getting batchCount from static db: %w", err)
+	}
+	batchCount, err := strconv.Atoi(strings.TrimSpace(string(batchCountBytes)))
+	if err != nil {
+		return 0, fmt.Errorf("invalid batchCount: %w", err)
+	}
+
+	if index < 1 {
+		return 0, nil
+	}
+	batchDB := Node.NodeConnections.GetPodsDatabaseConnection()
+	low, high := 1, batchCount
+	for low <= high {
+		mid := (low + high) / 2
+		podBytes, err := batchDB.Get([]byte(fmt.Sprintf("pod-%d", mid)))
+		if err != nil {
+			return 0, fmt.Errorf("error in getting pod %d: %w", mid, err)
+		}
+		pod, err := types.UnmarshalPodState(podBytes)
+		if err != nil {
+			return 0, fmt.Errorf("invalid pod %d: %w", mid, err)
+		}
+		if PodTxnEnd(pod.Batch, mid, Node.Config.Station.GetPodSize()) >= index {
+			high = mid - 1
+		} else {
+			low = mid + 1
+		}
+	}
+	if low <= batchCount {
+		return low, nil
+	}
+
+	committed, err := CommittedTxnCount()
+	if err != nil {
+		return 0, err
+	}
+	if index <= committed {
+		return batchCount + 1, nil
+	}
+	return 0, nil
+}
+
+// PodTxnEnd returns the last txns-N record covered by the batch of the given pod number. Batches
+// that did not record it cover exactly podSize records per pod, the station's pod size.
+func PodTxnEnd(batch *types.BatchStruct, podNumber int, podSize int) int {
+	if batch != nil && batch.TxnEndIndex > 0 {
+		return batch.TxnEndIndex
+	}
+	return podSize * podNumber
+}
+
+// PodHash returns the tracks app hash of a pod: the hash of its public witness, proof and state
+// root together with the batchCount recorded before the pod was generated.
+func PodHash(witness, proof, stateRoot []byte, batchCount []byte) []byte {
+	hash := sha256.New()
+	hash.Write(witness)
+	hash.Write(proof)
+	hash.Write(stateRoot)
+	hash.Write(batchCount)
+	return hash.Sum(nil)
+}
+
+// VerifyPodRecord checks a saved pod record against the pod hash chain: it must be pod number,
+// its tracks app hash must match its content and it must follow prev, the record of the pod
+// before it, which is nil for the first pod.
+func VerifyPodRecord(prev, pod *PodState, number int) error {
+	if pod.LatestPodHeight != uint64(number) {
+		return fmt.Errorf("pod-%d records pod height %d", number, pod.LatestPodHeight)
+	}
+	if !bytes.Equal(pod.TracksAppHash, PodHash(pod.LatestPublicWitness, pod.LatestPodProof, pod.LatestPodHash, []byte(strconv.Itoa(number-1)))) {
+		return fmt.Errorf("pod-%d tracks app hash does not match its content", number)
+	}
+	var prevHash []byte
+	if prev != nil {
+		prevHash = prev.LatestPodHash
+	}
+	if !bytes.Equal(pod.PreviousPodHash, prevHash) {
+		return fmt.Errorf("pod-%d previous pod hash does not match pod-%d", number, number-1)
+	}
+	return nil
+}
+
+func GetLatestBlock(blockDB store.Store) int {
+	latestBlockBytes, err := blockDB.Get([]byte("blockCount"))
 	if err != nil {
 		logs.Log.Error(fmt.Sprintf("Error in getting blockCount from block db: %s", err.Error()))
 		return 0
@@ -183,22 +345,32 @@ func GetLatestBlock(blockDB *leveldb.DB) int {
 func NewNode(conf *config.Config) {
 
 	NodeConnections := InitializeDatabaseConnections()
+	// finish a pod transition that was interrupted before podState is read
+	if err := NodeConnections.PodJournal.Replay(); err != nil {
+		logs.Log.Error(fmt.Sprintf("Error in completing interrupted pod update: %s", err.Error()))
+		os.Exit(0)
+	}
 	stateConnection := NodeConnections.GetStateDatabaseConnection()
 	podState := InitializePodState(stateConnection)
+	podLifecycle, err := newNodePodLifecycle(NodeConnections, conf)
+	if err != nil {
+		logs.Log.Error(fmt.Sprintf("Error in resuming pod lifecycle: %s", err.Error()))
+		os.Exit(0)
+	}
 
 	Node = &NodeS{
 		Config:          conf,
 		podState:        podState,
 		NodeConnections: NodeConnections,
+		PodLifecycle:    podLifecycle,
 	}
 }
 
 func LoadConfig() (cnf *config.Config, err error) {
-	homeDir, err := os.UserHomeDir()
+	configDir, err := config.ConfigDirPath()
 	if err != nil {
 		return nil, fmt.Errorf("%v", err) // Return error, perhaps log it as well
 	}
-	configDir := filepath.Join(homeDir, config.DefaultTracksDir, config.DefaultConfigDir)
 
 	_, err = os.Stat(configDir)
 	if os.IsNotExist(err) {
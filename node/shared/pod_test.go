@@ -0,0 +1,30 @@
+package shared
+
+import (
+	"testing"
+
+	"github.com/airchains-network/tracks/config"
+	"github.com/airchains-network/tracks/types"
+)
+
+func TestPodTxnEnd(t *testing.T) {
+	recorded := (&config.StationConfig{PodSize: 10}).GetPodSize()
+	legacy := (&config.StationConfig{}).GetPodSize()
+	tests := []struct {
+		name      string
+		batch     *types.BatchStruct
+		podNumber int
+		podSize   int
+		want      int
+	}{
+		{"full pods of the recorded size", nil, 3, recorded, 30},
+		{"batch without an end", &types.BatchStruct{TxnCount: 10}, 4, recorded, 40},
+		{"partial pod", &types.BatchStruct{TxnEndIndex: 34, TxnCount: 4}, 4, recorded, 34},
+		{"station without a recorded size", nil, 2, legacy, 2 * config.DefaultPodSize},
+	}
+	for _, tt := range tests {
+		if got := PodTxnEnd(tt.batch, tt.podNumber, tt.podSize); got != tt.want {
+			t.Errorf("%s: PodTxnEnd = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
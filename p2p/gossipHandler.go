@@ -1,6 +1,7 @@
 package p2p
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/airchains-network/tracks/da/avail"
@@ -44,10 +45,12 @@ const (
 )
 
 type PodSubmittedMessageHandler struct {
+	ctx     context.Context
 	message PodSubmittedMsgData
 }
 
 type PodVerifiedMessageHandler struct {
+	ctx     context.Context
 	message PodVerifiedMsgData
 }
 
@@ -75,7 +78,8 @@ type AccountDetails struct {
 // - dataType: The type of gossip data being processed
 // - dataByte: The byte slice representation of the gossip data
 // - messageBroadcaster: The ID of the peer who broadcasted the message
-func ProcessGossipMessage(node host.Host, dataType string, dataByte []byte, messageBroadcaster peer.ID) {
+// Pod generation started by a handler stops with ctx.
+func ProcessGossipMessage(ctx context.Context, node host.Host, dataType string, dataByte []byte, messageBroadcaster peer.ID) {
 	messageHandlers := map[string]func([]byte){
 		"vrfInitiated": func(dataByte []byte) {
 			handler := NewVRFInitiatedMessageHandler(dataByte)
@@ -85,13 +89,13 @@ func ProcessGossipMessage(node host.Host, dataType string, dataByte []byte, mess
 		},
 		"vrnValidated": VRNValidatedMsgHandler,
 		"podSubmitted": func(dataByte []byte) {
-			handler := NewPodSubmittedMessageHandler(dataByte)
+			handler := NewPodSubmittedMessageHandler(ctx, dataByte)
 			if handler != nil {
 				handler.HandlePodSubmissionMessage()
 			}
 		},
 		"podVerified": func(dataByte []byte) {
-			handler := NewPodVerifiedMessageHandler(dataByte)
+			handler := NewPodVerifiedMessageHandler(ctx, dataByte)
 			if handler != nil {
 				handler.HandlePodMessage()
 			}
@@ -131,11 +135,8 @@ func (h *VRFInitiatedMessageHandler) HandleVRFInitiatedMessage() {
 		return
 	}
 
-	// all nodes: update vrn init hash
-	currentPodState := shared.GetPodState()
-	VrfInitTxHash := h.message.VrfInitTxHash
-	currentPodState.VRFInitiationTxHash = VrfInitTxHash
-	shared.SetPodState(currentPodState)
+	// all nodes: record the vrn init hash
+	advancePod(shared.TxStateVerifyVRF, h.message.VrfInitTxHash)
 
 	// selected node
 	if h.message.SelectedTrackAddress == accountDetails.MyAddress {
@@ -204,6 +205,7 @@ func processVerifiedVRF(VRFInitiatedMsg *VRFInitiatedMsgData, ad *AccountDetails
 		logs.Log.Error("Verification of VRF is failed, need Voting for correct VRN")
 		return
 	}
+	advancePod(shared.TxStateSubmitPod, shared.GetPodState().VRFValidationTxHash)
 
 	PodNumber := int(shared.GetPodState().LatestPodHeight)
 	SelectedTrackAddress := ad.Tracks[vrfRecord.SelectedTrackIndex]
@@ -255,11 +257,8 @@ func VRNValidatedMsgHandler(dataByte []byte) {
 		time.Sleep(3 * time.Second)
 	}
 
-	// all nodes: update txHash of vrn validated
-	VRFValidationTxHash := VRNVerifiedMsg.VRFVerifiedTxHash
-	currentPodState := shared.GetPodState()
-	currentPodState.VRFValidationTxHash = VRFValidationTxHash
-	shared.SetPodState(currentPodState)
+	// all nodes: record the txHash of vrn validated
+	advancePod(shared.TxStateSubmitPod, VRNVerifiedMsg.VRFVerifiedTxHash)
 
 	// check if this node is selected to submit pod & da
 	_, _, accountPath, accountName, addressPrefix, tracks, err := junction.GetJunctionDetails()
@@ -312,7 +311,7 @@ func VRNValidatedMsgHandler(dataByte []byte) {
 				logs.Log.Warn(fmt.Sprintf("Error in marshaling DA pointer : %s", daStoreDataErr.Error()))
 			}
 
-			storeErr := DaBatchSaver.Put([]byte(daStoreKey), daStoreData, nil)
+			storeErr := DaBatchSaver.Put([]byte(daStoreKey), daStoreData)
 			if storeErr != nil {
 				logs.Log.Warn(fmt.Sprintf("Error in saving DA pointer in pod database : %s", storeErr.Error()))
 			}
@@ -340,7 +339,7 @@ func VRNValidatedMsgHandler(dataByte []byte) {
 				logs.Log.Warn(fmt.Sprintf("Error in marshaling DA pointer : %s", daStoreDataErr.Error()))
 			}
 
-			storeErr := DaBatchSaver.Put([]byte(daStoreKey), daStoreData, nil)
+			storeErr := DaBatchSaver.Put([]byte(daStoreKey), daStoreData)
 			if storeErr != nil {
 				logs.Log.Warn(fmt.Sprintf("Error in saving DA pointer in pod database : %s", storeErr.Error()))
 			}
@@ -369,7 +368,7 @@ func VRNValidatedMsgHandler(dataByte []byte) {
 				logs.Log.Warn(fmt.Sprintf("Error in marshaling DA pointer : %s", daStoreDataErr.Error()))
 			}
 
-			storeErr := DaBatchSaver.Put([]byte(daStoreKey), daStoreData, nil)
+			storeErr := DaBatchSaver.Put([]byte(daStoreKey), daStoreData)
 			if storeErr != nil {
 				logs.Log.Warn(fmt.Sprintf("Error in saving DA pointer in pod database : %s", storeErr.Error()))
 			}
@@ -400,7 +399,7 @@ func VRNValidatedMsgHandler(dataByte []byte) {
 				logs.Log.Warn(fmt.Sprintf("Error in marshaling DA pointer : %s", daStoreDataErr.Error()))
 			}
 
-			storeErr := DaBatchSaver.Put([]byte(daStoreKey), daStoreData, nil)
+			storeErr := DaBatchSaver.Put([]byte(daStoreKey), daStoreData)
 			if storeErr != nil {
 				logs.Log.Warn(fmt.Sprintf("Error in saving DA pointer in pod database : %s", storeErr.Error()))
 			}
@@ -418,6 +417,7 @@ func VRNValidatedMsgHandler(dataByte []byte) {
 			logs.Log.Error("Failed to submit pod")
 			return
 		}
+		advancePod(shared.TxStateVerifyPod, shared.GetPodState().InitPodTxHash)
 
 		var filteredTracks []string
 		for _, track := range tracks {
@@ -458,8 +458,8 @@ func VRNValidatedMsgHandler(dataByte []byte) {
 // NewPodSubmittedMessageHandler takes in a byte slice representing the PodSubmitted message,
 // decodes it into a PodSubmittedMsgData struct, and returns a pointer to a PodSubmittedMessageHandler
 // with the decoded message. If the decoding fails, it returns nil.
-func NewPodSubmittedMessageHandler(dataByte []byte) *PodSubmittedMessageHandler {
-	h := &PodSubmittedMessageHandler{}
+func NewPodSubmittedMessageHandler(ctx context.Context, dataByte []byte) *PodSubmittedMessageHandler {
+	h := &PodSubmittedMessageHandler{ctx: ctx}
 	if err := json.Unmarshal(dataByte, &h.message); err != nil {
 		logs.Log.Error(LogPodSubmitExtractFail)
 		return nil
@@ -496,11 +496,8 @@ func (h *PodSubmittedMessageHandler) processPodSubmission() {
 		return
 	}
 
-	// all nodes: update initPodTxHash
-	currentPodState := shared.GetPodState()
-	InitPodTxHash := h.message.InitPodTxHash
-	currentPodState.InitPodTxHash = InitPodTxHash
-	shared.SetPodState(currentPodState)
+	// all nodes: record the initPodTxHash
+	advancePod(shared.TxStateVerifyPod, h.message.InitPodTxHash)
 
 	if h.message.SelectedTrackAddress == myAddress {
 		h.verifyAndBroadcastPod()
@@ -548,9 +545,12 @@ func (h *PodSubmittedMessageHandler) broadcastPodVerifiedMessage() {
 		logs.Log.Error(LogMarshalGossipMsg)
 		return
 	}
-	saveVerifiedPOD()
+	// entering PreInit saves the pod and moves the counters past it
+	if !advancePod(shared.TxStatePreInit, VerifyPodTxHash) {
+		return
+	}
 	BroadcastMessage(CTX, Node, gossipMsgByte)
-	GenerateUnverifiedPods()
+	GenerateUnverifiedPods(h.ctx)
 }
 
 // NewPodVerifiedMessageHandler takes in a byte slice and returns a new instance of PodVerifiedMessageHandler
@@ -559,8 +559,8 @@ func (h *PodSubmittedMessageHandler) broadcastPodVerifiedMessage() {
 // If there is an error during decoding, it logs an error and returns nil.
 // If decoding is successful, it logs a warning indicating the successful decoding and returns the handler.
 
-func NewPodVerifiedMessageHandler(dataByte []byte) *PodVerifiedMessageHandler {
-	h := &PodVerifiedMessageHandler{}
+func NewPodVerifiedMessageHandler(ctx context.Context, dataByte []byte) *PodVerifiedMessageHandler {
+	h := &PodVerifiedMessageHandler{ctx: ctx}
 	if err := json.Unmarshal(dataByte, &h.message); err != nil {
 		logs.Log.Error(LogPodExtractFail)
 		return nil
@@ -580,12 +580,6 @@ func (h *PodVerifiedMessageHandler) HandlePodMessage() {
 		h.logAndSleep(LogPodMismatch, h.message.PodNumber, podState.LatestPodHeight)
 	}
 
-	// update pod verified
-	podState := shared.GetPodState()
-	VerifyPodTxHash := shared.GetPodState().VerifyPodTxHash
-	podState.VerifyPodTxHash = VerifyPodTxHash
-	shared.SetPodState(podState)
-
 	logs.Log.Warn(LogPodMatchSuccess)
 	h.handleVerificationResult()
 }
@@ -601,9 +595,12 @@ func (h *PodVerifiedMessageHandler) handleVerificationResult() {
 
 	if h.message.VerificationResult {
 		logs.Log.Info(LogPodSave)
-		saveVerifiedPOD() // save the latest pod details and make next pod
+		// save the latest pod details, unless this track already saved it
+		if !advancePod(shared.TxStatePreInit, h.message.PodVerifiedTxHash) {
+			return
+		}
 		logs.Log.Info(LogPodGenNext)
-		GenerateUnverifiedPods() // generate next pod
+		GenerateUnverifiedPods(h.ctx) // generate next pod
 	} else {
 		logs.Log.Error(LogPodFail)
 	}
@@ -2,6 +2,7 @@ package p2p
 
 import (
 	"fmt"
+	"github.com/airchains-network/tracks/config"
 	"github.com/libp2p/go-libp2p"
 	"github.com/libp2p/go-libp2p/core/crypto"
 	"github.com/libp2p/go-libp2p/core/host"
@@ -38,8 +39,7 @@ func (pg *PeerGenerator) GeneratePeerID() (peer.ID, error) {
 		panic(err)
 	}
 	pg.Node = node
-	homeDir, _ := os.UserHomeDir()
-	filePath := filepath.Join(homeDir, ".tracks/config")
+	filePath, _ := config.ConfigDirPath()
 	err = savePrivateKey(filepath.Join(filePath, "identity.info"), privateKey)
 	if err != nil {
 		return "", err
@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/sha256"
 	"fmt"
+	"github.com/airchains-network/tracks/config"
 	logs "github.com/airchains-network/tracks/log"
 	"github.com/airchains-network/tracks/node/shared"
 	"github.com/libp2p/go-libp2p"
@@ -16,11 +17,9 @@ import (
 	"io"
 	"math/big"
 	"os"
-	"os/signal"
 	"path/filepath"
 	"sort"
 	"sync"
-	"syscall"
 )
 
 const (
@@ -141,8 +140,8 @@ func getAllPeers(node host.Host) []peer.AddrInfo {
 }
 
 func startNode(ctx context.Context) (host.Host, error) {
-	homeDir, _ := os.UserHomeDir()
-	filePath := filepath.Join(homeDir, ".tracks/config/identity.info")
+	configDir, _ := config.ConfigDirPath()
+	filePath := filepath.Join(configDir, "identity.info")
 	privateKey, err := loadPrivateKey(filePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load private key: %w", err)
@@ -220,17 +219,19 @@ func parseAddrToPeerInfo(addrStr string) (peer.AddrInfo, error) {
 	return *peerInfo, nil
 }
 
-func setupStreamHandler(node host.Host) {
-	node.SetStreamHandler(protocol.ID(customProtocolID), streamHandler)
+func setupStreamHandler(ctx context.Context, node host.Host) {
+	node.SetStreamHandler(protocol.ID(customProtocolID), func(s network.Stream) {
+		streamHandler(ctx, s)
+	})
 
 }
 
-func streamHandler(s network.Stream) {
+func streamHandler(ctx context.Context, s network.Stream) {
 	defer s.Close()
-	handleStreamData(s)
+	handleStreamData(ctx, s)
 }
 
-func handleStreamData(s network.Stream) {
+func handleStreamData(ctx context.Context, s network.Stream) {
 	const initialBufSize = 8192
 	buf := make([]byte, initialBufSize)
 	messageBroadcaster := s.Conn().RemotePeer()
@@ -255,7 +256,7 @@ func handleStreamData(s network.Stream) {
 		}
 		fmt.Println("Data Type Received from other Peer :", dataType)
 
-		ProcessGossipMessage(Node, dataType, dataByte, messageBroadcaster)
+		ProcessGossipMessage(ctx, Node, dataType, dataByte, messageBroadcaster)
 	}
 }
 
@@ -302,9 +303,10 @@ func BroadcastMessage(ctx context.Context, host host.Host, message []byte) {
 	}
 }
 
-func P2PConfiguration() {
+// P2PConfiguration runs the p2p node until ctx is cancelled.
+func P2PConfiguration(ctx context.Context) {
 
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 	CTX = ctx
 	node, err := startNode(ctx)
@@ -315,9 +317,10 @@ func P2PConfiguration() {
 	defer Node.Close()
 
 	printNodeInfo(Node)
-	setupStreamHandler(Node)
+	setupStreamHandler(ctx, Node)
 	handlePeerConnections(ctx, Node)
-	waitForShutdownSignal()
+	<-ctx.Done()
+	fmt.Println("Received signal, shutting down...")
 }
 
 func handlePeerConnections(ctx context.Context, node host.Host) {
@@ -329,13 +332,6 @@ func handlePeerConnections(ctx context.Context, node host.Host) {
 	}
 }
 
-func waitForShutdownSignal() {
-	ch := make(chan os.Signal, 1)
-	signal.Notify(ch, syscall.SIGTERM)
-	<-ch
-	fmt.Println("Received signal, shutting down...")
-}
-
 func MasterTracksSelection(host host.Host, sharedInput string) string {
 	peers := getAllPeers(host)
 	numPeers := len(peers)
@@ -1,17 +1,21 @@
 package p2p
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"github.com/airchains-network/tracks/config"
 	"github.com/airchains-network/tracks/da/avail"
 	"github.com/airchains-network/tracks/da/celestia"
 	"github.com/airchains-network/tracks/da/eigen"
 	mock "github.com/airchains-network/tracks/da/mockda"
 	"github.com/airchains-network/tracks/junction"
-	junctionTypes "github.com/airchains-network/tracks/junction/types"
 	logs "github.com/airchains-network/tracks/log"
 	"github.com/airchains-network/tracks/node/shared"
+	"github.com/airchains-network/tracks/store"
 	"github.com/airchains-network/tracks/types"
+	utilis "github.com/airchains-network/tracks/utils"
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
@@ -23,12 +27,18 @@ import (
 	"time"
 )
 
-func BatchGeneration(wg *sync.WaitGroup) {
+// BatchGeneration generates and processes pods until ctx is cancelled.
+func BatchGeneration(ctx context.Context, wg *sync.WaitGroup) {
 	defer wg.Done()
-	GenerateUnverifiedPods()
+	GenerateUnverifiedPods(ctx)
 }
 
-func GenerateUnverifiedPods() {
+// GenerateUnverifiedPods generates the next pod and takes it through the junction steps. It
+// checks ctx before starting a pod, so a node that is stopping never starts a pod transition.
+func GenerateUnverifiedPods(ctx context.Context) {
+	if ctx.Err() != nil {
+		return
+	}
 	zerolog.TimeFieldFormat = time.RFC3339
 	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
 	log.Info().
@@ -36,6 +46,7 @@ func GenerateUnverifiedPods() {
 		Msg("Generating New unverified pods")
 
 	connection := shared.Node.NodeConnections
+	lifecycle := shared.Node.PodLifecycle
 	staticDBConnection := connection.GetStaticDatabaseConnection()
 	txnDBConnection := connection.GetTxnDatabaseConnection()
 
@@ -49,71 +60,68 @@ func GenerateUnverifiedPods() {
 	podStateData, err := GetPodStateFromDatabase()
 	CheckErrorAndExit(err, "Error in getting previous station data", 0)
 
-	var (
-		previousTrackAppHash []byte
-		trackAppHash         []byte
-		witness              []byte
-		uZKP                 []byte
-		MRH                  []byte
-		batchInput           *types.BatchStruct
-		txState              string
-		batchNumber          int
-	)
-
-	currentPodNumber, _ := strconv.Atoi(strings.TrimSpace(string(rawCurrentPodNumber)))
-	txState = podStateData.LatestTxState
-	if txState == "" {
-		txState = shared.TxStatePreInit
-	}
-
-	if currentPodNumber == 0 {
-		currentPodNumber = 1
-	}
-
-	podData := junction.QueryPod(uint64(currentPodNumber))
-	if podData != nil {
-		if podData.IsVerified == true {
-			currentPodNumber++
-		}
-	}
+	baseCfg, err := shared.LoadConfig()
+	CheckErrorAndExit(err, "Error in loading config", 0)
+	pipeline := provingPipeline(txnDBConnection, baseCfg)
 
-	batchNumber = currentPodNumber
-	log.Info().Str("module", "p2p").Msg(fmt.Sprintf("Processing Pod Number: %d", batchNumber))
-	if podStateData.LatestTxState == shared.TxStatePreInit {
-		txState = shared.TxStateInitVRF
+	// the master of a new pod is seeded with the tracks app hash of the last saved pod, a resumed
+	// pod keeps the empty seed
+	var previousTrackAppHash []byte
+	if lifecycle.State() == shared.TxStatePreInit {
 		previousTrackAppHash = podStateData.TracksAppHash
 		if previousTrackAppHash == nil {
 			previousTrackAppHash = []byte("nil")
 		}
 
-		baseCfg, err := shared.LoadConfig()
-		if err != nil {
-			log.Error().Str("module", "p2p").Msg("Error in loading config")
+		currentPodNumber := atoiTrimmed(rawCurrentPodNumber)
+		if currentPodNumber == 0 {
+			currentPodNumber = 1
 		}
-		stationVariant := baseCfg.Station.StationType
-		stationVariantLowerCase := strings.ToLower(stationVariant)
-
-		if stationVariantLowerCase == "evm" {
-			witness, uZKP, MRH, batchInput, err = createEVMPOD(txnDBConnection, rawConfirmedTransactionIndex, rawCurrentPodNumber)
-			CheckErrorAndExit(err, "Error in creating POD", 0)
-		} else if stationVariantLowerCase == "wasm" {
-			witness, uZKP, MRH, batchInput, err = createWasmPOD(txnDBConnection, rawConfirmedTransactionIndex, rawCurrentPodNumber)
-			CheckErrorAndExit(err, "Error in creating POD", 0)
+
+		podData := junction.QueryPod(uint64(currentPodNumber))
+		if podData != nil {
+			if podData.IsVerified == true {
+				currentPodNumber++
+			}
 		}
+		log.Info().Str("module", "p2p").Msg(fmt.Sprintf("Processing Pod Number: %d", currentPodNumber))
 
-		trackAppHash = generatePodHash(witness, uZKP, MRH, rawCurrentPodNumber)
-		updateNewPodState(trackAppHash, witness, uZKP, MRH, uint64(batchNumber), batchInput, txState)
+		position := podPosition{
+			startIndex: atoiTrimmed(rawConfirmedTransactionIndex),
+			batchCount: atoiTrimmed(rawCurrentPodNumber),
+		}
+		proved, err := pipeline.take(position, ctx.Done())
+		if errors.Is(err, errPipelineStopped) {
+			return
+		}
+		CheckErrorAndExit(err, "Error in creating POD", 0)
+
+		pod := &shared.PodState{
+			LatestPodHeight:     uint64(currentPodNumber),
+			LatestTxState:       shared.TxStatePreInit,
+			LatestPodHash:       proved.mrh,
+			PreviousPodHash:     podStateData.LatestPodHash,
+			LatestPodProof:      proved.proof,
+			LatestPublicWitness: proved.witness,
+			Votes:               make(map[string]shared.Votes),
+			TracksAppHash:       shared.PodHash(proved.witness, proved.proof, proved.mrh, rawCurrentPodNumber),
+			Batch:               proved.batch,
+		}
+		pod, err = lifecycle.Fire(pod, shared.TxStateInitVRF, "")
+		CheckErrorAndExit(err, "Error in starting the lifecycle of the new pod", 0)
+		shared.SetPodState(pod)
 	} else {
-		trackAppHash = podStateData.TracksAppHash
-		witness = podStateData.LatestPublicWitness
-		uZKP = podStateData.LatestPodProof
-		MRH = podStateData.LatestPodHash
-		pMRH := podStateData.PreviousPodHash
-		batchInput = podStateData.Batch
-
-		storeNewPodState(trackAppHash, witness, uZKP, pMRH, MRH, uint64(batchNumber), batchInput, txState)
+		// resume the pod where its last transition left it
+		log.Info().Str("module", "p2p").Msg(fmt.Sprintf("Resuming Pod Number %d at %s", podStateData.LatestPodHeight, lifecycle.State()))
+		shared.SetPodState(podStateData)
 	}
 
+	// prove the pods after this one while it takes its junction steps
+	current := shared.GetPodState()
+	pipeline.prepare(podPosition{
+		startIndex: shared.PodTxnEnd(current.Batch, int(current.LatestPodHeight), baseCfg.Station.GetPodSize()),
+		batchCount: int(current.LatestPodHeight),
+	})
 	selectedMaster := MasterTracksSelection(Node, string(previousTrackAppHash))
 	decodedMaster, err := peer.Decode(selectedMaster)
 	CheckErrorAndExit(err, "Error in decoding master", 0)
@@ -130,256 +138,10 @@ func GenerateUnverifiedPods() {
 		Peers := getAllPeers(Node)
 		peerCount := len(Peers)
 		if peerCount == 1 {
-			DaData := shared.GetPodState().Batch.TransactionHash
-			var daDataByte []byte
-			for _, str := range DaData {
-				daDataByte = append(daDataByte, []byte(str)...)
-			}
-			ZkProof := shared.GetPodState().LatestPodProof
-			PodNumber := int(shared.GetPodState().LatestPodHeight)
-
-			finalizeDA := types.FinalizeDA{
-				CompressedHash: DaData,
-				Proof:          ZkProof,
-				PodNumber:      PodNumber,
-			}
-			_, err := json.Marshal(finalizeDA)
-			if err != nil {
-				logs.Log.Error("Error marshaling da data: " + err.Error())
-				return
-			}
-
-			addr, err := junction.GetAddress()
-			if err != nil {
-				logs.Log.Error("Error in getting address")
-				return
-			}
-
-			if shared.GetPodState().LatestTxState == shared.TxStateInitVRF {
-				success, _ := junction.InitVRF()
-				if !success {
-					logs.Log.Error("Failed to Init VRF")
-					return
-				}
-				updateTxState(shared.TxStateVerifyVRF)
-			} else {
-				log.Debug().Str("module", "p2p").Msg("VRF is already initiated, moving to next step")
-			}
-
-			//os.Exit(0)
-
-			if shared.GetPodState().LatestTxState == shared.TxStateVerifyVRF {
-				success := junction.ValidateVRF(addr)
-				if !success {
-					logs.Log.Error("Failed to Validate VRF")
-					return
-				}
-				updateTxState(shared.TxStateSubmitPod)
-
-				// check if VRF is successfully validated
-				var vrfRecord *junctionTypes.VrfRecord
-				vrfRecord = junction.QueryVRF()
-				if vrfRecord == nil {
-					logs.Log.Error("VRF record is nil")
-					return
-				}
-				if !vrfRecord.IsVerified {
-					logs.Log.Error("Verification of VRF is failed, need Voting for correct VRN")
-					return
-				}
-			} else {
-				log.Debug().Str("module", "p2p").Msg("VRF is already validated, moving to next step")
+			if !processPod(ctx, connection) {
+				return // stop sequencer, the step is retried on restart
 			}
-			//os.Exit(0)
-
-			if shared.GetPodState().LatestTxState == shared.TxStateSubmitPod {
-
-				DaBatchSaver := connection.DataAvailabilityDatabaseConnection
-				baseConfig, err := shared.LoadConfig()
-				if err != nil {
-					fmt.Println("Error loading configuration")
-				}
-				Datype := baseConfig.DA.DaType
-				var (
-					daCheck    string
-					daCheckErr error
-				)
-
-				if Datype == "mock" {
-					mdb := connection.MockDatabaseConnection
-
-					for {
-						daCheck, daCheckErr = mock.MockDA(mdb, daDataByte, PodNumber)
-						if daCheckErr != nil {
-							logs.Log.Error("Error in submitting data to DA")
-							logs.Log.Debug("Retrying Mock DA after 10 seconds")
-							time.Sleep(10 * time.Second)
-						} else {
-							break
-						}
-					}
-
-					da := types.DAStruct{
-						DAKey:             daCheck,
-						DAClientName:      "mock-da",
-						BatchNumber:       strconv.Itoa(PodNumber),
-						PreviousStateHash: string(shared.GetPodState().PreviousPodHash),
-						CurrentStateHash:  string(shared.GetPodState().TracksAppHash),
-					}
-
-					daStoreKey := fmt.Sprintf("da-%d", PodNumber)
-					daStoreData, daStoreDataErr := json.Marshal(da)
-					if daStoreDataErr != nil {
-						logs.Log.Debug(fmt.Sprintf("Error in marshaling DA pointer : %s", daStoreDataErr.Error()))
-					}
-
-					storeErr := DaBatchSaver.Put([]byte(daStoreKey), daStoreData, nil)
-					if storeErr != nil {
-						logs.Log.Debug(fmt.Sprintf("Error in saving DA pointer in pod database : %s", storeErr.Error()))
-					}
-
-					log.Info().Str("module", "p2p").Msg("Data Saved in DA")
-
-				} else if Datype == "avail" {
-
-					for {
-						daCheck, daCheckErr = avail.Avail(daDataByte, baseConfig.DA.DaRPC)
-						if daCheckErr != nil {
-							logs.Log.Debug("Error in submitting data to DA " + daCheckErr.Error())
-							logs.Log.Debug("Retrying Avail DA after 10 seconds")
-							time.Sleep(10 * time.Second)
-						} else {
-							break
-						}
-					}
-
-					da := types.DAStruct{
-						DAKey:             daCheck,
-						DAClientName:      "avail-da",
-						BatchNumber:       strconv.Itoa(PodNumber),
-						PreviousStateHash: string(shared.GetPodState().PreviousPodHash),
-						CurrentStateHash:  string(shared.GetPodState().TracksAppHash),
-					}
-
-					daStoreKey := fmt.Sprintf("da-%d", PodNumber)
-					daStoreData, daStoreDataErr := json.Marshal(da)
-					if daStoreDataErr != nil {
-						logs.Log.Debug(fmt.Sprintf("Error in marshaling DA pointer : %s", daStoreDataErr.Error()))
-						return
-					}
-
-					storeErr := DaBatchSaver.Put([]byte(daStoreKey), daStoreData, nil)
-					if storeErr != nil {
-						logs.Log.Debug(fmt.Sprintf("Error in saving DA pointer in pod database : %s", storeErr.Error()))
-						return
-					}
-
-					log.Info().Str("module", "p2p").Msg("Data Saved in DA")
-
-				} else if Datype == "celestia" {
-					for {
-						daCheck, daCheckErr = celestia.Celestia(daDataByte, baseConfig.DA.DaRPC, baseConfig.DA.DaRPC)
-						if daCheckErr != nil {
-							logs.Log.Debug("Error in submitting data to DA " + daCheckErr.Error())
-							logs.Log.Debug("Retrying Celestia DA after 10 seconds")
-							time.Sleep(10 * time.Second)
-						} else {
-							break
-						}
-					}
-
-					da := types.DAStruct{
-						DAKey:             daCheck,
-						DAClientName:      "celestia-da",
-						BatchNumber:       strconv.Itoa(PodNumber),
-						PreviousStateHash: string(shared.GetPodState().PreviousPodHash),
-						CurrentStateHash:  string(shared.GetPodState().TracksAppHash),
-					}
-
-					daStoreKey := fmt.Sprintf("da-%d", PodNumber)
-					daStoreData, daStoreDataErr := json.Marshal(da)
-					if daStoreDataErr != nil {
-						logs.Log.Debug(fmt.Sprintf("Error in marshaling DA pointer : %s", daStoreDataErr.Error()))
-						return
-					}
-
-					storeErr := DaBatchSaver.Put([]byte(daStoreKey), daStoreData, nil)
-					if storeErr != nil {
-						logs.Log.Debug(fmt.Sprintf("Error in saving DA pointer in pod database : %s", storeErr.Error()))
-						return
-					}
-
-					log.Info().Str("module", "p2p").Msg("Data Saved in DA")
-
-				} else if Datype == "eigen" {
-
-					for {
-						daCheck, daCheckErr = eigen.Eigen(daDataByte, baseConfig.DA.DaRPC, baseConfig.DA.DaRPC)
-						if daCheckErr != nil {
-							logs.Log.Debug("Error in submitting data to DA " + daCheckErr.Error())
-							logs.Log.Debug("Retrying Eigen DA after 10 seconds")
-							time.Sleep(10 * time.Second)
-						} else {
-							break
-						}
-					}
-
-					da := types.DAStruct{
-						DAKey:             daCheck,
-						DAClientName:      "eigen-da",
-						BatchNumber:       strconv.Itoa(PodNumber),
-						PreviousStateHash: string(shared.GetPodState().PreviousPodHash),
-						CurrentStateHash:  string(shared.GetPodState().TracksAppHash),
-					}
-
-					daStoreKey := fmt.Sprintf("da-%d", PodNumber)
-					daStoreData, daStoreDataErr := json.Marshal(da)
-					if daStoreDataErr != nil {
-						logs.Log.Debug(fmt.Sprintf("Error in marshaling DA pointer : %s", daStoreDataErr.Error()))
-						return
-					}
-
-					storeErr := DaBatchSaver.Put([]byte(daStoreKey), daStoreData, nil)
-					if storeErr != nil {
-						logs.Log.Debug(fmt.Sprintf("Error in saving DA pointer in pod database : %s", storeErr.Error()))
-						return
-					}
-
-					log.Info().Str("module", "p2p").Msg("Data Saved in DA")
-
-				} else {
-					logs.Log.Error("Unknown layer. Please use 'avail' or 'celestia' as argument.")
-					return
-				}
-
-				// submit pod
-				success := junction.SubmitCurrentPod()
-				if !success {
-					logs.Log.Error("Failed to submit pod")
-					return
-				}
-				updateTxState(shared.TxStateVerifyPod)
-			} else {
-				log.Warn().Str("module", "p2p").Msg("Pod already submitted, moving to next step")
-			}
-			//os.Exit(0)
-
-			// verify pod
-			if shared.GetPodState().LatestTxState == shared.TxStateVerifyPod {
-				success := junction.VerifyCurrentPod()
-				if !success {
-					logs.Log.Error("Failed to Transact Verify pod")
-					return
-				}
-				updateTxState(shared.TxStatePreInit)
-			} else {
-				log.Error().Str("module", "p2p").Msg("Database Error. LatestTxState should equal to TxStatePreInit at this point")
-				log.Error().Str("module", "p2p").Msg("LatestTxState: " + shared.GetPodState().LatestTxState)
-				return // stop sequencer, there is some error
-			}
-
-			saveVerifiedPOD()        // save data to database
-			GenerateUnverifiedPods() // generate next pod
+			GenerateUnverifiedPods(ctx) // generate next pod
 		} else {
 			PodNumber := int(shared.GetPodState().LatestPodHeight)
 			success, addr := junction.InitVRF()
@@ -388,6 +150,7 @@ func GenerateUnverifiedPods() {
 				return
 			}
 			logs.Log.Info("VRF initiated")
+			advancePod(shared.TxStateVerifyVRF, shared.GetPodState().VRFInitiationTxHash)
 
 			// get own address
 			_, _, accountPath, accountName, addressPrefix, tracks, err := junction.GetJunctionDetails()
@@ -442,3 +205,212 @@ func GenerateUnverifiedPods() {
 	}
 
 }
+
+var (
+	podPipelineOnce sync.Once
+	podProvingQueue *podPipeline
+)
+
+// provingPipeline returns the pipeline that proves the pods of the station ahead of their
+// junction steps, creating it on first use.
+func provingPipeline(txnDB store.Store, conf *config.Config) *podPipeline {
+	podPipelineOnce.Do(func() {
+		generate, err := newPodGenerator(txnDB, conf.Station.StationType)
+		CheckErrorAndExit(err, "No pod generator for station type "+conf.Station.StationType, 0)
+		podProvingQueue = newPodPipeline(generate, conf.Station.GetProvingQueue())
+		shared.SetProvenTxnEnd(podProvingQueue.provenTxnEnd)
+	})
+	return podProvingQueue
+}
+
+// atoiTrimmed parses a counter of the static database, which is 0 when it is not set.
+func atoiTrimmed(value []byte) int {
+	n, _ := strconv.Atoi(strings.TrimSpace(string(value)))
+	return n
+}
+
+// advancePod moves the lifecycle of the pod being processed up to txState, recording txHash
+// with the step. It reports whether the lifecycle moved.
+func advancePod(txState string, txHash string) bool {
+	var moved bool
+	err := shared.UpdatePodState(func(pod *shared.PodState) (next *shared.PodState, err error) {
+		next, moved, err = shared.Node.PodLifecycle.Advance(pod, txState, txHash)
+		return next, err
+	})
+	if err != nil {
+		logs.Log.Error(fmt.Sprintf("Error in moving pod to %s: %s", txState, err.Error()))
+	}
+	return moved
+}
+
+// processPod takes the junction steps of a pod this track processes alone, from the tx state its
+// lifecycle is in. Each step first checks whether the junction already has it, so a step whose
+// transaction went through before a restart is recorded instead of sent again. It reports
+// whether the pod was verified and saved, and stops before the next step once ctx is cancelled.
+func processPod(ctx context.Context, connection *shared.Connections) bool {
+	lifecycle := shared.Node.PodLifecycle
+
+	addr, err := junction.GetAddress()
+	if err != nil {
+		logs.Log.Error("Error in getting address")
+		return false
+	}
+
+	for {
+		// a node that is stopping leaves the pod at its last step, which is resumed on restart
+		if ctx.Err() != nil {
+			return false
+		}
+		pod := shared.GetPodState()
+		var next, txHash string
+
+		switch lifecycle.State() {
+		case shared.TxStateInitVRF:
+			success, _ := junction.InitVRF()
+			if !success {
+				logs.Log.Error("Failed to Init VRF")
+				return false
+			}
+			next, txHash = shared.TxStateVerifyVRF, shared.GetPodState().VRFInitiationTxHash
+
+		case shared.TxStateVerifyVRF:
+			if vrfRecord := junction.QueryVRF(); vrfRecord != nil && vrfRecord.IsVerified {
+				log.Debug().Str("module", "p2p").Msg("VRF is already validated, moving to next step")
+			} else {
+				if !junction.ValidateVRF(addr) {
+					logs.Log.Error("Failed to Validate VRF")
+					return false
+				}
+
+				// check if VRF is successfully validated
+				vrfRecord = junction.QueryVRF()
+				if vrfRecord == nil {
+					logs.Log.Error("VRF record is nil")
+					return false
+				}
+				if !vrfRecord.IsVerified {
+					logs.Log.Error("Verification of VRF is failed, need Voting for correct VRN")
+					return false
+				}
+			}
+			next, txHash = shared.TxStateSubmitPod, shared.GetPodState().VRFValidationTxHash
+
+		case shared.TxStateSubmitPod:
+			if !submitPodToDA(ctx, connection, pod) {
+				return false
+			}
+			if junction.QueryPod(pod.LatestPodHeight) != nil {
+				log.Warn().Str("module", "p2p").Msg("Pod already submitted, moving to next step")
+			} else if !junction.SubmitCurrentPod() {
+				logs.Log.Error("Failed to submit pod")
+				return false
+			}
+			next, txHash = shared.TxStateVerifyPod, shared.GetPodState().InitPodTxHash
+
+		case shared.TxStateVerifyPod:
+			if podData := junction.QueryPod(pod.LatestPodHeight); podData != nil && podData.IsVerified {
+				log.Warn().Str("module", "p2p").Msg("Pod already verified, saving it")
+			} else if !junction.VerifyCurrentPod() {
+				logs.Log.Error("Failed to Transact Verify pod")
+				return false
+			}
+			// entering PreInit saves the pod and moves the counters past it
+			next, txHash = shared.TxStatePreInit, shared.GetPodState().VerifyPodTxHash
+
+		default:
+			log.Error().Str("module", "p2p").Msg("Database Error. No pod is being processed in tx state " + lifecycle.State())
+			return false
+		}
+
+		err := shared.UpdatePodState(func(pod *shared.PodState) (*shared.PodState, error) {
+			return lifecycle.Fire(pod, next, txHash)
+		})
+		if err != nil {
+			logs.Log.Error(fmt.Sprintf("Error in moving pod to %s: %s", next, err.Error()))
+			return false
+		}
+		if next == shared.TxStatePreInit {
+			log.Info().Str("module", "p2p").Msg("Present Pod has been saved Locally")
+			return true
+		}
+	}
+}
+
+// submitPodToDA stores the transaction hashes of the pod in the configured DA layer, retrying
+// until it succeeds or ctx is cancelled, and records the DA pointer as da-N. A pod that already has its pointer is
+// not submitted again.
+func submitPodToDA(ctx context.Context, connection *shared.Connections, pod *shared.PodState) bool {
+	DaBatchSaver := connection.DataAvailabilityDatabaseConnection
+	PodNumber := int(pod.LatestPodHeight)
+	daStoreKey := fmt.Sprintf("da-%d", PodNumber)
+	if ok, _ := DaBatchSaver.Has([]byte(daStoreKey)); ok {
+		log.Debug().Str("module", "p2p").Msg("Pod data is already in DA, moving to next step")
+		return true
+	}
+
+	var daDataByte []byte
+	for _, str := range pod.Batch.TransactionHash {
+		daDataByte = append(daDataByte, []byte(str)...)
+	}
+
+	baseConfig, err := shared.LoadConfig()
+	if err != nil {
+		fmt.Println("Error loading configuration")
+		return false
+	}
+
+	var (
+		submit       func() (string, error)
+		daClientName string
+	)
+	switch baseConfig.DA.DaType {
+	case "mock":
+		mdb := connection.MockDatabaseConnection
+		submit, daClientName = func() (string, error) { return mock.MockDA(mdb, daDataByte, PodNumber) }, "mock-da"
+	case "avail":
+		submit, daClientName = func() (string, error) { return avail.Avail(daDataByte, baseConfig.DA.DaRPC) }, "avail-da"
+	case "celestia":
+		submit, daClientName = func() (string, error) {
+			return celestia.Celestia(daDataByte, baseConfig.DA.DaRPC, baseConfig.DA.DaRPC)
+		}, "celestia-da"
+	case "eigen":
+		submit, daClientName = func() (string, error) {
+			return eigen.Eigen(daDataByte, baseConfig.DA.DaRPC, baseConfig.DA.DaRPC)
+		}, "eigen-da"
+	default:
+		logs.Log.Error("Unknown layer. Please use 'avail' or 'celestia' as argument.")
+		return false
+	}
+
+	var daCheck string
+	err = utilis.RetryContext(ctx, func() (err error) {
+		if daCheck, err = submit(); err != nil {
+			log.Warn().Str("module", "p2p").Err(err).Msg("Error in submitting data to " + daClientName + ", retrying")
+		}
+		return err
+	})
+	if err != nil {
+		log.Error().Str("module", "p2p").Err(err).Msg("Stopped submitting data to " + daClientName)
+		return false
+	}
+
+	da := types.DAStruct{
+		DAKey:             daCheck,
+		DAClientName:      daClientName,
+		BatchNumber:       strconv.Itoa(PodNumber),
+		PreviousStateHash: string(pod.PreviousPodHash),
+		CurrentStateHash:  string(pod.TracksAppHash),
+	}
+	daStoreData, err := json.Marshal(da)
+	if err != nil {
+		logs.Log.Error(fmt.Sprintf("Error in marshaling DA pointer : %s", err.Error()))
+		return false
+	}
+	if err = DaBatchSaver.Put([]byte(daStoreKey), daStoreData); err != nil {
+		logs.Log.Error(fmt.Sprintf("Error in saving DA pointer in pod database : %s", err.Error()))
+		return false
+	}
+
+	log.Info().Str("module", "p2p").Msg("Data Saved in DA")
+	return true
+}
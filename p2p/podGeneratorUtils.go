@@ -1,22 +1,21 @@
 package p2p
 
 import (
+	"bytes"
 	"context"
-	"crypto/sha256"
 	"encoding/json"
 	"fmt"
+	"github.com/airchains-network/tracks/blocksync"
 	"github.com/airchains-network/tracks/config"
 	logs "github.com/airchains-network/tracks/log"
 	"github.com/airchains-network/tracks/node/shared"
+	"github.com/airchains-network/tracks/store"
 	"github.com/airchains-network/tracks/types"
+	"github.com/airchains-network/tracks/types/svmTypes"
 	utilis "github.com/airchains-network/tracks/utils"
-	v1 "github.com/airchains-network/tracks/zk/v1EVM"
-	v1Wasm "github.com/airchains-network/tracks/zk/v1WASM"
 	"github.com/rs/zerolog/log"
-	"github.com/syndtr/goleveldb/leveldb"
 	"os"
 	"strconv"
-	"strings"
 	"time"
 )
 
@@ -36,327 +35,405 @@ func CheckErrorAndExit(err error, message string, exitCode int) {
 	}
 }
 
-func GetValueOrDefault(db *leveldb.DB, key []byte, defaultValue []byte) ([]byte, error) {
-	val, err := db.Get(key, nil)
+func GetValueOrDefault(db store.Store, key []byte, defaultValue []byte) ([]byte, error) {
+	val, err := db.Get(key)
 	if err != nil {
 		logs.Log.Warn(fmt.Sprintf("%s not found in static db", string(key)))
-		err = db.Put(key, defaultValue, nil)
+		err = db.Put(key, defaultValue)
 		CheckErrorAndExit(err, fmt.Sprintf("Error in saving %s in static db", string(key)), 0)
 	}
 	return val, nil
 }
 
-func retryGetBalance(address string, blockNumber int, rpcURL string) (string, error) {
-	var balance string
-	err := utilis.Retry(func() error {
-		var err error
-		balance, err = utilis.GetBalance(address, uint64(blockNumber), rpcURL)
-		return err
-	})
-	return balance, err
+// getFinalizedTxn returns the txns-N record at index once the station block it belongs to is final.
+func getFinalizedTxn(ldt store.Store, index int) ([]byte, error) {
+	finalized, err := blocksync.GetFinalizedTxnCount(ldt)
+	if err != nil {
+		return nil, err
+	}
+	if index > finalized {
+		return nil, fmt.Errorf("transaction %d is not finalized yet", index)
+	}
+	txn, err := blocksync.GetTxn(ldt, index)
+	if err == nil && txn == nil {
+		err = fmt.Errorf("transaction %d is not indexed", index)
+	}
+	return txn, err
 }
 
-func retryGetAccountNonce(ctx context.Context, txHash string, blockNumber int, rpcURL string) (string, error) {
-	var nonce string
-	err := utilis.Retry(func() error {
-		var err error
-		nonce, err = utilis.GetAccountNonce(ctx, txHash, uint64(blockNumber), rpcURL)
-		return err
-	})
-	return nonce, err
+// partialPodDue reports whether a pod holding collected transactions, fewer than the pod size, is
+// sealed once the station reached the block time reached. The pod holds the transactions of
+// blocks produced within maxInterval of the block of its first transaction, first. Both times
+// come from the station, so every track seals the same pod however late it starts collecting.
+func partialPodDue(first time.Time, reached time.Time, maxInterval time.Duration, collected int) bool {
+	return maxInterval > 0 && collected > 0 && !first.IsZero() && !reached.Before(first.Add(maxInterval))
 }
 
-func createEVMPOD(ldt *leveldb.DB, batchStartIndex []byte, limit []byte) (witness []byte, unverifiedProof []byte, MRH []byte, podData *types.BatchStruct, err error) {
-	baseConfig, err := shared.LoadConfig()
-	if err != nil {
-		return
+// nextPodTxn waits for the finalized transaction txns-index of a pod that holds collected
+// transactions, the first of them in a station block at first. It returns the transaction and the
+// station time of its block, or nil once partialPodDue seals the pod: when the block of the
+// transaction is past the interval, or when the transaction is not finalized yet and a finalized
+// block is. It stops waiting once ctx is cancelled.
+func nextPodTxn(ctx context.Context, ldt store.Store, index int, first time.Time, maxInterval time.Duration, collected int) ([]byte, time.Time, error) {
+	for {
+		txData, err := getFinalizedTxn(ldt, index)
+		if err == nil {
+			txnTime, err := blocksync.GetTxnTime(ldt, index)
+			if err != nil || partialPodDue(first, txnTime, maxInterval, collected) {
+				return nil, time.Time{}, err
+			}
+			return txData, txnTime, nil
+		}
+		finalizedTime, err := blocksync.GetFinalizedTime(ldt)
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		if partialPodDue(first, finalizedTime, maxInterval, collected) {
+			return nil, time.Time{}, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, time.Time{}, ctx.Err()
+		case <-time.After(1 * time.Second):
+		}
 	}
-	limitInt, _ := strconv.Atoi(strings.TrimSpace(string(limit)))
-
-	batchStartIndexInt, _ := strconv.Atoi(strings.TrimSpace(string(batchStartIndex)))
-
-	var batch types.BatchStruct
-
-	var From []string
-	var To []string
-	var Amounts []string
-	var TransactionHash []string
-	var SenderBalances []string
-	var ReceiverBalances []string
-	var Messages []string
-	var TransactionNonces []string
-	var AccountNonces []string
+}
 
-	for i := batchStartIndexInt; i < (config.PODSize * (limitInt + 1)); i++ {
+// podTransfer is what a pod holds of one station transaction.
+type podTransfer struct {
+	From             string
+	To               string
+	Amount           string
+	TransactionHash  string
+	SenderBalance    string
+	ReceiverBalance  string
+	Message          string
+	TransactionNonce string
+	AccountNonce     string
+}
 
-		findKey := fmt.Sprintf("txns-%d", i+1)
-		txData, err := ldt.Get([]byte(findKey), nil)
+// transferDecoder returns the pod transfer of the transaction record stored as txns-index, or nil
+// if the transaction does not go into pods.
+type transferDecoder func(ctx context.Context, index int, txn []byte) (*podTransfer, error)
+
+// podProver proves a pod batch as pod number podNumber; it is the GenerateProof of the station's
+// zk package.
+type podProver func(batch types.BatchStruct, podNumber int, podSize int) (any, string, []byte, error)
+
+// collectPod collects the pod that starts after txns-startIndex from the finalized transactions:
+// pod size transfers, or fewer once partialPodDue seals it. It stops waiting for transactions
+// when ctx is cancelled.
+func collectPod(ctx context.Context, ldt store.Store, station *config.StationConfig, startIndex int, decode transferDecoder) (*types.BatchStruct, error) {
+	podSize := station.GetPodSize()
+	batch := &types.BatchStruct{}
+	var first time.Time // station time of the block of the first transaction in the pod
+	txnIndex := startIndex
+	for batch.TxnCount < podSize {
+		txData, txnTime, err := nextPodTxn(ctx, ldt, txnIndex+1, first, station.MaxPodInterval, batch.TxnCount)
 		if err != nil {
-			i--
-			time.Sleep(1 * time.Second)
-			continue
+			return nil, err
 		}
-		var tx types.TransactionStruct
-		err = json.Unmarshal(txData, &tx)
-		if err != nil {
-			logs.Log.Error(fmt.Sprintf("Error in unmarshalling tx data : %s", err.Error()))
-			os.Exit(0)
+		if txData == nil {
+			log.Info().Str("module", "p2p").Msg(fmt.Sprintf("Sealing pod with %d of %d transactions after %s of station time", batch.TxnCount, podSize, station.MaxPodInterval))
+			break
 		}
+		txnIndex++
 
-		senderBalancesCheck, err := retryGetBalance(tx.From, int(tx.BlockNumber-1), baseConfig.Station.StationRPC)
+		transfer, err := decode(ctx, txnIndex, txData)
 		if err != nil {
-			logs.Log.Error(fmt.Sprintf("Error in getting sender balance : %s", err.Error()))
+			return nil, fmt.Errorf("transaction %d: %w", txnIndex, err)
 		}
-
-		receiverBalancesCheck, err := retryGetBalance(tx.To, int(tx.BlockNumber-1), baseConfig.Station.StationRPC)
-		if err != nil {
-			logs.Log.Error(fmt.Sprintf("Error in getting reciver balance : %s", err.Error()))
+		if transfer == nil {
+			continue
 		}
-
-		accountNonceCheck, err := retryGetAccountNonce(context.Background(), tx.Hash, int(tx.BlockNumber), baseConfig.Station.StationRPC)
-		if err != nil {
-			logs.Log.Error(fmt.Sprintf("Error in getting account nonce : %s", err.Error()))
+		batch.From = append(batch.From, transfer.From)
+		batch.To = append(batch.To, transfer.To)
+		batch.Amounts = append(batch.Amounts, transfer.Amount)
+		batch.TransactionHash = append(batch.TransactionHash, transfer.TransactionHash)
+		batch.SenderBalances = append(batch.SenderBalances, transfer.SenderBalance)
+		batch.ReceiverBalances = append(batch.ReceiverBalances, transfer.ReceiverBalance)
+		batch.Messages = append(batch.Messages, transfer.Message)
+		batch.TransactionNonces = append(batch.TransactionNonces, transfer.TransactionNonce)
+		batch.AccountNonces = append(batch.AccountNonces, transfer.AccountNonce)
+		if batch.TxnCount == 0 {
+			first = txnTime
 		}
-
-		From = append(From, tx.From)
-		To = append(To, tx.To)
-		Amounts = append(Amounts, tx.Value)
-		TransactionHash = append(TransactionHash, tx.Hash)
-		SenderBalances = append(SenderBalances, senderBalancesCheck)
-		ReceiverBalances = append(ReceiverBalances, receiverBalancesCheck)
-		Messages = append(Messages, tx.Input)
-		TransactionNonces = append(TransactionNonces, tx.Nonce)
-		AccountNonces = append(AccountNonces, accountNonceCheck)
-	}
-
-	batch.From = From
-	batch.To = To
-	batch.Amounts = Amounts
-	batch.TransactionHash = TransactionHash
-	batch.SenderBalances = SenderBalances
-	batch.ReceiverBalances = ReceiverBalances
-	batch.Messages = Messages
-	batch.TransactionNonces = TransactionNonces
-	batch.AccountNonces = AccountNonces
-	witnessVector, currentStatusHash, proofByte, pkErr := v1.GenerateProof(batch, limitInt+1)
-	if pkErr != nil {
-		logs.Log.Error(fmt.Sprintf("Error in generating proof : %s", pkErr.Error()))
-		return nil, nil, nil, nil, pkErr
+		batch.TxnCount++
 	}
-	log.Info().Str("module", "p2p").Str("Pod Number", strconv.Itoa(limitInt+1)).Msg("Successfully generated  Unverified proof")
+	batch.TxnEndIndex = txnIndex
+	return batch, nil
+}
 
-	// marshal witnessVector
-	witnessVectorByte, err := json.Marshal(witnessVector)
+// createPod collects and proves the pod that starts after txns-startIndex, with batchCount pods
+// before it. It returns the marshalled witness, the proof and the marshalled pod state hash.
+func createPod(ctx context.Context, ldt store.Store, startIndex int, batchCount int, transfers func(store.Store, *config.StationConfig) transferDecoder, prove podProver) (witness []byte, proof []byte, mrh []byte, batch *types.BatchStruct, err error) {
+	baseConfig, err := shared.LoadConfig()
 	if err != nil {
-		logs.Log.Error(fmt.Sprintf("Error in marshalling witness vector : %s", err.Error()))
+		return nil, nil, nil, nil, err
 	}
-
-	// string to []byte currentStatusHash
-	currentStatusHashByte, err := json.Marshal(currentStatusHash)
+	batch, err = collectPod(ctx, ldt, baseConfig.Station, startIndex, transfers(ldt, baseConfig.Station))
 	if err != nil {
-		logs.Log.Error(fmt.Sprintf("Error in marshalling current status hash : %s", err.Error()))
-		os.Exit(0)
+		return nil, nil, nil, nil, err
+	}
+	if err = ctx.Err(); err != nil {
+		return nil, nil, nil, nil, err
 	}
 
-	return witnessVectorByte, proofByte, currentStatusHashByte, &batch, nil
-}
-func createWasmPOD(ldt *leveldb.DB, batchStartIndex []byte, limit []byte) (witness []byte, unverifiedProof []byte, MRH []byte, podData *types.BatchStruct, err error) {
-	baseConfig, err := shared.LoadConfig()
+	witnessVector, currentStatusHash, proof, err := prove(*batch, batchCount+1, baseConfig.Station.GetPodSize())
 	if err != nil {
-		return
+		logs.Log.Error(fmt.Sprintf("Error in generating proof : %s", err.Error()))
+		return nil, nil, nil, nil, err
 	}
-	limitInt, _ := strconv.Atoi(strings.TrimSpace(string(limit)))
-	batchStartIndexInt, _ := strconv.Atoi(strings.TrimSpace(string(batchStartIndex)))
+	log.Info().Str("module", "p2p").Str("Pod Number", strconv.Itoa(batchCount+1)).Msg("Successfully generated  Unverified proof")
 
-	var batch types.BatchStruct
+	if witness, err = json.Marshal(witnessVector); err != nil {
+		return nil, nil, nil, nil, err
+	}
+	if mrh, err = json.Marshal(currentStatusHash); err != nil {
+		return nil, nil, nil, nil, err
+	}
+	return witness, proof, mrh, batch, nil
+}
 
-	var From []string
-	var To []string
-	var Amounts []string
-	var TransactionHash []string
-	var SenderBalances []string
-	var ReceiverBalances []string
-	var Messages []string
-	var TransactionNonces []string
-	var AccountNonces []string
+// evmTransfers decodes EVM transaction records into pod transfers, skipping reverted ones.
+func evmTransfers(ldt store.Store, station *config.StationConfig) transferDecoder {
+	endpoints := blocksync.StationRPCEndpoints(station)
+	return func(ctx context.Context, index int, txn []byte) (*podTransfer, error) {
+		var tx types.TransactionStruct
+		if err := json.Unmarshal(txn, &tx); err != nil {
+			return nil, err
+		}
+		if tx.Status == types.TxStatusReverted {
+			log.Info().Str("module", "p2p").Msg(fmt.Sprintf("Skipping reverted transaction %s", tx.Hash))
+			return nil, nil
+		}
 
-	for i := batchStartIndexInt; i < (config.PODSize * (limitInt + 1)); i++ {
-		findKey := fmt.Sprintf("txns-%d", i+1)
-		txData, err := ldt.Get([]byte(findKey), nil)
+		senderBalance, receiverBalance, accountNonce := evmPreState(ctx, ldt, index, &tx, endpoints)
+		return &podTransfer{
+			From:             tx.From,
+			To:               tx.To,
+			Amount:           tx.Value,
+			TransactionHash:  tx.Hash,
+			SenderBalance:    senderBalance,
+			ReceiverBalance:  receiverBalance,
+			Message:          tx.Input,
+			TransactionNonce: tx.Nonce,
+			AccountNonce:     accountNonce,
+		}, nil
+	}
+}
 
-		if err != nil {
-			i--
-			time.Sleep(1 * time.Second)
-			continue
+// wasmTransfers decodes the bank sends of WASM transaction records into pod transfers.
+func wasmTransfers(_ store.Store, station *config.StationConfig) transferDecoder {
+	endpoints := blocksync.StationAPIEndpoints(station)
+	return func(ctx context.Context, index int, txn []byte) (*podTransfer, error) {
+		var tx types.BatchTransaction
+		if err := json.Unmarshal(txn, &tx); err != nil {
+			return nil, err
+		}
+		if len(tx.Tx.Body.Messages) == 0 || len(tx.Tx.Body.Messages[0].Amount) == 0 {
+			return nil, nil
 		}
+		message := tx.Tx.Body.Messages[0]
 
-		var txn types.BatchTransaction
-		err = json.Unmarshal(txData, &txn)
+		senderBalance, receiverBalance, accountNonce, err := wasmPreState(ctx, &tx, endpoints)
 		if err != nil {
-			logs.Log.Info(fmt.Sprintf("Error in unmarshalling tx data : %s", err.Error()))
+			return nil, err
 		}
-		fromCheck := utilis.Bech32Decoder(txn.Tx.Body.Messages[0].FromAddress)
-		toCheck := utilis.Bech32Decoder(txn.Tx.Body.Messages[0].ToAddress)
-		transactionHashCheck := utilis.TXHashCheck(txn.TxResponse.TxHash)
-
-		senderBalancesCheck := utilis.AccountBalanceCheck(txn.Tx.Body.Messages[0].FromAddress, txn.TxResponse.Height, baseConfig.Station.StationAPI)
-		receiverBalancesCheck := utilis.AccountBalanceCheck(txn.Tx.Body.Messages[0].ToAddress, txn.TxResponse.Height, baseConfig.Station.StationAPI)
-		accountNoncesCheck := utilis.AccountNounceCheck(txn.Tx.Body.Messages[0].FromAddress, baseConfig.Station.StationAPI)
-
-		From = append(From, fromCheck)
-		To = append(To, toCheck)
-		Amounts = append(Amounts, txn.Tx.Body.Messages[0].Amount[0].Amount)
-		SenderBalances = append(SenderBalances, senderBalancesCheck)
-		ReceiverBalances = append(ReceiverBalances, receiverBalancesCheck)
-		TransactionHash = append(TransactionHash, transactionHashCheck)
-		Messages = append(Messages, fmt.Sprint(txn.Tx.Body.Messages[0]))
-		TransactionNonces = append(TransactionNonces, "0")
-		AccountNonces = append(AccountNonces, accountNoncesCheck)
+		return &podTransfer{
+			From:             utilis.Bech32Decoder(message.FromAddress),
+			To:               utilis.Bech32Decoder(message.ToAddress),
+			Amount:           message.Amount[0].Amount,
+			TransactionHash:  utilis.TXHashCheck(tx.TxResponse.TxHash),
+			SenderBalance:    senderBalance,
+			ReceiverBalance:  receiverBalance,
+			Message:          fmt.Sprint(message),
+			TransactionNonce: "0",
+			AccountNonce:     accountNonce,
+		}, nil
 	}
+}
 
-	batch.From = From
-	batch.To = To
-	batch.Amounts = Amounts
-	batch.TransactionHash = TransactionHash
-	batch.SenderBalances = SenderBalances
-	batch.ReceiverBalances = ReceiverBalances
-	batch.Messages = Messages
-	batch.TransactionNonces = TransactionNonces
-	batch.AccountNonces = AccountNonces
-
-	// add prover here
-	witnessVector, currentStatusHash, proofByte, pkErr := v1Wasm.GenerateProof(batch, limitInt+1)
-	if pkErr != nil {
-		logs.Log.Error("Error in generating proof : %s" + pkErr.Error())
-		os.Exit(0)
-	}
+func retryGetBalance(ctx context.Context, address string, blockNumber int, endpoints *utilis.EndpointPool) (string, error) {
+	var balance string
+	err := utilis.RetryContext(ctx, func() error {
+		return endpoints.Do(ctx, func(rpcURL string) (err error) {
+			balance, err = utilis.GetBalance(address, uint64(blockNumber), rpcURL)
+			return err
+		})
+	})
+	return balance, err
+}
 
-	witnessVectorByte, err := json.Marshal(witnessVector)
-	if err != nil {
-		logs.Log.Error(fmt.Sprintf("Error in marshalling witness vector : %s", err.Error()))
-	}
+func retryGetAccountNonce(ctx context.Context, txHash string, blockNumber int, endpoints *utilis.EndpointPool) (string, error) {
+	var nonce string
+	err := utilis.RetryContext(ctx, func() error {
+		return endpoints.Do(ctx, func(rpcURL string) (err error) {
+			nonce, err = utilis.GetAccountNonce(ctx, txHash, uint64(blockNumber), rpcURL)
+			return err
+		})
+	})
+	return nonce, err
+}
 
-	// string to []byte currentStatusHash
-	currentStatusHashByte, err := json.Marshal(currentStatusHash)
+// evmPreState returns the sender balance, the receiver balance and the account nonce of the
+// transaction stored as txns-index. They come from the pre-state recorded by the local ledger,
+// or from the station state for the accounts the ledger has not applied the transaction to or
+// no longer vouches for.
+func evmPreState(ctx context.Context, ldt store.Store, index int, tx *types.TransactionStruct, endpoints *utilis.EndpointPool) (string, string, string) {
+	pre, err := blocksync.GetLedgerPreState(ldt, index)
 	if err != nil {
-		logs.Log.Error(fmt.Sprintf("Error in marshalling current status hash : %s", err.Error()))
-		os.Exit(0)
+		logs.Log.Error(fmt.Sprintf("Error in getting ledger pre-state : %s", err.Error()))
+	}
+	if pre == nil {
+		log.Warn().Str("module", "p2p").Msg(fmt.Sprintf("Transaction %d is not in the ledger, reading its pre-state from the station", index))
+		pre = &blocksync.LedgerPreState{
+			SenderAccount:   blocksync.LedgerAccount{Drifted: true},
+			Receiver:        tx.To,
+			ReceiverAccount: blocksync.LedgerAccount{Drifted: true},
+		}
 	}
 
-	return witnessVectorByte, proofByte, currentStatusHashByte, &batch, nil
-
-}
-func saveVerifiedPOD() {
-
-	podState := shared.GetPodState()
-	batchTimestamp := time.Now()
-	podState.Timestamp = &batchTimestamp
-	currentPodNumber := podState.LatestPodHeight
-	currentPodNumberInt := int(currentPodNumber)
-
-	lds := shared.Node.NodeConnections.GetStaticDatabaseConnection()
-
-	err := lds.Put([]byte("batchStartIndex"), []byte(strconv.Itoa(config.PODSize*(currentPodNumberInt))), nil)
-	if err != nil {
-		logs.Log.Error(fmt.Sprintf("Error in updating batchStartIndex in static db : %s", err.Error()))
-		os.Exit(0)
+	senderBalance, accountNonce := pre.SenderAccount.Balance, strconv.FormatUint(pre.SenderAccount.Nonce, 10)
+	if pre.SenderAccount.Drifted {
+		if senderBalance, err = retryGetBalance(ctx, tx.From, int(tx.BlockNumber-1), endpoints); err != nil {
+			logs.Log.Error(fmt.Sprintf("Error in getting sender balance : %s", err.Error()))
+		}
+		if accountNonce, err = retryGetAccountNonce(ctx, tx.Hash, int(tx.BlockNumber), endpoints); err != nil {
+			logs.Log.Error(fmt.Sprintf("Error in getting account nonce : %s", err.Error()))
+		}
 	}
 
-	err = lds.Put([]byte("batchCount"), []byte(strconv.Itoa(currentPodNumberInt)), nil)
-	if err != nil {
-		logs.Log.Error(fmt.Sprintf("Error in updating batchCount in static db : %s", err.Error()))
-		os.Exit(0)
+	receiverBalance := "0"
+	if pre.Receiver != "" {
+		receiverBalance = pre.ReceiverAccount.Balance
+		if pre.ReceiverAccount.Drifted {
+			if receiverBalance, err = retryGetBalance(ctx, tx.To, int(tx.BlockNumber-1), endpoints); err != nil {
+				logs.Log.Error(fmt.Sprintf("Error in getting reciver balance : %s", err.Error()))
+			}
+		}
 	}
+	return senderBalance, receiverBalance, accountNonce
+}
 
-	batchDB := shared.Node.NodeConnections.GetPodsDatabaseConnection()
-	podKey := fmt.Sprintf("pod-%d", currentPodNumberInt)
+// wasmPreState returns the sender balance, the receiver balance and the sender's account number
+// of a bank transfer, read from the station API at the block of the transaction.
+func wasmPreState(ctx context.Context, txn *types.BatchTransaction, endpoints *utilis.EndpointPool) (string, string, string, error) {
+	message := txn.Tx.Body.Messages[0]
+	var senderBalance, receiverBalance, accountNonce string
+	err := utilis.RetryContext(ctx, func() error {
+		return endpoints.Do(ctx, func(stationAPI string) (err error) {
+			if senderBalance, err = utilis.AccountBalanceCheck(ctx, message.FromAddress, txn.TxResponse.Height, stationAPI); err != nil {
+				return err
+			}
+			if receiverBalance, err = utilis.AccountBalanceCheck(ctx, message.ToAddress, txn.TxResponse.Height, stationAPI); err != nil {
+				return err
+			}
+			accountNonce, err = utilis.AccountNounceCheck(ctx, message.FromAddress, stationAPI)
+			return err
+		})
+	})
+	return senderBalance, receiverBalance, accountNonce, err
+}
 
-	batchInputWithTimestampBytes, err := json.Marshal(podState)
-	if err != nil {
-		logs.Log.Error(fmt.Sprintf("Error in marshalling batch data : %s", err.Error()))
-		os.Exit(0)
+// svmTransfer returns the first system program transfer of an SVM transaction, or false if the
+// transaction failed or transfers no lamports.
+func svmTransfer(tx *svmTypes.SVMTransactionStruct) (source string, destination string, lamports uint64, ok bool) {
+	if tx.Meta.Err != nil || len(tx.Transaction.Signatures) == 0 {
+		return "", "", 0, false
 	}
-	err = batchDB.Put([]byte(podKey), batchInputWithTimestampBytes, nil)
-	if err != nil {
-		panic("Failed to update pod data: " + err.Error())
+	for _, instruction := range tx.Transaction.Message.Instructions {
+		if instruction.Program == "system" && instruction.Parsed.Type == "transfer" {
+			info := instruction.Parsed.Info
+			return info.Source, info.Destination, info.Lamports, true
+		}
 	}
-	podState.MasterTrackAppHash = nil
-	shared.SetPodState(podState)
-
-	log.Info().Str("module", "p2p").Msg("Present Pod has been saved Locally")
-}
-func generatePodHash(Witness, uZKP, MRH []byte, podNumber []byte) []byte {
-	hash := sha256.New()
-	hash.Write(Witness)
-	hash.Write(uZKP)
-	hash.Write(MRH)
-	hash.Write(podNumber)
-	return hash.Sum(nil)
+	return "", "", 0, false
 }
-func storeNewPodState(CombinedPodHash, Witness, uZKP, previousMRH, MRH []byte, podNumber uint64, batchInput *types.BatchStruct, txState string) {
-	var podState *shared.PodState
-	votes := make(map[string]shared.Votes)
-	podState = &shared.PodState{
-		LatestPodHeight:     podNumber,
-		LatestTxState:       txState,
-		LatestPodHash:       MRH,
-		PreviousPodHash:     previousMRH,
-		LatestPodProof:      uZKP,
-		LatestPublicWitness: Witness,
-		Votes:               votes,
-		TracksAppHash:       CombinedPodHash,
-		Batch:               batchInput,
+
+// svmLamports formats a lamports value decoded from JSON, a json.Number or a float64.
+func svmLamports(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case json.Number:
+		return v.String(), true
+	case float64:
+		return strconv.FormatUint(uint64(v), 10), true
 	}
-	shared.SetPodState(podState)
-	updatePodStateInDatabase(podState)
+	return "", false
 }
-func updateNewPodState(CombinedPodHash, Witness, uZKP, MRH []byte, podNumber uint64, batchInput *types.BatchStruct, txState string) {
-	var podState *shared.PodState
-	votes := make(map[string]shared.Votes)
-	podState = &shared.PodState{
-		LatestPodHeight:     podNumber,
-		LatestTxState:       txState,
-		LatestPodHash:       MRH,
-		PreviousPodHash:     shared.GetPodState().LatestPodHash,
-		LatestPodProof:      uZKP,
-		LatestPublicWitness: Witness,
-		Votes:               votes,
-		TracksAppHash:       CombinedPodHash,
-		Batch:               batchInput,
+
+// svmPreState returns the sender and receiver balances before the transaction, from the
+// pre-balances the station recorded with it. The current account state of the station is not the
+// state before the transaction, so a transaction without them fails its pod.
+func svmPreState(tx *svmTypes.SVMTransactionStruct, source string, destination string) (string, string, error) {
+	preBalances := make(map[string]string)
+	for i, key := range tx.Transaction.Message.AccountKeys {
+		if i >= len(tx.Meta.PreBalances) {
+			break
+		}
+		if balance, ok := svmLamports(tx.Meta.PreBalances[i]); ok {
+			preBalances[key.Pubkey] = balance
+		}
 	}
-	shared.SetPodState(podState)
-	updatePodStateInDatabase(podState)
-}
-func updateTxState(txState string) {
-	podState := shared.GetPodState()
-	podState.LatestTxState = txState
-	shared.SetPodState(podState)
-	updatePodStateInDatabase(podState)
+	senderBalance, senderOk := preBalances[source]
+	receiverBalance, receiverOk := preBalances[destination]
+	if !senderOk || !receiverOk {
+		return "", "", fmt.Errorf("transaction %s carries no pre-balances of %s and %s", tx.Transaction.Signatures[0], source, destination)
+	}
+	return senderBalance, receiverBalance, nil
 }
-func updatePodStateInDatabase(podState *shared.PodState) {
-	stateConnection := shared.Node.NodeConnections.GetStateDatabaseConnection()
 
-	podStateByte, err := json.Marshal(podState)
-	if err != nil {
-		logs.Log.Error(err.Error())
-		os.Exit(0)
-	}
+// svmTransfers decodes SVM transaction records into pod transfers. Public keys and signatures
+// enter the circuit as field elements, see utilis.Base58Decoder.
+func svmTransfers(store.Store, *config.StationConfig) transferDecoder {
+	return func(ctx context.Context, index int, txn []byte) (*podTransfer, error) {
+		// balances are lamports up to 2^64, keep them exact
+		var tx svmTypes.SVMTransactionStruct
+		decoder := json.NewDecoder(bytes.NewReader(txn))
+		decoder.UseNumber()
+		if err := decoder.Decode(&tx); err != nil {
+			return nil, err
+		}
+		source, destination, lamports, ok := svmTransfer(&tx)
+		if !ok {
+			// vote and program transactions move no lamports between accounts
+			return nil, nil
+		}
 
-	err = stateConnection.Put([]byte("podState"), podStateByte, nil)
-	if err != nil {
-		logs.Log.Error(err.Error())
+		signature := tx.Transaction.Signatures[0]
+		transfer := &podTransfer{
+			Amount:           strconv.FormatUint(lamports, 10),
+			Message:          signature,
+			TransactionNonce: "0",
+			AccountNonce:     "0",
+		}
+		for _, field := range []struct {
+			value  string
+			target *string
+		}{{source, &transfer.From}, {destination, &transfer.To}, {signature, &transfer.TransactionHash}} {
+			element, err := utilis.Base58Decoder(field.value)
+			if err != nil {
+				return nil, err
+			}
+			*field.target = element
+		}
+		var err error
+		if transfer.SenderBalance, transfer.ReceiverBalance, err = svmPreState(&tx, source, destination); err != nil {
+			return nil, err
+		}
+		return transfer, nil
 	}
 }
+
 func GetPodStateFromDatabase() (*types.PodState, error) {
-	var podStateData *types.PodState
 	stateConnection := shared.Node.NodeConnections.GetStateDatabaseConnection()
 
-	podStateDataByte, err := stateConnection.Get([]byte("podState"), nil)
+	podStateDataByte, err := stateConnection.Get([]byte("podState"))
 	if err != nil {
 		logs.Log.Error("error in getting pod state data from database")
 		return nil, err
 	}
-	err = json.Unmarshal(podStateDataByte, &podStateData)
+	podStateData, err := types.UnmarshalPodState(podStateDataByte)
 	if err != nil {
 		logs.Log.Error("error in unmarshal pod state data")
 		return nil, err
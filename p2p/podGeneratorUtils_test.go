@@ -0,0 +1,185 @@
+package p2p
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/airchains-network/tracks/config"
+	"github.com/airchains-network/tracks/store"
+	"github.com/airchains-network/tracks/types"
+)
+
+// testTxnDB returns a txn database holding the finalized transaction records txns, each in a
+// station block one second after the one before.
+func testTxnDB(t *testing.T, txns ...string) store.Store {
+	t.Helper()
+	ldt := store.NewMemory()
+	for i, txn := range txns {
+		finalizeTestTxn(t, ldt, i+1, int64(i+1), txn)
+	}
+	return ldt
+}
+
+// finalizeTestTxn stores txn as the txns-index record of a finalized block at unix time blockTime.
+func finalizeTestTxn(t *testing.T, ldt store.Store, index int, blockTime int64, txn string) {
+	t.Helper()
+	// the watermarks go last, as the indexer writes them after the records
+	for _, entry := range [][2]string{
+		{fmt.Sprintf("txnTime-%d", index), fmt.Sprint(blockTime)},
+		{fmt.Sprintf("txns-%d", index), txn},
+		{"finalizedTxnCount", fmt.Sprint(index)},
+		{"finalizedTime", fmt.Sprint(blockTime)},
+	} {
+		if err := ldt.Put([]byte(entry[0]), []byte(entry[1])); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestPartialPodDue(t *testing.T) {
+	first := time.Unix(100, 0)
+	tests := []struct {
+		name        string
+		first       time.Time
+		reached     time.Time
+		maxInterval time.Duration
+		collected   int
+		want        bool
+	}{
+		{"within the interval", first, time.Unix(102, 0), 3 * time.Second, 1, false},
+		{"at the interval", first, time.Unix(103, 0), 3 * time.Second, 1, true},
+		{"past the interval", first, time.Unix(110, 0), 3 * time.Second, 2, true},
+		{"empty pod", first, time.Unix(110, 0), 3 * time.Second, 0, false},
+		{"sealing disabled", first, time.Unix(110, 0), 0, 1, false},
+		{"no first block time", time.Time{}, time.Unix(110, 0), 3 * time.Second, 1, false},
+		{"no station time", first, time.Time{}, 3 * time.Second, 1, false},
+	}
+	for _, tt := range tests {
+		if got := partialPodDue(tt.first, tt.reached, tt.maxInterval, tt.collected); got != tt.want {
+			t.Errorf("%s: partialPodDue is %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNextPodTxn(t *testing.T) {
+	ldt := testTxnDB(t, "a", "b", "c", "d", "e")
+	// a finalized block without transactions past the interval of b
+	if err := ldt.Put([]byte("finalizedTime"), []byte("9")); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name      string
+		index     int
+		first     int64
+		collected int
+		want      string
+	}{
+		{"first transaction of a pod", 1, 0, 0, "a"},
+		{"within the interval", 3, 2, 1, "c"},
+		{"block past the interval", 5, 2, 2, ""},
+		{"finalized block past the interval", 6, 2, 3, ""},
+	}
+	for _, tt := range tests {
+		var first time.Time
+		if tt.first > 0 {
+			first = time.Unix(tt.first, 0)
+		}
+		txn, txnTime, err := nextPodTxn(context.Background(), ldt, tt.index, first, 3*time.Second, tt.collected)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if string(txn) != tt.want {
+			t.Errorf("%s: next transaction is %q, want %q", tt.name, txn, tt.want)
+		}
+		if txn != nil && txnTime.Unix() != int64(tt.index) {
+			t.Errorf("%s: transaction is at station time %d, want %d", tt.name, txnTime.Unix(), tt.index)
+		}
+	}
+}
+
+// testTransfers moves the record itself, skips "skip" records and fails on "bad" records.
+func testTransfers(ctx context.Context, index int, txn []byte) (*podTransfer, error) {
+	switch string(txn) {
+	case "skip":
+		return nil, nil
+	case "bad":
+		return nil, errors.New("undecodable")
+	}
+	return &podTransfer{From: string(txn), TransactionHash: string(txn)}, nil
+}
+
+func TestCollectPod(t *testing.T) {
+	ldt := testTxnDB(t, "a", "skip", "b", "c", "d", "bad")
+	station := &config.StationConfig{PodSize: 2}
+
+	batch, err := collectPod(context.Background(), ldt, station, 0, testTransfers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(batch.From, ",") != "a,b" || batch.TxnCount != 2 || batch.TxnEndIndex != 3 {
+		t.Errorf("first pod holds %v, %d transfers up to txns-%d", batch.From, batch.TxnCount, batch.TxnEndIndex)
+	}
+	if len(batch.AccountNonces) != 2 || len(batch.Messages) != 2 {
+		t.Error("pod fields are not collected for every transfer")
+	}
+
+	// a partial pod is sealed once a finalized block is past the max pod interval, even one
+	// without transactions
+	station = &config.StationConfig{PodSize: 3, MaxPodInterval: 2 * time.Second}
+	partial := testTxnDB(t, "a", "skip", "b", "c", "d")
+	if err = partial.Put([]byte("finalizedTime"), []byte("6")); err != nil {
+		t.Fatal(err)
+	}
+	batch, err = collectPod(context.Background(), partial, station, 3, testTransfers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(batch.From, ",") != "c,d" || batch.TxnEndIndex != 5 {
+		t.Errorf("partial pod holds %v up to txns-%d", batch.From, batch.TxnEndIndex)
+	}
+
+	if _, err = collectPod(context.Background(), ldt, station, 5, testTransfers); err == nil || !strings.Contains(err.Error(), "transaction 6") {
+		t.Errorf("collecting an undecodable transaction returned %v", err)
+	}
+}
+
+func TestCollectPodSealsByStationTime(t *testing.T) {
+	station := &config.StationConfig{PodSize: 3, MaxPodInterval: 3 * time.Second}
+	ldt := testTxnDB(t, "a", "skip", "b", "c")
+
+	// one generator starts collecting while the pod has a single transaction
+	early := make(chan *types.BatchStruct, 1)
+	go func() {
+		batch, err := collectPod(context.Background(), ldt, station, 3, testTransfers)
+		if err != nil {
+			t.Error(err)
+		}
+		early <- batch
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	// the station finalizes d within the interval of c and e past it, then a second generator
+	// starts on the same transactions
+	finalizeTestTxn(t, ldt, 5, 5, "d")
+	finalizeTestTxn(t, ldt, 6, 7, "e")
+	late, err := collectPod(context.Background(), ldt, station, 3, testTransfers)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var batch *types.BatchStruct
+	select {
+	case batch = <-early:
+	case <-time.After(10 * time.Second):
+		t.Fatal("the early generator did not seal its pod")
+	}
+	for _, b := range []*types.BatchStruct{batch, late} {
+		if b == nil || strings.Join(b.From, ",") != "c,d" || b.TxnEndIndex != 5 {
+			t.Fatalf("generators sealed %+v and %+v, want both to hold c,d up to txns-5", batch, late)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package p2p
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/airchains-network/tracks/config"
+	"github.com/airchains-network/tracks/store"
+	"github.com/airchains-network/tracks/types"
+	v1 "github.com/airchains-network/tracks/zk/v1EVM"
+	v1Svm "github.com/airchains-network/tracks/zk/v1SVM"
+	v1Wasm "github.com/airchains-network/tracks/zk/v1WASM"
+	"strings"
+	"sync"
+)
+
+// errPipelineStopped is returned by take when it stops waiting for a pod.
+var errPipelineStopped = errors.New("pod pipeline stopped")
+
+// podPosition is where a pod starts: the last txns-N record covered by the pods before it and
+// the batchCount recorded before it is generated.
+type podPosition struct {
+	startIndex int
+	batchCount int
+}
+
+// provedPod is a pod generated and proven ahead of its junction steps.
+type provedPod struct {
+	position podPosition
+	witness  []byte
+	proof    []byte
+	mrh      []byte
+	batch    *types.BatchStruct
+	err      error
+}
+
+// next returns the position of the pod after p.
+func (p *provedPod) next() podPosition {
+	return podPosition{startIndex: p.batch.TxnEndIndex, batchCount: p.position.batchCount + 1}
+}
+
+// podGenerateFunc generates and proves the pod at a position. It gives up waiting for the
+// transactions of the pod once ctx is cancelled.
+type podGenerateFunc func(ctx context.Context, position podPosition) (*provedPod, error)
+
+// newPodGenerator returns the pod generator of a station type, which builds pods from the
+// finalized transactions in txnDB.
+func newPodGenerator(txnDB store.Store, stationType string) (podGenerateFunc, error) {
+	var transfers func(store.Store, *config.StationConfig) transferDecoder
+	var prove podProver
+	switch strings.ToLower(stationType) {
+	case "evm":
+		transfers, prove = evmTransfers, v1.GenerateProof
+	case "wasm":
+		transfers, prove = wasmTransfers, v1Wasm.GenerateProof
+	case "svm":
+		transfers, prove = svmTransfers, v1Svm.GenerateProof
+	default:
+		return nil, fmt.Errorf("unsupported station type %q", stationType)
+	}
+	return func(ctx context.Context, position podPosition) (*provedPod, error) {
+		witness, proof, mrh, batch, err := createPod(ctx, txnDB, position.startIndex, position.batchCount, transfers, prove)
+		if err != nil {
+			return nil, err
+		}
+		return &provedPod{position: position, witness: witness, proof: proof, mrh: mrh, batch: batch}, nil
+	}, nil
+}
+
+// podPipeline proves pods ahead of the junction steps. One prover goroutine generates pods in
+// order into a queue holding at most depth proven pods, and take hands them out in the same
+// order, so proving the next pod overlaps the junction round trips of the current one.
+type podPipeline struct {
+	generate podGenerateFunc
+	depth    int
+
+	mu     sync.Mutex
+	head   podPosition // position of the pod the queue returns next
+	queue  chan *provedPod
+	cancel context.CancelFunc // stops the prover of queue
+	proven int                // last txns-N record covered by the pods the prover of queue proved
+}
+
+func newPodPipeline(generate podGenerateFunc, depth int) *podPipeline {
+	return &podPipeline{generate: generate, depth: depth}
+}
+
+// prepare starts proving from position unless the queue already continues there.
+func (p *podPipeline) prepare(position podPosition) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.restartAt(position)
+}
+
+// restartAt returns the queue of the prover that proves from position, replacing the prover
+// when its queue does not continue there, as after a failed pod or a pod number taken from
+// junction. A replaced prover stops waiting for transactions at once and drops the pod it is
+// proving.
+func (p *podPipeline) restartAt(position podPosition) chan *provedPod {
+	if p.queue != nil && p.head == position {
+		return p.queue
+	}
+	if p.cancel != nil {
+		p.cancel()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	p.head = position
+	p.queue = make(chan *provedPod, p.depth)
+	p.cancel = cancel
+	p.proven = position.startIndex
+	go p.prove(ctx, position, p.queue)
+	return p.queue
+}
+
+// provenTxnEnd returns the last txns-N record covered by the pods proven ahead of their junction
+// steps. The indexer must not unwind these transactions, as they are already in a proof.
+func (p *podPipeline) provenTxnEnd() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.proven
+}
+
+// take returns the pod at position, waiting for the prover when it is not proven yet, or
+// errPipelineStopped once done is closed.
+func (p *podPipeline) take(position podPosition, done <-chan struct{}) (*provedPod, error) {
+	p.mu.Lock()
+	queue := p.restartAt(position)
+	p.mu.Unlock()
+
+	var pod *provedPod
+	select {
+	case pod = <-queue:
+	case <-done:
+		return nil, errPipelineStopped
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.queue == queue {
+		if pod.err != nil {
+			// the prover stopped, the next take starts a new one
+			p.queue = nil
+		} else {
+			p.head = pod.next()
+		}
+	}
+	return pod, pod.err
+}
+
+func (p *podPipeline) prove(ctx context.Context, position podPosition, queue chan *provedPod) {
+	for ctx.Err() == nil {
+		pod, err := p.generate(ctx, position)
+		if ctx.Err() != nil {
+			// the prover was replaced, nobody takes its pods any more
+			return
+		}
+		if err != nil {
+			pod = &provedPod{position: position, err: err}
+		} else {
+			p.mu.Lock()
+			if p.queue == queue {
+				p.proven = pod.batch.TxnEndIndex
+			}
+			p.mu.Unlock()
+		}
+		select {
+		case queue <- pod:
+		case <-ctx.Done():
+			return
+		}
+		if err != nil {
+			return
+		}
+		position = pod.next()
+	}
+}
@@ -0,0 +1,177 @@
+package p2p
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/airchains-network/tracks/types"
+)
+
+// fakePodGenerator proves pods of ten transactions instantly and records the positions it proved.
+type fakePodGenerator struct {
+	mu     sync.Mutex
+	proved []podPosition
+	fail   podPosition
+}
+
+func (g *fakePodGenerator) generate(ctx context.Context, position podPosition) (*provedPod, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if position == g.fail {
+		return nil, errors.New("proving failed")
+	}
+	g.proved = append(g.proved, position)
+	return &provedPod{position: position, batch: &types.BatchStruct{TxnEndIndex: position.startIndex + 10}}, nil
+}
+
+func (g *fakePodGenerator) count() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return len(g.proved)
+}
+
+// waitForCount waits until the generator proved n pods and checks it proves no more.
+func (g *fakePodGenerator) waitForCount(t *testing.T, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for g.count() < n && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if got := g.count(); got != n {
+		t.Fatalf("prover proved %d pods, want %d", got, n)
+	}
+}
+
+func TestPodPipelineProvesAheadInOrder(t *testing.T) {
+	g := &fakePodGenerator{fail: podPosition{startIndex: -1}}
+	p := newPodPipeline(g.generate, 2)
+
+	// the queue holds two proven pods and the prover waits with a third
+	p.prepare(podPosition{startIndex: 0, batchCount: 0})
+	g.waitForCount(t, 3)
+
+	for i := 0; i < 4; i++ {
+		want := podPosition{startIndex: 10 * i, batchCount: i}
+		pod, err := p.take(want, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pod.position != want {
+			t.Fatalf("take returned the pod at %+v, want %+v", pod.position, want)
+		}
+	}
+	g.waitForCount(t, 7)
+
+	// a pod the queue does not continue at restarts the prover there
+	restart := podPosition{startIndex: 35, batchCount: 2}
+	pod, err := p.take(restart, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pod.position != restart {
+		t.Fatalf("take returned the pod at %+v, want %+v", pod.position, restart)
+	}
+	if next, _ := p.take(pod.next(), nil); next.position != (podPosition{startIndex: 45, batchCount: 3}) {
+		t.Errorf("pod after the restart is at %+v", next.position)
+	}
+}
+
+func TestPodPipelineReturnsProvingErrors(t *testing.T) {
+	g := &fakePodGenerator{fail: podPosition{startIndex: 10, batchCount: 1}}
+	p := newPodPipeline(g.generate, 2)
+
+	if _, err := p.take(podPosition{}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.take(podPosition{startIndex: 10, batchCount: 1}, nil); err == nil {
+		t.Fatal("take returned a pod the prover failed to prove")
+	}
+
+	// the failed pod is proven again by a new prover
+	g.mu.Lock()
+	g.fail = podPosition{startIndex: -1}
+	g.mu.Unlock()
+	if pod, err := p.take(podPosition{startIndex: 10, batchCount: 1}, nil); err != nil || pod.batch.TxnEndIndex != 20 {
+		t.Fatalf("take after a failure = %+v, %v", pod, err)
+	}
+}
+
+func TestPodPipelineTakeStops(t *testing.T) {
+	// the prover waits for transactions that never come
+	blocked := make(chan struct{})
+	defer close(blocked)
+	p := newPodPipeline(func(context.Context, podPosition) (*provedPod, error) {
+		<-blocked
+		return nil, errors.New("stopped")
+	}, 1)
+
+	done := make(chan struct{})
+	close(done)
+	if _, err := p.take(podPosition{}, done); !errors.Is(err, errPipelineStopped) {
+		t.Errorf("take after done returned %v, want errPipelineStopped", err)
+	}
+}
+
+func TestPodPipelineProvenTxnEnd(t *testing.T) {
+	g := &fakePodGenerator{fail: podPosition{startIndex: -1}}
+	p := newPodPipeline(g.generate, 2)
+
+	// pods proven ahead cover transactions up to the last one queued
+	p.prepare(podPosition{startIndex: 0, batchCount: 0})
+	g.waitForCount(t, 3)
+	if got := p.provenTxnEnd(); got != 30 {
+		t.Errorf("proven txn end is %d with three pods proven, want 30", got)
+	}
+
+	// a restart drops the pods proven ahead, the new prover proves from the pod taken
+	if _, err := p.take(podPosition{startIndex: 5, batchCount: 0}, nil); err != nil {
+		t.Fatal(err)
+	}
+	g.waitForCount(t, 7)
+	if got := p.provenTxnEnd(); got != 45 {
+		t.Errorf("proven txn end is %d after the restart, want 45", got)
+	}
+}
+
+func TestPodPipelineStopsReplacedProver(t *testing.T) {
+	// the prover of the first position waits for transactions that never come
+	waiting := make(chan struct{})
+	stopped := make(chan struct{})
+	var proved []podPosition
+	var mu sync.Mutex
+	p := newPodPipeline(func(ctx context.Context, position podPosition) (*provedPod, error) {
+		if position.startIndex == 0 {
+			close(waiting)
+			<-ctx.Done()
+			close(stopped)
+			return nil, ctx.Err()
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		proved = append(proved, position)
+		return &provedPod{position: position, batch: &types.BatchStruct{TxnEndIndex: position.startIndex + 10}}, nil
+	}, 1)
+
+	p.prepare(podPosition{})
+	<-waiting
+	pod, err := p.take(podPosition{startIndex: 7, batchCount: 1}, nil)
+	if err != nil || pod.position.startIndex != 7 {
+		t.Fatalf("take after the restart = %+v, %v", pod, err)
+	}
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the replaced prover still waits for its transactions")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	for _, position := range proved {
+		if position.startIndex < 7 {
+			t.Errorf("the replaced prover proved the pod at %+v", position)
+		}
+	}
+}
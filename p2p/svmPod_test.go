@@ -0,0 +1,96 @@
+package p2p
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/airchains-network/tracks/types/svmTypes"
+	"github.com/consensys/gnark-crypto/ecc"
+)
+
+const svmTransferTxn = `{
+	"meta": {"err": null, "preBalances": [18446744073709551615, 5, 1], "postBalances": [0, 0, 1]},
+	"transaction": {
+		"message": {
+			"accountKeys": [{"pubkey": "sender"}, {"pubkey": "receiver"}, {"pubkey": "11111111111111111111111111111111"}],
+			"instructions": [
+				{"program": "spl-memo", "parsed": {"type": "memo"}},
+				{"program": "system", "parsed": {"type": "transfer", "info": {"source": "sender", "destination": "receiver", "lamports": 1000000000}}}
+			]
+		},
+		"signatures": ["signature"]
+	}
+}`
+
+func decodeSVMTxn(t *testing.T, data string) *svmTypes.SVMTransactionStruct {
+	t.Helper()
+	var tx svmTypes.SVMTransactionStruct
+	decoder := json.NewDecoder(bytes.NewReader([]byte(data)))
+	decoder.UseNumber()
+	if err := decoder.Decode(&tx); err != nil {
+		t.Fatal(err)
+	}
+	return &tx
+}
+
+func TestSVMTransferAndPreState(t *testing.T) {
+	tx := decodeSVMTxn(t, svmTransferTxn)
+
+	source, destination, lamports, ok := svmTransfer(tx)
+	if !ok || source != "sender" || destination != "receiver" || lamports != 1000000000 {
+		t.Fatalf("svmTransfer = %q, %q, %d, %v", source, destination, lamports, ok)
+	}
+
+	senderBalance, receiverBalance, err := svmPreState(tx, source, destination)
+	if err != nil || senderBalance != "18446744073709551615" || receiverBalance != "5" {
+		t.Errorf("svmPreState = %s, %s, %v, want the exact pre-balances", senderBalance, receiverBalance, err)
+	}
+
+	// the current station balances are not the pre-state, so a missing pre-balance fails
+	noPreBalances := decodeSVMTxn(t, strings.Replace(svmTransferTxn, `"preBalances": [18446744073709551615, 5, 1], `, "", 1))
+	if _, _, err = svmPreState(noPreBalances, source, destination); err == nil {
+		t.Error("svmPreState returned balances of a transaction without pre-balances")
+	}
+	if _, err = svmTransfers(nil, nil)(context.Background(), 1, []byte(strings.Replace(svmTransferTxn, "5, 1]", "5]", 1))); err != nil {
+		t.Errorf("a transfer whose pre-balances cover sender and receiver failed: %v", err)
+	}
+
+	tx.Meta.Err = map[string]interface{}{"InstructionError": []interface{}{0, "Custom"}}
+	if _, _, _, ok := svmTransfer(tx); ok {
+		t.Error("svmTransfer returned a transfer of a failed transaction")
+	}
+
+	vote := decodeSVMTxn(t, `{"transaction": {"message": {"instructions": [{"program": "vote", "parsed": {"type": "towersync"}}]}, "signatures": ["vote"]}}`)
+	if _, _, _, ok := svmTransfer(vote); ok {
+		t.Error("svmTransfer returned a transfer of a vote transaction")
+	}
+}
+
+func TestSVMTransfersFitTheScalarField(t *testing.T) {
+	decode := svmTransfers(nil, nil)
+	transfer, err := decode(context.Background(), 1, []byte(svmTransferTxn))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transfer.Amount != "1000000000" || transfer.SenderBalance != "18446744073709551615" || transfer.Message != "signature" {
+		t.Errorf("transfer is %+v", transfer)
+	}
+	for name, value := range map[string]string{"from": transfer.From, "to": transfer.To, "signature": transfer.TransactionHash} {
+		element, ok := new(big.Int).SetString(value, 10)
+		if !ok || element.Cmp(ecc.BLS12_381.ScalarField()) >= 0 {
+			t.Errorf("%s %s is not a BLS12-381 scalar", name, value)
+		}
+	}
+	if transfer.From == transfer.To {
+		t.Error("sender and receiver map to the same field element")
+	}
+
+	// signatures that are not base58 fail the transaction instead of proving zero
+	if _, err = decode(context.Background(), 1, []byte(strings.Replace(svmTransferTxn, `["signature"]`, `["0OIl"]`, 1))); err == nil {
+		t.Error("an invalid base58 signature was decoded")
+	}
+}
@@ -14,7 +14,7 @@ const BatchCountKey = "batchCount"
 func HandleGetBatchCount(c *gin.Context, Params []any) {
 	logger := logrus.New()
 	staticDB := shared.Node.NodeConnections.GetStaticDatabaseConnection()
-	currentPodNumber, err := staticDB.Get([]byte(BatchCountKey), nil)
+	currentPodNumber, err := staticDB.Get([]byte(BatchCountKey))
 	if err != nil {
 		logger.WithField("batchCountKey", BatchCountKey).Error("Failed to get current pod number from database: ", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error in getting current pod number from database"})
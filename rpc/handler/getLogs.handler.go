@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"encoding/json"
+
+	"github.com/airchains-network/tracks/blocksync"
+	"github.com/airchains-network/tracks/node/shared"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// logFilterParams is the filter object of tracks_getLogs. Address and every topic position
+// accept a single value or a list of values.
+type logFilterParams struct {
+	FromBlock int               `json:"fromBlock"`
+	ToBlock   int               `json:"toBlock"`
+	Address   json.RawMessage   `json:"address"`
+	Topics    []json.RawMessage `json:"topics"`
+}
+
+func HandleGetLogs(c *gin.Context, Params []interface{}) {
+	Log := logrus.New()
+	if len(Params) == 0 {
+		respondWithError(c, Log, 5, "Missing log filter", 400)
+		return
+	}
+
+	rawFilter, err := json.Marshal(Params[0])
+	if err != nil {
+		respondWithError(c, Log, 5, "Invalid log filter", 400)
+		return
+	}
+	var params logFilterParams
+	if err = json.Unmarshal(rawFilter, &params); err != nil {
+		respondWithError(c, Log, 5, "Invalid log filter", 400)
+		return
+	}
+
+	filter := blocksync.LogFilter{FromBlock: params.FromBlock, ToBlock: params.ToBlock}
+	if filter.Addresses, err = stringOrList(params.Address); err != nil {
+		respondWithError(c, Log, 5, "Invalid address in log filter", 400)
+		return
+	}
+	for _, rawTopics := range params.Topics {
+		topics, err := stringOrList(rawTopics)
+		if err != nil {
+			respondWithError(c, Log, 5, "Invalid topic in log filter", 400)
+			return
+		}
+		filter.Topics = append(filter.Topics, topics)
+	}
+
+	txnDB := shared.Node.NodeConnections.GetTxnDatabaseConnection()
+	logs, err := blocksync.GetLogs(txnDB, filter)
+	if err != nil {
+		Log.Error("Failed to get logs: ", err)
+		respondWithError(c, Log, 3, err.Error(), 400)
+		return
+	}
+
+	respondWithSuccess(c, Log, logs, "success")
+}
+
+// stringOrList decodes a JSON string, list of strings or null.
+func stringOrList(raw json.RawMessage) ([]string, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	var value string
+	if err := json.Unmarshal(raw, &value); err == nil {
+		return []string{value}, nil
+	}
+	var values []string
+	if err := json.Unmarshal(raw, &values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
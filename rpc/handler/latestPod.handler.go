@@ -1,7 +1,6 @@
 package handler
 
 import (
-	"encoding/json"
 	"github.com/airchains-network/tracks/node/shared"
 	"github.com/airchains-network/tracks/types"
 	"github.com/gin-gonic/gin"
@@ -18,11 +17,11 @@ type Handler struct {
 
 func (h *Handler) getStateData() ([]byte, error) {
 	stateConnection := shared.Node.NodeConnections.GetStateDatabaseConnection()
-	return stateConnection.Get([]byte("podState"), nil)
+	return stateConnection.Get([]byte("podState"))
 }
 
-func (h *Handler) unmarshalPodStateData(data []byte, out *types.PodState) error {
-	return json.Unmarshal(data, out)
+func (h *Handler) unmarshalPodStateData(data []byte) (*types.PodState, error) {
+	return types.UnmarshalPodState(data)
 }
 
 func (h *Handler) HandleGetLatestPod(c *gin.Context) {
@@ -34,8 +33,7 @@ func (h *Handler) HandleGetLatestPod(c *gin.Context) {
 		return
 	}
 
-	var podState types.PodState
-	err = h.unmarshalPodStateData(podStateData, &podState)
+	podState, err := h.unmarshalPodStateData(podStateData)
 	if err != nil {
 		h.Log.Error("Error in unmarshalling pod state data: ", err)
 		respondWithError(c, Log, 500, "Internal Server Error", 500)
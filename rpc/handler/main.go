@@ -38,6 +38,14 @@ func RouterHandler(c *gin.Context) {
 		HandleGetBatchCount(c, requestBody.Params) // Assuming this is defined
 	case "tracks_getPodByNumber":
 		HandleGetPodByNumber(c, requestBody.Params) // Assuming this is defined
+	case "tracks_getLogs":
+		HandleGetLogs(c, requestBody.Params)
+	case "tracks_getTransactionByHash":
+		HandleGetTransactionByHash(c, requestBody.Params)
+	case "tracks_getTransactionsByAddress":
+		HandleGetTransactionsByAddress(c, requestBody.Params)
+	case "tracks_getBlockTransactions":
+		HandleGetBlockTransactions(c, requestBody.Params)
 	default:
 		errorMsg := "No method exists with the name " + requestBody.Method
 		respondWithError(c, Log, 4, errorMsg, 404)
@@ -21,21 +21,20 @@ func HandleGetPodByNumber(c *gin.Context, Params []interface{}) {
 	daKey := fmt.Sprintf("da-%.0f", Params[0])
 
 	fmt.Println(daKey)
-	podDataByte, err := batchDB.Get([]byte(podKey), nil)
+	podDataByte, err := batchDB.Get([]byte(podKey))
 	if err != nil {
 		Log.Error("Failed to get pod data: ", err)
 		respondWithError(c, Log, 3, "Failed to get pod data", 500)
 		return
 	}
-	daDataByte, err := daDB.Get([]byte(daKey), nil)
+	daDataByte, err := daDB.Get([]byte(daKey))
 	if err != nil {
 		Log.Error("Failed to get pod data: ", err)
 		respondWithError(c, Log, 3, "Failed to get da data", 500)
 		return
 	}
 
-	podData := &shared.PodState{}
-	err = json.Unmarshal(podDataByte, &podData)
+	podData, err := types.UnmarshalPodState(podDataByte)
 	if err != nil {
 		Log.Error("Failed to unmarshal pod data: ", err)
 		respondWithError(c, Log, 4, "Failed to unmarshal pod data", 500)
@@ -55,7 +54,7 @@ func HandleGetPodByNumber(c *gin.Context, Params []interface{}) {
 		PreviousPodHash     []byte
 		LatestPodProof      []byte
 		LatestPublicWitness []byte
-		Votes               map[string]shared.Votes
+		Votes               map[string]types.Votes
 		TracksAppHash       []byte
 		Batch               *types.BatchStruct
 		MasterTrackAppHash  []byte
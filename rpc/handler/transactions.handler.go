@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/airchains-network/tracks/blocksync"
+	"github.com/airchains-network/tracks/node/shared"
+	"github.com/airchains-network/tracks/store"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// indexedTxn is a transaction record of the txns-N sequence as returned by the RPC.
+type indexedTxn struct {
+	Index       int             `json:"index"`
+	Pod         int             `json:"pod,omitempty"`
+	Transaction json.RawMessage `json:"transaction"`
+}
+
+func HandleGetTransactionByHash(c *gin.Context, Params []interface{}) {
+	Log := logrus.New()
+	hash, ok := stringParam(Params, 0)
+	if !ok {
+		respondWithError(c, Log, 5, "Missing transaction hash", 400)
+		return
+	}
+
+	txnDB := shared.Node.NodeConnections.GetTxnDatabaseConnection()
+	index, err := blocksync.GetTxnIndexByHash(txnDB, hash)
+	if err != nil {
+		Log.Error("Failed to look up transaction: ", err)
+		respondWithError(c, Log, 3, "Failed to look up transaction", 500)
+		return
+	}
+	if index == 0 {
+		respondWithError(c, Log, 6, "Transaction not found", 404)
+		return
+	}
+
+	txns, err := loadTxns(txnDB, []int{index})
+	if err != nil {
+		Log.Error("Failed to get transaction: ", err)
+		respondWithError(c, Log, 3, "Failed to get transaction", 500)
+		return
+	}
+	if txns[0].Pod, err = shared.FindPodByTxnIndex(index); err != nil {
+		Log.Error("Failed to find the pod of the transaction: ", err)
+		respondWithError(c, Log, 3, "Failed to find the pod of the transaction", 500)
+		return
+	}
+
+	respondWithSuccess(c, Log, txns[0], "success")
+}
+
+func HandleGetTransactionsByAddress(c *gin.Context, Params []interface{}) {
+	Log := logrus.New()
+	address, ok := stringParam(Params, 0)
+	if !ok {
+		respondWithError(c, Log, 5, "Missing address", 400)
+		return
+	}
+	after, _ := intParam(Params, 1)
+	limit, ok := intParam(Params, 2)
+	if !ok || limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+
+	txnDB := shared.Node.NodeConnections.GetTxnDatabaseConnection()
+	indices, err := blocksync.GetTxnIndicesByAddress(txnDB, address, after, limit)
+	if err != nil {
+		Log.Error("Failed to look up transactions: ", err)
+		respondWithError(c, Log, 3, "Failed to look up transactions", 500)
+		return
+	}
+	txns, err := loadTxns(txnDB, indices)
+	if err != nil {
+		Log.Error("Failed to get transactions: ", err)
+		respondWithError(c, Log, 3, "Failed to get transactions", 500)
+		return
+	}
+
+	respondWithSuccess(c, Log, txns, "success")
+}
+
+func HandleGetBlockTransactions(c *gin.Context, Params []interface{}) {
+	Log := logrus.New()
+	height, ok := intParam(Params, 0)
+	if !ok {
+		respondWithError(c, Log, 5, "Missing block height", 400)
+		return
+	}
+
+	txnDB := shared.Node.NodeConnections.GetTxnDatabaseConnection()
+	first, last, found, err := blocksync.GetBlockTxnRange(txnDB, height)
+	if err != nil {
+		Log.Error("Failed to look up block: ", err)
+		respondWithError(c, Log, 3, "Failed to look up block", 500)
+		return
+	}
+	if !found {
+		respondWithError(c, Log, 6, "Block not indexed", 404)
+		return
+	}
+
+	indices := make([]int, 0, last-first+1)
+	for index := first; index <= last; index++ {
+		indices = append(indices, index)
+	}
+	txns, err := loadTxns(txnDB, indices)
+	if err != nil {
+		Log.Error("Failed to get transactions: ", err)
+		respondWithError(c, Log, 3, "Failed to get transactions", 500)
+		return
+	}
+
+	respondWithSuccess(c, Log, txns, "success")
+}
+
+func loadTxns(txnDB store.Store, indices []int) ([]indexedTxn, error) {
+	txns := make([]indexedTxn, 0, len(indices))
+	for _, index := range indices {
+		txn, err := blocksync.GetTxn(txnDB, index)
+		if err != nil {
+			return nil, err
+		}
+		if txn == nil {
+			return nil, fmt.Errorf("transaction %d is not indexed", index)
+		}
+		txns = append(txns, indexedTxn{Index: index, Transaction: txn})
+	}
+	return txns, nil
+}
+
+func stringParam(Params []interface{}, i int) (string, bool) {
+	if len(Params) <= i {
+		return "", false
+	}
+	value, ok := Params[i].(string)
+	return value, ok && value != ""
+}
+
+func intParam(Params []interface{}, i int) (int, bool) {
+	if len(Params) <= i {
+		return 0, false
+	}
+	value, ok := Params[i].(float64)
+	return int(value), ok
+}
@@ -7,12 +7,13 @@ import (
 	"github.com/rs/zerolog/log"
 	"github.com/sirupsen/logrus"
 	"net/http"
-	"os"
-	"os/signal"
 	"sync"
-	"syscall"
+	"time"
 )
 
+// shutdownTimeout bounds how long the server waits for requests in flight when it stops.
+const shutdownTimeout = 5 * time.Second
+
 type Server struct {
 	httpServer *http.Server
 	router     *gin.Engine
@@ -63,7 +64,9 @@ func (s *Server) Stop(ctx context.Context) {
 	}
 }
 
-func StartRPC(wg *sync.WaitGroup) {
+// StartRPC serves the tracks RPC until ctx is cancelled, then shuts the server down, waiting for
+// the requests in flight.
+func StartRPC(ctx context.Context, wg *sync.WaitGroup) {
 	defer wg.Done()
 
 	server := NewServer()
@@ -74,9 +77,9 @@ func StartRPC(wg *sync.WaitGroup) {
 	server.Log.Info("Server Started Successfully on Port 2024")
 	log.Info().Str("module", "rpc").Msg("RPC Server Stared at Port 2024 Successfully")
 
-	stopChan := make(chan os.Signal, 1)
-	signal.Notify(stopChan, syscall.SIGTERM)
-
-	<-stopChan
+	<-ctx.Done()
 	server.Log.Info("Received shutdown signal")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	server.Stop(shutdownCtx)
 }
@@ -0,0 +1,224 @@
+// Package snapshot exports the data of a track up to a saved pod into a single archive and
+// imports such an archive into an empty data directory, so a new track can join a station without
+// indexing it from the first block.
+package snapshot
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"time"
+
+	"github.com/airchains-network/tracks/blocksync"
+)
+
+// FormatVersion is the version of the archive layout written by Export.
+const FormatVersion = 1
+
+// magic starts every archive, before the format version.
+var magic = []byte("TRKSNAP\n")
+
+// maxFieldSize bounds a single key, value or manifest read from an archive.
+const maxFieldSize = 1 << 30
+
+// ErrChecksum is returned when an archive does not match its checksum.
+var ErrChecksum = errors.New("snapshot archive checksum mismatch")
+
+// Manifest describes the contents of an archive. It is written after the records, since the
+// indexer progress is only known once the blocks have been exported.
+type Manifest struct {
+	FormatVersion int                `json:"formatVersion"`
+	SchemaVersion int                `json:"schemaVersion"`
+	StationType   string             `json:"stationType"`
+	StationID     string             `json:"stationId,omitempty"`
+	PodHeight     int                `json:"podHeight"`
+	PodTxnEnd     int                `json:"podTxnEnd"`
+	Progress      blocksync.Progress `json:"progress"`
+	Records       int                `json:"records"`
+	CreatedAt     time.Time          `json:"createdAt"`
+}
+
+// The archive is a gzip stream of:
+//
+//	magic, uvarint format version
+//	records: uvarint-prefixed database name, key and value
+//	an empty database name ending the records
+//	the uvarint-prefixed JSON manifest
+//	the SHA-256 of everything above
+type archiveWriter struct {
+	gz  *gzip.Writer
+	buf *bufio.Writer
+	sum hash.Hash
+	out io.Writer
+}
+
+func newArchiveWriter(w io.Writer) (*archiveWriter, error) {
+	gz := gzip.NewWriter(w)
+	buf := bufio.NewWriter(gz)
+	sum := sha256.New()
+	a := &archiveWriter{gz: gz, buf: buf, sum: sum, out: io.MultiWriter(buf, sum)}
+	if _, err := a.out.Write(magic); err != nil {
+		return nil, err
+	}
+	if err := a.writeUvarint(FormatVersion); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func (a *archiveWriter) writeUvarint(v uint64) error {
+	var tmp [binary.MaxVarintLen64]byte
+	_, err := a.out.Write(tmp[:binary.PutUvarint(tmp[:], v)])
+	return err
+}
+
+func (a *archiveWriter) writeField(b []byte) error {
+	if err := a.writeUvarint(uint64(len(b))); err != nil {
+		return err
+	}
+	_, err := a.out.Write(b)
+	return err
+}
+
+// writeRecord adds one database record.
+func (a *archiveWriter) writeRecord(db string, key []byte, value []byte) error {
+	if db == "" {
+		return fmt.Errorf("snapshot: record without database name")
+	}
+	for _, field := range [][]byte{[]byte(db), key, value} {
+		if err := a.writeField(field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// close ends the records, writes the manifest and the checksum and flushes the archive.
+func (a *archiveWriter) close(manifest *Manifest) error {
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+	if err = a.writeField(nil); err != nil {
+		return err
+	}
+	if err = a.writeField(data); err != nil {
+		return err
+	}
+	if _, err = a.buf.Write(a.sum.Sum(nil)); err != nil {
+		return err
+	}
+	if err = a.buf.Flush(); err != nil {
+		return err
+	}
+	return a.gz.Close()
+}
+
+type archiveReader struct {
+	in  *bufio.Reader
+	sum hash.Hash
+}
+
+func newArchiveReader(r io.Reader) (*archiveReader, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("snapshot: not a snapshot archive: %w", err)
+	}
+	a := &archiveReader{in: bufio.NewReader(gz), sum: sha256.New()}
+
+	head := make([]byte, len(magic))
+	if err = a.readFull(head); err != nil || !bytes.Equal(head, magic) {
+		return nil, fmt.Errorf("snapshot: not a snapshot archive")
+	}
+	version, err := a.readUvarint()
+	if err != nil {
+		return nil, err
+	}
+	if version != FormatVersion {
+		return nil, fmt.Errorf("snapshot: archive format %d is not supported, this release reads format %d", version, FormatVersion)
+	}
+	return a, nil
+}
+
+func (a *archiveReader) readFull(b []byte) error {
+	if _, err := io.ReadFull(a.in, b); err != nil {
+		return fmt.Errorf("snapshot: truncated archive: %w", err)
+	}
+	a.sum.Write(b)
+	return nil
+}
+
+func (a *archiveReader) readUvarint() (uint64, error) {
+	var buf []byte
+	for {
+		b, err := a.in.ReadByte()
+		if err != nil {
+			return 0, fmt.Errorf("snapshot: truncated archive: %w", err)
+		}
+		buf = append(buf, b)
+		if b < 0x80 {
+			break
+		}
+		if len(buf) == binary.MaxVarintLen64 {
+			return 0, fmt.Errorf("snapshot: corrupt archive")
+		}
+	}
+	a.sum.Write(buf)
+	v, _ := binary.Uvarint(buf)
+	return v, nil
+}
+
+func (a *archiveReader) readField() ([]byte, error) {
+	n, err := a.readUvarint()
+	if err != nil {
+		return nil, err
+	}
+	if n > maxFieldSize {
+		return nil, fmt.Errorf("snapshot: corrupt archive")
+	}
+	b := make([]byte, n)
+	return b, a.readFull(b)
+}
+
+// next returns the next record, or an empty db name after the last one.
+func (a *archiveReader) next() (db string, key []byte, value []byte, err error) {
+	name, err := a.readField()
+	if err != nil || len(name) == 0 {
+		return "", nil, nil, err
+	}
+	if key, err = a.readField(); err != nil {
+		return "", nil, nil, err
+	}
+	if value, err = a.readField(); err != nil {
+		return "", nil, nil, err
+	}
+	return string(name), key, value, nil
+}
+
+// finish reads the manifest and checks the checksum. It must follow the last record.
+func (a *archiveReader) finish() (*Manifest, error) {
+	data, err := a.readField()
+	if err != nil {
+		return nil, err
+	}
+	expected := a.sum.Sum(nil)
+	stored := make([]byte, sha256.Size)
+	if _, err = io.ReadFull(a.in, stored); err != nil {
+		return nil, fmt.Errorf("snapshot: truncated archive: %w", err)
+	}
+	if !bytes.Equal(stored, expected) {
+		return nil, ErrChecksum
+	}
+	var manifest Manifest
+	if err = json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("snapshot: invalid manifest: %w", err)
+	}
+	return &manifest, nil
+}
@@ -0,0 +1,109 @@
+package snapshot
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/airchains-network/tracks/blocksync"
+	"github.com/airchains-network/tracks/config"
+	"github.com/airchains-network/tracks/node/shared"
+	"github.com/airchains-network/tracks/store"
+	"github.com/airchains-network/tracks/types"
+)
+
+// Export writes an archive of the data directory opened by blocksync.InitDb as of saved pod
+// podHeight, or of the latest saved pod when podHeight is 0. The archive holds the pods and DA
+// pointers up to that pod and the blocks and transactions up to the block that produced its last
+// transaction. The ledger is not exported.
+func Export(w io.Writer, conf *config.Config, podHeight int) (*Manifest, error) {
+	static := blocksync.GetStaticDbInstance()
+	pods := blocksync.GetBatchesDbInstance()
+	da := blocksync.GetDaDbInstance()
+
+	batchCount, err := readCounter(static, "batchCount")
+	if err != nil {
+		return nil, err
+	}
+	if podHeight == 0 {
+		podHeight = batchCount
+	}
+	if podHeight < 1 || podHeight > batchCount {
+		return nil, fmt.Errorf("pod %d is not saved, the latest saved pod is %d", podHeight, batchCount)
+	}
+	indexer, err := blocksync.DescribingIndexer(conf.Station.StationType)
+	if err != nil {
+		return nil, err
+	}
+
+	archive, err := newArchiveWriter(w)
+	if err != nil {
+		return nil, err
+	}
+	manifest := &Manifest{
+		FormatVersion: FormatVersion,
+		SchemaVersion: blocksync.SchemaVersion(),
+		StationType:   strings.ToLower(conf.Station.StationType),
+		StationID:     conf.Junction.StationId,
+		PodHeight:     podHeight,
+		CreatedAt:     time.Now().UTC(),
+	}
+	emit := func(db string, key []byte, value []byte) error {
+		manifest.Records++
+		return archive.writeRecord(db, key, value)
+	}
+
+	var pod *shared.PodState
+	for i := 1; i <= podHeight; i++ {
+		key := []byte(fmt.Sprintf("pod-%d", i))
+		data, err := pods.Get(key)
+		if err != nil {
+			return nil, fmt.Errorf("pod-%d: %w", i, err)
+		}
+		if i == podHeight {
+			if pod, err = types.UnmarshalPodState(data); err != nil {
+				return nil, fmt.Errorf("pod-%d: %w", i, err)
+			}
+		}
+		if err = emit(dbPods, key, data); err != nil {
+			return nil, err
+		}
+
+		key = []byte(fmt.Sprintf("da-%d", i))
+		data, err = da.Get(key)
+		if err == store.ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err = emit(dbDA, key, data); err != nil {
+			return nil, err
+		}
+	}
+
+	manifest.PodTxnEnd = shared.PodTxnEnd(pod.Batch, podHeight, conf.Station.GetPodSize())
+	progress, err := blocksync.ExportChain(blocksync.GetBlockDbInstance(), blocksync.GetTxDbInstance(), indexer, manifest.PodTxnEnd, emit)
+	if err != nil {
+		return nil, err
+	}
+	manifest.Progress = *progress
+
+	if err = archive.close(manifest); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+func readCounter(db store.Store, key string) (int, error) {
+	value, err := db.Get([]byte(key))
+	if err == store.ErrNotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(value)))
+}
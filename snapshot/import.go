@@ -0,0 +1,205 @@
+package snapshot
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/airchains-network/tracks/blocksync"
+	"github.com/airchains-network/tracks/config"
+	"github.com/airchains-network/tracks/node/shared"
+	"github.com/airchains-network/tracks/store"
+	"github.com/airchains-network/tracks/types"
+)
+
+// Names of the databases archive records belong to, as opened by blocksync.InitDb.
+const (
+	dbPods   = "batches"
+	dbDA     = "da"
+	dbTxns   = "tx"
+	dbBlocks = "blocks"
+)
+
+// importBatchSize is the number of records Import writes per batch.
+const importBatchSize = 1000
+
+// Import restores the archive at path into the empty data directory opened by
+// blocksync.InitDb. The archive checksum is verified before anything is written, and the pods
+// are verified against the pod hash chain before the counters, the indexer progress and the pod
+// state that make the restored data visible are recorded. A failed import leaves a data
+// directory that must be removed before importing again.
+func Import(path string, conf *config.Config) (*Manifest, error) {
+	manifest, err := readArchive(path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if manifest.SchemaVersion != blocksync.SchemaVersion() {
+		return nil, fmt.Errorf("archive has schema version %d, this release uses %d", manifest.SchemaVersion, blocksync.SchemaVersion())
+	}
+	if !strings.EqualFold(manifest.StationType, conf.Station.StationType) {
+		return nil, fmt.Errorf("archive is of a %s station, the track is configured for %s", manifest.StationType, conf.Station.StationType)
+	}
+	if manifest.StationID != "" && conf.Junction.StationId != "" && manifest.StationID != conf.Junction.StationId {
+		return nil, fmt.Errorf("archive is of station %s, the track is configured for station %s", manifest.StationID, conf.Junction.StationId)
+	}
+	indexer, err := blocksync.DescribingIndexer(conf.Station.StationType)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkEmpty(); err != nil {
+		return nil, err
+	}
+
+	stores := map[string]store.Store{
+		dbPods:   blocksync.GetBatchesDbInstance(),
+		dbDA:     blocksync.GetDaDbInstance(),
+		dbTxns:   blocksync.GetTxDbInstance(),
+		dbBlocks: blocksync.GetBlockDbInstance(),
+	}
+	if _, err = readArchive(path, stores); err != nil {
+		return nil, err
+	}
+
+	pod, err := verifyPods(stores[dbPods], manifest, conf.Station.GetPodSize())
+	if err != nil {
+		return nil, err
+	}
+	if err = blocksync.RestoreChain(stores[dbBlocks], stores[dbTxns], indexer, &manifest.Progress); err != nil {
+		return nil, err
+	}
+
+	static := blocksync.GetStaticDbInstance().NewBatch()
+	static.Put([]byte("batchStartIndex"), []byte(strconv.Itoa(manifest.PodTxnEnd)))
+	static.Put([]byte("batchCount"), []byte(strconv.Itoa(manifest.PodHeight)))
+	if err = blocksync.GetStaticDbInstance().Write(static); err != nil {
+		return nil, err
+	}
+	pod.LatestTxState = shared.TxStatePreInit
+	pod.MasterTrackAppHash = nil
+	if err = blocksync.GetStateDbInstance().Put([]byte("podState"), types.MarshalPodState(pod)); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// readArchive reads the archive at path and returns its manifest once the checksum matches. With
+// stores, every record is also written to the store of its database.
+func readArchive(path string, stores map[string]store.Store) (*Manifest, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	archive, err := newArchiveReader(file)
+	if err != nil {
+		return nil, err
+	}
+	batches := make(map[string]store.Batch)
+	flush := func() error {
+		for db, batch := range batches {
+			if batch.Len() == 0 {
+				continue
+			}
+			if err := stores[db].Write(batch); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+		return nil
+	}
+
+	records, pending := 0, 0
+	for {
+		db, key, value, err := archive.next()
+		if err != nil {
+			return nil, err
+		}
+		if db == "" {
+			break
+		}
+		records++
+		if stores == nil {
+			continue
+		}
+		s, ok := stores[db]
+		if !ok {
+			return nil, fmt.Errorf("snapshot: record of unknown database %q", db)
+		}
+		batch, ok := batches[db]
+		if !ok {
+			batch = s.NewBatch()
+			batches[db] = batch
+		}
+		batch.Put(key, value)
+		if pending++; pending == importBatchSize {
+			if err = flush(); err != nil {
+				return nil, err
+			}
+			pending = 0
+		}
+	}
+	manifest, err := archive.finish()
+	if err != nil {
+		return nil, err
+	}
+	if manifest.Records != records {
+		return nil, fmt.Errorf("snapshot: archive holds %d records, its manifest lists %d", records, manifest.Records)
+	}
+	if stores != nil {
+		if err = flush(); err != nil {
+			return nil, err
+		}
+	}
+	return manifest, nil
+}
+
+// checkEmpty returns an error unless the open data directory has no transactions and no pods.
+func checkEmpty() error {
+	txnCount, err := readCounter(blocksync.GetTxDbInstance(), "txnCount")
+	if err != nil {
+		return err
+	}
+	batchCount, err := readCounter(blocksync.GetStaticDbInstance(), "batchCount")
+	if err != nil {
+		return err
+	}
+	hasPod, err := blocksync.GetBatchesDbInstance().Has([]byte("pod-1"))
+	if err != nil {
+		return err
+	}
+	if txnCount > 0 || batchCount > 0 || hasPod {
+		return fmt.Errorf("data directory is not empty (%d transactions, %d pods), import into a new home directory", txnCount, batchCount)
+	}
+	return nil
+}
+
+// verifyPods checks the restored pods against the pod hash chain and their transaction ranges
+// against the restored transactions, and returns the last pod.
+func verifyPods(pods store.Store, manifest *Manifest, podSize int) (*shared.PodState, error) {
+	var prev *shared.PodState
+	prevEnd := 0
+	for i := 1; i <= manifest.PodHeight; i++ {
+		data, err := pods.Get([]byte(fmt.Sprintf("pod-%d", i)))
+		if err != nil {
+			return nil, fmt.Errorf("pod-%d: %w", i, err)
+		}
+		pod, err := types.UnmarshalPodState(data)
+		if err != nil {
+			return nil, fmt.Errorf("pod-%d: %w", i, err)
+		}
+		if err = shared.VerifyPodRecord(prev, pod, i); err != nil {
+			return nil, err
+		}
+		end := shared.PodTxnEnd(pod.Batch, i, podSize)
+		if end <= prevEnd || end > manifest.Progress.TxnIndex {
+			return nil, fmt.Errorf("pod-%d ends at transaction %d, outside %d-%d", i, end, prevEnd+1, manifest.Progress.TxnIndex)
+		}
+		prev, prevEnd = pod, end
+	}
+	if prev == nil || prevEnd != manifest.PodTxnEnd {
+		return nil, fmt.Errorf("archive pods end at transaction %d, its manifest lists %d", prevEnd, manifest.PodTxnEnd)
+	}
+	return prev, nil
+}
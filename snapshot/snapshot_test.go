@@ -0,0 +1,161 @@
+package snapshot_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/airchains-network/tracks/blocksync"
+	"github.com/airchains-network/tracks/config"
+	"github.com/airchains-network/tracks/node/shared"
+	"github.com/airchains-network/tracks/snapshot"
+	"github.com/airchains-network/tracks/store"
+	"github.com/airchains-network/tracks/types"
+)
+
+func openMemoryDataDir(t *testing.T) *config.Config {
+	t.Helper()
+	conf := config.DefaultConfig()
+	conf.BaseConfig.DBBackend = store.BackendMemory
+	conf.Station.StationType = "evm"
+	if !blocksync.InitDb(conf.BaseConfig) {
+		t.Fatal("InitDb failed")
+	}
+	return conf
+}
+
+func put(t *testing.T, db store.Store, key string, value interface{}) {
+	t.Helper()
+	data, ok := value.([]byte)
+	if !ok {
+		var err error
+		if data, err = json.Marshal(value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.Put([]byte(key), data); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// fillDataDir indexes blocks 0-4 with two transactions each and saves two pods of three
+// transactions, so the second pod ends inside block 2.
+func fillDataDir(t *testing.T) {
+	ldb, ldt := blocksync.GetBlockDbInstance(), blocksync.GetTxDbInstance()
+	for height := 0; height <= 4; height++ {
+		put(t, ldb, fmt.Sprintf("block_%d", height), []byte(fmt.Sprintf("block %d", height)))
+		put(t, ldt, fmt.Sprintf("blockMeta-%d", height), blocksync.BlockMeta{
+			Height: height, Hash: fmt.Sprintf("0xb%d", height), TxnStart: 2*height + 1, TxnEnd: 2*height + 2,
+		})
+		for i := 2*height + 1; i <= 2*height+2; i++ {
+			put(t, ldt, fmt.Sprintf("txns-%d", i), map[string]string{"hash": fmt.Sprintf("0x%x", i), "from": "0x1"})
+		}
+	}
+	put(t, ldt, "txnCount", []byte("10"))
+	put(t, ldt, "indexerProgress", blocksync.Progress{Height: 4, Hash: "0xb4", TxnIndex: 10})
+
+	var prev []byte
+	for number := 1; number <= 2; number++ {
+		pod := &shared.PodState{
+			LatestPodHeight:     uint64(number),
+			LatestTxState:       shared.TxStatePreInit,
+			LatestPodHash:       []byte(fmt.Sprintf("root-%d", number)),
+			PreviousPodHash:     prev,
+			LatestPodProof:      []byte("proof"),
+			LatestPublicWitness: []byte("witness"),
+			Batch:               &types.BatchStruct{TxnEndIndex: 3 * number},
+		}
+		pod.TracksAppHash = shared.PodHash(pod.LatestPublicWitness, pod.LatestPodProof, pod.LatestPodHash, []byte(strconv.Itoa(number-1)))
+		put(t, blocksync.GetBatchesDbInstance(), fmt.Sprintf("pod-%d", number), pod)
+		put(t, blocksync.GetDaDbInstance(), fmt.Sprintf("da-%d", number), []byte("pointer"))
+		prev = pod.LatestPodHash
+	}
+	put(t, blocksync.GetStaticDbInstance(), "batchCount", []byte("2"))
+}
+
+func TestExportImport(t *testing.T) {
+	conf := openMemoryDataDir(t)
+	fillDataDir(t)
+
+	var archive bytes.Buffer
+	manifest, err := snapshot.Export(&archive, conf, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manifest.PodHeight != 2 || manifest.PodTxnEnd != 6 || manifest.Progress.Height != 2 || manifest.Progress.TxnIndex != 6 {
+		t.Fatalf("manifest = %+v, want pod 2 ending at txn 6 in block 2", manifest)
+	}
+	path := filepath.Join(t.TempDir(), "snapshot")
+	if err = os.WriteFile(path, archive.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	conf = openMemoryDataDir(t)
+	if _, err = snapshot.Import(path, conf); err != nil {
+		t.Fatal(err)
+	}
+	ldt := blocksync.GetTxDbInstance()
+	if index, err := blocksync.GetTxnIndexByHash(ldt, "0x6"); err != nil || index != 6 {
+		t.Errorf("GetTxnIndexByHash(0x6) = %d, %v, want 6", index, err)
+	}
+	if ok, _ := ldt.Has([]byte("txns-7")); ok {
+		t.Error("transaction 7 of block 3 was imported")
+	}
+	if progress, err := blocksync.GetProgress(ldt); err != nil || progress.Height != 2 || progress.TxnIndex != 6 {
+		t.Errorf("progress = %+v, %v, want block 2 and txn 6", progress, err)
+	}
+	if value, _ := blocksync.GetStaticDbInstance().Get([]byte("batchStartIndex")); string(value) != "6" {
+		t.Errorf("batchStartIndex = %q, want 6", value)
+	}
+
+	if _, err = snapshot.Import(path, conf); err == nil {
+		t.Error("Import into a data directory with pods succeeded")
+	}
+}
+
+func TestImportRejectsCorruptArchive(t *testing.T) {
+	conf := openMemoryDataDir(t)
+	fillDataDir(t)
+	var archive bytes.Buffer
+	if _, err := snapshot.Export(&archive, conf, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	// change one byte of the pod-1 record and compress the archive again
+	gz, err := gzip.NewReader(&archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := bytes.Index(raw, []byte("PreInit"))
+	if at < 0 {
+		t.Fatal("pod record not found in archive")
+	}
+	raw[at] = 'X'
+	var corrupted bytes.Buffer
+	w := gzip.NewWriter(&corrupted)
+	w.Write(raw)
+	w.Close()
+
+	path := filepath.Join(t.TempDir(), "snapshot")
+	if err = os.WriteFile(path, corrupted.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	conf = openMemoryDataDir(t)
+	if _, err = snapshot.Import(path, conf); !errors.Is(err, snapshot.ErrChecksum) {
+		t.Fatalf("Import of a corrupt archive returned %v, want ErrChecksum", err)
+	}
+	if ok, _ := blocksync.GetBatchesDbInstance().Has([]byte("pod-1")); ok {
+		t.Error("records of a corrupt archive were written")
+	}
+}
@@ -0,0 +1,127 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// Journal writes batches that span several stores as one unit. The batches are first recorded
+// under a key of the journal store, then written to their stores, and the record is removed
+// once every store has them. Replay writes a record left behind by a crash again; this is safe
+// because batches only hold puts and deletes of whole values.
+type Journal struct {
+	journal Store
+	key     []byte
+	stores  map[string]Store
+}
+
+// NewJournal returns a journal that keeps its pending record under key in journal and writes to
+// the named stores. journal may also be one of the stores.
+func NewJournal(journal Store, key string, stores map[string]Store) *Journal {
+	return &Journal{journal: journal, key: []byte(key), stores: stores}
+}
+
+// MultiBatch collects the batches of one journal write, one per store name.
+type MultiBatch struct {
+	batches map[string]*batch
+}
+
+// NewBatch returns an empty MultiBatch to be written with Write.
+func (j *Journal) NewBatch() *MultiBatch {
+	return &MultiBatch{batches: make(map[string]*batch)}
+}
+
+// Store returns the batch of the named store, creating it on first use.
+func (m *MultiBatch) Store(name string) Batch {
+	b, ok := m.batches[name]
+	if !ok {
+		b = &batch{}
+		m.batches[name] = b
+	}
+	return b
+}
+
+// Len returns the number of operations over all stores.
+func (m *MultiBatch) Len() int {
+	n := 0
+	for _, b := range m.batches {
+		n += b.Len()
+	}
+	return n
+}
+
+type journalOp struct {
+	Key    []byte `json:"key"`
+	Value  []byte `json:"value,omitempty"`
+	Delete bool   `json:"delete,omitempty"`
+}
+
+// Write records m in the journal, writes each of its batches to its store and clears the
+// record. After an error m is either not recorded or will be completed by Replay.
+func (j *Journal) Write(m *MultiBatch) error {
+	record := make(map[string][]journalOp, len(m.batches))
+	for name, b := range m.batches {
+		if _, ok := j.stores[name]; !ok {
+			return fmt.Errorf("store: journal has no store %q", name)
+		}
+		ops := make([]journalOp, 0, len(b.ops))
+		for _, op := range b.ops {
+			ops = append(ops, journalOp{Key: op.key, Value: op.value, Delete: op.delete})
+		}
+		record[name] = ops
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if err = j.journal.Put(j.key, data); err != nil {
+		return fmt.Errorf("store: recording journal: %w", err)
+	}
+	return j.apply(record)
+}
+
+// Replay completes a write that was recorded but interrupted before its record was cleared. It
+// does nothing when no write is pending.
+func (j *Journal) Replay() error {
+	data, err := j.journal.Get(j.key)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var record map[string][]journalOp
+	if err = json.Unmarshal(data, &record); err != nil {
+		return fmt.Errorf("store: decoding journal: %w", err)
+	}
+	return j.apply(record)
+}
+
+func (j *Journal) apply(record map[string][]journalOp) error {
+	names := make([]string, 0, len(record))
+	for name := range record {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		s, ok := j.stores[name]
+		if !ok {
+			return fmt.Errorf("store: journal has no store %q", name)
+		}
+		b := s.NewBatch()
+		for _, op := range record[name] {
+			if op.Delete {
+				b.Delete(op.Key)
+			} else {
+				b.Put(op.Key, op.Value)
+			}
+		}
+		if err := s.Write(b); err != nil {
+			return fmt.Errorf("store: writing journal batch to %s: %w", name, err)
+		}
+	}
+	return j.journal.Delete(j.key)
+}
@@ -0,0 +1,104 @@
+package store
+
+import (
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// levelDBWriteOptions syncs every write to disk before it returns, as pebbleWriteOptions does,
+// so a batch or journal record that was written survives a crash of the machine.
+var levelDBWriteOptions = &opt.WriteOptions{Sync: true}
+
+// levelDB is a Store backed by goleveldb.
+type levelDB struct {
+	db *leveldb.DB
+}
+
+func openLevelDB(path string) (Store, error) {
+	db, err := leveldb.OpenFile(path, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &levelDB{db: db}, nil
+}
+
+func (l *levelDB) Get(key []byte) ([]byte, error) {
+	value, err := l.db.Get(key, nil)
+	if err == leveldb.ErrNotFound {
+		return nil, ErrNotFound
+	}
+	return value, err
+}
+
+func (l *levelDB) Has(key []byte) (bool, error) {
+	return l.db.Has(key, nil)
+}
+
+func (l *levelDB) NewIterator(r Range) Iterator {
+	return l.db.NewIterator(&util.Range{Start: r.Start, Limit: r.Limit}, nil)
+}
+
+func (l *levelDB) Put(key []byte, value []byte) error {
+	return l.db.Put(key, value, levelDBWriteOptions)
+}
+
+func (l *levelDB) Delete(key []byte) error {
+	return l.db.Delete(key, levelDBWriteOptions)
+}
+
+func (l *levelDB) NewBatch() Batch {
+	return new(batch)
+}
+
+func (l *levelDB) Write(b Batch) error {
+	ops, err := asBatch(b)
+	if err != nil {
+		return err
+	}
+	lb := new(leveldb.Batch)
+	for _, op := range ops.ops {
+		if op.delete {
+			lb.Delete(op.key)
+		} else {
+			lb.Put(op.key, op.value)
+		}
+	}
+	return l.db.Write(lb, levelDBWriteOptions)
+}
+
+func (l *levelDB) NewSnapshot() (Snapshot, error) {
+	snap, err := l.db.GetSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	return &levelDBSnapshot{snap: snap}, nil
+}
+
+func (l *levelDB) Close() error {
+	return l.db.Close()
+}
+
+type levelDBSnapshot struct {
+	snap *leveldb.Snapshot
+}
+
+func (s *levelDBSnapshot) Get(key []byte) ([]byte, error) {
+	value, err := s.snap.Get(key, nil)
+	if err == leveldb.ErrNotFound {
+		return nil, ErrNotFound
+	}
+	return value, err
+}
+
+func (s *levelDBSnapshot) Has(key []byte) (bool, error) {
+	return s.snap.Has(key, nil)
+}
+
+func (s *levelDBSnapshot) NewIterator(r Range) Iterator {
+	return s.snap.NewIterator(&util.Range{Start: r.Start, Limit: r.Limit}, nil)
+}
+
+func (s *levelDBSnapshot) Release() {
+	s.snap.Release()
+}
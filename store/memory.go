@@ -0,0 +1,161 @@
+package store
+
+import (
+	"bytes"
+	"sort"
+	"sync"
+)
+
+// memory is a Store kept in memory, for development and tests. Its content is lost on Close.
+type memory struct {
+	mu   sync.RWMutex
+	data map[string][]byte
+}
+
+// NewMemory returns an empty in-memory Store.
+func NewMemory() Store {
+	return &memory{data: make(map[string][]byte)}
+}
+
+func (m *memory) Get(key []byte) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	value, ok := m.data[string(key)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return append([]byte(nil), value...), nil
+}
+
+func (m *memory) Has(key []byte) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	_, ok := m.data[string(key)]
+	return ok, nil
+}
+
+func (m *memory) NewIterator(r Range) Iterator {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return newMemoryIter(m.data, r)
+}
+
+func (m *memory) Put(key []byte, value []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.data[string(key)] = append([]byte(nil), value...)
+	return nil
+}
+
+func (m *memory) Delete(key []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.data, string(key))
+	return nil
+}
+
+func (m *memory) NewBatch() Batch {
+	return new(batch)
+}
+
+func (m *memory) Write(b Batch) error {
+	ops, err := asBatch(b)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, op := range ops.ops {
+		if op.delete {
+			delete(m.data, string(op.key))
+		} else {
+			m.data[string(op.key)] = op.value
+		}
+	}
+	return nil
+}
+
+func (m *memory) NewSnapshot() (Snapshot, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	data := make(map[string][]byte, len(m.data))
+	for key, value := range m.data {
+		data[key] = value
+	}
+	return &memorySnapshot{data: data}, nil
+}
+
+func (m *memory) Close() error {
+	return nil
+}
+
+type memorySnapshot struct {
+	data map[string][]byte
+}
+
+func (s *memorySnapshot) Get(key []byte) ([]byte, error) {
+	value, ok := s.data[string(key)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return append([]byte(nil), value...), nil
+}
+
+func (s *memorySnapshot) Has(key []byte) (bool, error) {
+	_, ok := s.data[string(key)]
+	return ok, nil
+}
+
+func (s *memorySnapshot) NewIterator(r Range) Iterator {
+	return newMemoryIter(s.data, r)
+}
+
+func (s *memorySnapshot) Release() {}
+
+// memoryIter iterates a copy of the keys in range taken when it was created.
+type memoryIter struct {
+	keys   []string
+	values [][]byte
+	pos    int
+}
+
+func newMemoryIter(data map[string][]byte, r Range) *memoryIter {
+	iter := &memoryIter{pos: -1}
+	for key := range data {
+		if r.Start != nil && bytes.Compare([]byte(key), r.Start) < 0 {
+			continue
+		}
+		if r.Limit != nil && bytes.Compare([]byte(key), r.Limit) >= 0 {
+			continue
+		}
+		iter.keys = append(iter.keys, key)
+	}
+	sort.Strings(iter.keys)
+	for _, key := range iter.keys {
+		iter.values = append(iter.values, data[key])
+	}
+	return iter
+}
+
+func (i *memoryIter) Next() bool {
+	if i.pos+1 >= len(i.keys) {
+		i.pos = len(i.keys)
+		return false
+	}
+	i.pos++
+	return true
+}
+
+func (i *memoryIter) Key() []byte {
+	return []byte(i.keys[i.pos])
+}
+
+func (i *memoryIter) Value() []byte {
+	return i.values[i.pos]
+}
+
+func (i *memoryIter) Error() error {
+	return nil
+}
+
+func (i *memoryIter) Release() {}
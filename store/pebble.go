@@ -0,0 +1,169 @@
+package store
+
+import (
+	"errors"
+	"io"
+
+	"github.com/cockroachdb/pebble"
+)
+
+// pebbleWriteOptions syncs every write to disk before it returns.
+var pebbleWriteOptions = pebble.Sync
+
+// pebbleDB is a Store backed by Pebble.
+type pebbleDB struct {
+	db *pebble.DB
+}
+
+func openPebble(path string) (Store, error) {
+	db, err := pebble.Open(path, &pebble.Options{})
+	if err != nil {
+		return nil, err
+	}
+	return &pebbleDB{db: db}, nil
+}
+
+// pebbleReader is implemented by both *pebble.DB and *pebble.Snapshot.
+type pebbleReader interface {
+	Get(key []byte) ([]byte, io.Closer, error)
+	NewIter(o *pebble.IterOptions) (*pebble.Iterator, error)
+}
+
+func pebbleGet(r pebbleReader, key []byte) ([]byte, error) {
+	value, closer, err := r.Get(key)
+	if errors.Is(err, pebble.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer closer.Close()
+	return append([]byte(nil), value...), nil
+}
+
+func pebbleHas(r pebbleReader, key []byte) (bool, error) {
+	_, err := pebbleGet(r, key)
+	if err == ErrNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func pebbleIterator(r pebbleReader, rng Range) Iterator {
+	iter, err := r.NewIter(&pebble.IterOptions{LowerBound: rng.Start, UpperBound: rng.Limit})
+	return &pebbleIter{iter: iter, err: err}
+}
+
+func (p *pebbleDB) Get(key []byte) ([]byte, error) {
+	return pebbleGet(p.db, key)
+}
+
+func (p *pebbleDB) Has(key []byte) (bool, error) {
+	return pebbleHas(p.db, key)
+}
+
+func (p *pebbleDB) NewIterator(r Range) Iterator {
+	return pebbleIterator(p.db, r)
+}
+
+func (p *pebbleDB) Put(key []byte, value []byte) error {
+	return p.db.Set(key, value, pebbleWriteOptions)
+}
+
+func (p *pebbleDB) Delete(key []byte) error {
+	return p.db.Delete(key, pebbleWriteOptions)
+}
+
+func (p *pebbleDB) NewBatch() Batch {
+	return new(batch)
+}
+
+func (p *pebbleDB) Write(b Batch) error {
+	ops, err := asBatch(b)
+	if err != nil {
+		return err
+	}
+	pb := p.db.NewBatch()
+	defer pb.Close()
+	for _, op := range ops.ops {
+		if op.delete {
+			err = pb.Delete(op.key, nil)
+		} else {
+			err = pb.Set(op.key, op.value, nil)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return pb.Commit(pebbleWriteOptions)
+}
+
+func (p *pebbleDB) NewSnapshot() (Snapshot, error) {
+	return &pebbleSnapshot{snap: p.db.NewSnapshot()}, nil
+}
+
+func (p *pebbleDB) Close() error {
+	return p.db.Close()
+}
+
+type pebbleSnapshot struct {
+	snap *pebble.Snapshot
+}
+
+func (s *pebbleSnapshot) Get(key []byte) ([]byte, error) {
+	return pebbleGet(s.snap, key)
+}
+
+func (s *pebbleSnapshot) Has(key []byte) (bool, error) {
+	return pebbleHas(s.snap, key)
+}
+
+func (s *pebbleSnapshot) NewIterator(r Range) Iterator {
+	return pebbleIterator(s.snap, r)
+}
+
+func (s *pebbleSnapshot) Release() {
+	s.snap.Close()
+}
+
+// pebbleIter adapts a Pebble iterator, which is positioned with First, to Iterator.
+type pebbleIter struct {
+	iter    *pebble.Iterator
+	err     error
+	started bool
+}
+
+func (i *pebbleIter) Next() bool {
+	if i.iter == nil {
+		return false
+	}
+	if !i.started {
+		i.started = true
+		return i.iter.First()
+	}
+	return i.iter.Next()
+}
+
+func (i *pebbleIter) Key() []byte {
+	return i.iter.Key()
+}
+
+func (i *pebbleIter) Value() []byte {
+	return i.iter.Value()
+}
+
+func (i *pebbleIter) Error() error {
+	if i.err != nil || i.iter == nil {
+		return i.err
+	}
+	return i.iter.Error()
+}
+
+func (i *pebbleIter) Release() {
+	if i.iter != nil {
+		if err := i.iter.Close(); err != nil && i.err == nil {
+			i.err = err
+		}
+		i.iter = nil
+	}
+}
@@ -0,0 +1,140 @@
+// Package store is the key-value storage behind the tracks databases. Every database is a Store
+// opened with the backend selected by BaseConfig.DBBackend.
+package store
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrNotFound is returned by Get when the key does not exist.
+var ErrNotFound = errors.New("store: key not found")
+
+// Backends accepted by Open.
+const (
+	BackendGoLevelDB = "goleveldb"
+	BackendPebble    = "pebbledb"
+	BackendMemory    = "memdb"
+)
+
+// Reader reads keys from a Store or a Snapshot.
+type Reader interface {
+	// Get returns the value of key, or ErrNotFound. The returned slice may be modified.
+	Get(key []byte) ([]byte, error)
+	Has(key []byte) (bool, error)
+	// NewIterator iterates the keys of r in ascending order. The iterator must be released.
+	NewIterator(r Range) Iterator
+}
+
+// Store is a key-value database.
+type Store interface {
+	Reader
+	Put(key []byte, value []byte) error
+	Delete(key []byte) error
+	// NewBatch returns an empty batch to be applied atomically with Write.
+	NewBatch() Batch
+	Write(batch Batch) error
+	// NewSnapshot returns a consistent read-only view of the store. It must be released.
+	NewSnapshot() (Snapshot, error)
+	Close() error
+}
+
+// Batch collects writes that a Store applies atomically.
+type Batch interface {
+	Put(key []byte, value []byte)
+	Delete(key []byte)
+	Len() int
+	Reset()
+}
+
+// Snapshot is a read-only view of a Store at the time it was taken.
+type Snapshot interface {
+	Reader
+	Release()
+}
+
+// Iterator walks a range of keys. Key and Value are only valid until the next call to Next.
+type Iterator interface {
+	Next() bool
+	Key() []byte
+	Value() []byte
+	Error() error
+	Release()
+}
+
+// Range is the key range [Start, Limit). A nil Start or Limit leaves that side unbounded.
+type Range struct {
+	Start []byte
+	Limit []byte
+}
+
+// Prefix returns the range of keys that start with prefix.
+func Prefix(prefix []byte) Range {
+	var limit []byte
+	for i := len(prefix) - 1; i >= 0; i-- {
+		if prefix[i] < 0xff {
+			limit = append([]byte(nil), prefix[:i+1]...)
+			limit[i]++
+			break
+		}
+	}
+	return Range{Start: prefix, Limit: limit}
+}
+
+// Open opens the store at path with the given backend. The memory backend ignores path.
+func Open(backend string, path string) (Store, error) {
+	switch strings.ToLower(backend) {
+	case "", BackendGoLevelDB, "leveldb":
+		return openLevelDB(path)
+	case BackendPebble, "pebble":
+		return openPebble(path)
+	case BackendMemory, "memory":
+		return NewMemory(), nil
+	}
+	return nil, fmt.Errorf("unknown db backend %q", backend)
+}
+
+// BackendDir returns the directory name the stores of a backend are kept under.
+func BackendDir(backend string) string {
+	switch strings.ToLower(backend) {
+	case BackendPebble, "pebble":
+		return "pebble"
+	}
+	return "leveldb"
+}
+
+// batch is the Batch of every backend; each backend replays its operations on Write.
+type batch struct {
+	ops []batchOp
+}
+
+type batchOp struct {
+	key    []byte
+	value  []byte
+	delete bool
+}
+
+func (b *batch) Put(key []byte, value []byte) {
+	b.ops = append(b.ops, batchOp{key: append([]byte(nil), key...), value: append([]byte(nil), value...)})
+}
+
+func (b *batch) Delete(key []byte) {
+	b.ops = append(b.ops, batchOp{key: append([]byte(nil), key...), delete: true})
+}
+
+func (b *batch) Len() int {
+	return len(b.ops)
+}
+
+func (b *batch) Reset() {
+	b.ops = b.ops[:0]
+}
+
+func asBatch(b Batch) (*batch, error) {
+	ops, ok := b.(*batch)
+	if !ok {
+		return nil, fmt.Errorf("store: unsupported batch %T", b)
+	}
+	return ops, nil
+}
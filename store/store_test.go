@@ -0,0 +1,118 @@
+package store_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/airchains-network/tracks/store"
+)
+
+func TestBackends(t *testing.T) {
+	for _, backend := range []string{store.BackendGoLevelDB, store.BackendPebble, store.BackendMemory} {
+		t.Run(backend, func(t *testing.T) {
+			db, err := store.Open(backend, filepath.Join(t.TempDir(), "db"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+
+			if _, err = db.Get([]byte("missing")); err != store.ErrNotFound {
+				t.Fatalf("Get of a missing key returned %v, want ErrNotFound", err)
+			}
+
+			batch := db.NewBatch()
+			batch.Put([]byte("a-1"), []byte("1"))
+			batch.Put([]byte("a-2"), []byte("2"))
+			batch.Put([]byte("b-1"), []byte("3"))
+			batch.Delete([]byte("a-2"))
+			if err = db.Write(batch); err != nil {
+				t.Fatal(err)
+			}
+
+			snapshot, err := db.NewSnapshot()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer snapshot.Release()
+
+			if err = db.Put([]byte("a-3"), []byte("4")); err != nil {
+				t.Fatal(err)
+			}
+			if err = db.Delete([]byte("a-1")); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := keys(t, db, store.Prefix([]byte("a-"))); got != "a-3" {
+				t.Errorf("store keys = %q, want %q", got, "a-3")
+			}
+			if got := keys(t, snapshot, store.Prefix([]byte("a-"))); got != "a-1" {
+				t.Errorf("snapshot keys = %q, want %q", got, "a-1")
+			}
+			if got := keys(t, db, store.Range{Start: []byte("a-3")}); got != "a-3 b-1" {
+				t.Errorf("open range keys = %q, want %q", got, "a-3 b-1")
+			}
+			if ok, err := snapshot.Has([]byte("a-3")); err != nil || ok {
+				t.Errorf("snapshot Has(a-3) = %v, %v, want false", ok, err)
+			}
+		})
+	}
+}
+
+func keys(t *testing.T, r store.Reader, rng store.Range) string {
+	iter := r.NewIterator(rng)
+	defer iter.Release()
+	var out string
+	for iter.Next() {
+		if out != "" {
+			out += " "
+		}
+		out += string(iter.Key())
+	}
+	if err := iter.Error(); err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+// flakyStore fails every Write while fail is set, like a process stopping between two stores.
+type flakyStore struct {
+	store.Store
+	fail bool
+}
+
+func (s *flakyStore) Write(b store.Batch) error {
+	if s.fail {
+		return errors.New("write failed")
+	}
+	return s.Store.Write(b)
+}
+
+func TestJournalReplay(t *testing.T) {
+	state, other := store.NewMemory(), &flakyStore{Store: store.NewMemory(), fail: true}
+	journal := store.NewJournal(state, "journal", map[string]store.Store{"a": state, "b": other})
+
+	batch := journal.NewBatch()
+	batch.Store("a").Put([]byte("count"), []byte("2"))
+	batch.Store("b").Put([]byte("pod-2"), []byte("pod"))
+	if err := journal.Write(batch); err == nil {
+		t.Fatal("Write succeeded with a failing store")
+	}
+	if ok, _ := state.Has([]byte("journal")); !ok {
+		t.Fatal("interrupted write left no journal record")
+	}
+
+	other.fail = false
+	if err := journal.Replay(); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := state.Get([]byte("count")); err != nil || string(v) != "2" {
+		t.Errorf("count = %q, %v, want 2", v, err)
+	}
+	if v, err := other.Get([]byte("pod-2")); err != nil || string(v) != "pod" {
+		t.Errorf("pod-2 = %q, %v, want pod", v, err)
+	}
+	if ok, _ := state.Has([]byte("journal")); ok {
+		t.Error("journal record left after replay")
+	}
+}
@@ -0,0 +1,21 @@
+package store
+
+import "testing"
+
+func TestBackendsSyncWrites(t *testing.T) {
+	tests := []struct {
+		backend string
+		sync    bool
+	}{
+		{BackendGoLevelDB, levelDBWriteOptions.Sync},
+		{BackendPebble, pebbleWriteOptions.Sync},
+	}
+	for _, tt := range tests {
+		if !tt.sync {
+			t.Errorf("%s writes return before they are synced to disk", tt.backend)
+		}
+	}
+	if levelDBWriteOptions.Sync != pebbleWriteOptions.Sync {
+		t.Errorf("goleveldb syncs writes: %v, pebble syncs writes: %v", levelDBWriteOptions.Sync, pebbleWriteOptions.Sync)
+	}
+}
@@ -27,6 +27,18 @@ type BlockStruct struct {
 	TransactionCount int    `json:"transactioncount"`
 	TransactionsRoot string `json:"transactionsroot"`
 	Uncles           string `json:"uncles"`
+
+	// SkippedTransactions lists the block transactions that were not indexed.
+	SkippedTransactions []SkippedTransactionStruct `json:"skippedtransactions,omitempty"`
+	// WithdrawalRecipients lists the addresses credited by the withdrawals of the block.
+	WithdrawalRecipients []string `json:"withdrawalrecipients,omitempty"`
+}
+
+type SkippedTransactionStruct struct {
+	Hash             string `json:"hash"`
+	TransactionIndex string `json:"transactionIndex"`
+	Type             string `json:"type"`
+	Reason           string `json:"reason"`
 }
 
 type TransactionStruct struct {
@@ -45,4 +57,43 @@ type TransactionStruct struct {
 	Type             string `json:"type"`
 	V                string `json:"v"`
 	Value            string `json:"value"`
+
+	// Typed transaction fields, empty for the transaction types that do not carry them.
+	ChainID              string              `json:"chainId,omitempty"`
+	AccessList           []AccessTupleStruct `json:"accessList,omitempty"`
+	MaxFeePerGas         string              `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas string              `json:"maxPriorityFeePerGas,omitempty"`
+	MaxFeePerBlobGas     string              `json:"maxFeePerBlobGas,omitempty"`
+	BlobVersionedHashes  []string            `json:"blobVersionedHashes,omitempty"`
+
+	// Receipt fields. Status is empty for transactions indexed before receipts were stored.
+	Status            string      `json:"status,omitempty"`
+	GasUsed           string      `json:"gasUsed,omitempty"`
+	EffectiveGasPrice string      `json:"effectiveGasPrice,omitempty"`
+	BlobGasUsed       string      `json:"blobGasUsed,omitempty"`
+	BlobGasPrice      string      `json:"blobGasPrice,omitempty"`
+	ContractAddress   string      `json:"contractAddress,omitempty"`
+	Logs              []LogStruct `json:"logs,omitempty"`
+}
+
+// Receipt status values of TransactionStruct.Status.
+const (
+	TxStatusReverted = "0"
+	TxStatusSuccess  = "1"
+)
+
+type AccessTupleStruct struct {
+	Address     string   `json:"address"`
+	StorageKeys []string `json:"storageKeys"`
+}
+
+type LogStruct struct {
+	Address          string   `json:"address"`
+	Topics           []string `json:"topics"`
+	Data             string   `json:"data"`
+	BlockNumber      uint64   `json:"blockNumber"`
+	BlockHash        string   `json:"blockHash"`
+	TransactionHash  string   `json:"transactionHash"`
+	TransactionIndex string   `json:"transactionIndex"`
+	LogIndex         string   `json:"logIndex"`
 }
@@ -6,6 +6,7 @@ import (
 
 type StationInfo struct {
 	StationType string `json:"stationType"`
+	PodSize     int    `json:"podSize,omitempty"`
 	//DaType      string `json:"daType"`
 }
 
@@ -0,0 +1,325 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// PodStateVersion is the version MarshalPodState writes into every record. Records of a newer
+// version are rejected instead of being read with fields missing.
+const PodStateVersion = 1
+
+// ErrPodStateVersion is returned for records written by a newer release.
+var ErrPodStateVersion = errors.New("unsupported pod record version")
+
+// Field numbers of the messages in pod.proto.
+const (
+	podFieldVersion protowire.Number = iota + 1
+	podFieldLatestPodHeight
+	podFieldLatestTxState
+	podFieldLatestPodHash
+	podFieldPreviousPodHash
+	podFieldLatestPodProof
+	podFieldLatestPublicWitness
+	podFieldVotes
+	podFieldTracksAppHash
+	podFieldBatch
+	podFieldMasterTrackAppHash
+	podFieldTimestamp
+	podFieldVRFInitiationTxHash
+	podFieldVRFValidationTxHash
+	podFieldInitPodTxHash
+	podFieldVerifyPodTxHash
+)
+
+const (
+	batchFieldFrom protowire.Number = iota + 1
+	batchFieldTo
+	batchFieldAmounts
+	batchFieldTransactionHash
+	batchFieldSenderBalances
+	batchFieldReceiverBalances
+	batchFieldMessages
+	batchFieldTransactionNonces
+	batchFieldAccountNonces
+	batchFieldTxnEndIndex
+	batchFieldTxnCount
+)
+
+// MarshalPodState encodes a pod record in the protobuf layout of pod.proto. Votes are written in
+// peer order so equal records encode to equal bytes.
+func MarshalPodState(p *PodState) []byte {
+	var b []byte
+	b = protowire.AppendTag(b, podFieldVersion, protowire.VarintType)
+	b = protowire.AppendVarint(b, PodStateVersion)
+	b = appendVarint(b, podFieldLatestPodHeight, p.LatestPodHeight)
+	b = appendBytes(b, podFieldLatestTxState, []byte(p.LatestTxState))
+	b = appendBytes(b, podFieldLatestPodHash, p.LatestPodHash)
+	b = appendBytes(b, podFieldPreviousPodHash, p.PreviousPodHash)
+	b = appendBytes(b, podFieldLatestPodProof, p.LatestPodProof)
+	b = appendBytes(b, podFieldLatestPublicWitness, p.LatestPublicWitness)
+
+	peers := make([]string, 0, len(p.Votes))
+	for peer := range p.Votes {
+		peers = append(peers, peer)
+	}
+	sort.Strings(peers)
+	for _, peer := range peers {
+		vote := p.Votes[peer]
+		var value []byte
+		value = appendBytes(value, 1, []byte(vote.PeerID))
+		if vote.Vote {
+			value = appendVarint(value, 2, 1)
+		}
+		var entry []byte
+		entry = appendMessage(entry, 1, []byte(peer))
+		entry = appendMessage(entry, 2, value)
+		b = appendMessage(b, podFieldVotes, entry)
+	}
+
+	b = appendBytes(b, podFieldTracksAppHash, p.TracksAppHash)
+	if p.Batch != nil {
+		b = appendMessage(b, podFieldBatch, marshalBatch(p.Batch))
+	}
+	b = appendBytes(b, podFieldMasterTrackAppHash, p.MasterTrackAppHash)
+	if p.Timestamp != nil {
+		var ts []byte
+		ts = appendVarint(ts, 1, uint64(p.Timestamp.Unix()))
+		ts = appendVarint(ts, 2, uint64(p.Timestamp.Nanosecond()))
+		b = appendMessage(b, podFieldTimestamp, ts)
+	}
+	b = appendBytes(b, podFieldVRFInitiationTxHash, []byte(p.VRFInitiationTxHash))
+	b = appendBytes(b, podFieldVRFValidationTxHash, []byte(p.VRFValidationTxHash))
+	b = appendBytes(b, podFieldInitPodTxHash, []byte(p.InitPodTxHash))
+	b = appendBytes(b, podFieldVerifyPodTxHash, []byte(p.VerifyPodTxHash))
+	return b
+}
+
+func marshalBatch(batch *BatchStruct) []byte {
+	var b []byte
+	for _, field := range []struct {
+		number protowire.Number
+		values []string
+	}{
+		{batchFieldFrom, batch.From},
+		{batchFieldTo, batch.To},
+		{batchFieldAmounts, batch.Amounts},
+		{batchFieldTransactionHash, batch.TransactionHash},
+		{batchFieldSenderBalances, batch.SenderBalances},
+		{batchFieldReceiverBalances, batch.ReceiverBalances},
+		{batchFieldMessages, batch.Messages},
+		{batchFieldTransactionNonces, batch.TransactionNonces},
+		{batchFieldAccountNonces, batch.AccountNonces},
+	} {
+		for _, value := range field.values {
+			b = appendMessage(b, field.number, []byte(value))
+		}
+	}
+	b = appendVarint(b, batchFieldTxnEndIndex, uint64(batch.TxnEndIndex))
+	return appendVarint(b, batchFieldTxnCount, uint64(batch.TxnCount))
+}
+
+// UnmarshalPodState decodes a pod record written by MarshalPodState, or a JSON record written
+// before the binary encoding was introduced.
+func UnmarshalPodState(data []byte) (*PodState, error) {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		var p PodState
+		if err := json.Unmarshal(trimmed, &p); err != nil {
+			return nil, err
+		}
+		return &p, nil
+	}
+
+	p := &PodState{Votes: make(map[string]Votes)}
+	var version uint64
+	err := consumeFields(data, func(number protowire.Number, v uint64, raw []byte) error {
+		switch number {
+		case podFieldVersion:
+			version = v
+		case podFieldLatestPodHeight:
+			p.LatestPodHeight = v
+		case podFieldLatestTxState:
+			p.LatestTxState = string(raw)
+		case podFieldLatestPodHash:
+			p.LatestPodHash = bytes.Clone(raw)
+		case podFieldPreviousPodHash:
+			p.PreviousPodHash = bytes.Clone(raw)
+		case podFieldLatestPodProof:
+			p.LatestPodProof = bytes.Clone(raw)
+		case podFieldLatestPublicWitness:
+			p.LatestPublicWitness = bytes.Clone(raw)
+		case podFieldVotes:
+			return unmarshalVote(raw, p.Votes)
+		case podFieldTracksAppHash:
+			p.TracksAppHash = bytes.Clone(raw)
+		case podFieldBatch:
+			batch, err := unmarshalBatch(raw)
+			if err != nil {
+				return err
+			}
+			p.Batch = batch
+		case podFieldMasterTrackAppHash:
+			p.MasterTrackAppHash = bytes.Clone(raw)
+		case podFieldTimestamp:
+			var seconds, nanos uint64
+			if err := consumeFields(raw, func(number protowire.Number, v uint64, _ []byte) error {
+				switch number {
+				case 1:
+					seconds = v
+				case 2:
+					nanos = v
+				}
+				return nil
+			}); err != nil {
+				return err
+			}
+			timestamp := time.Unix(int64(seconds), int64(int32(nanos)))
+			p.Timestamp = &timestamp
+		case podFieldVRFInitiationTxHash:
+			p.VRFInitiationTxHash = string(raw)
+		case podFieldVRFValidationTxHash:
+			p.VRFValidationTxHash = string(raw)
+		case podFieldInitPodTxHash:
+			p.InitPodTxHash = string(raw)
+		case podFieldVerifyPodTxHash:
+			p.VerifyPodTxHash = string(raw)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid pod record: %w", err)
+	}
+	if version == 0 {
+		return nil, errors.New("invalid pod record: no version")
+	}
+	if version > PodStateVersion {
+		return nil, fmt.Errorf("%w %d, this release reads up to %d", ErrPodStateVersion, version, PodStateVersion)
+	}
+	return p, nil
+}
+
+func unmarshalVote(entry []byte, votes map[string]Votes) error {
+	var peer string
+	var vote Votes
+	err := consumeFields(entry, func(number protowire.Number, _ uint64, raw []byte) error {
+		switch number {
+		case 1:
+			peer = string(raw)
+		case 2:
+			if err := consumeFields(raw, func(number protowire.Number, v uint64, raw []byte) error {
+				switch number {
+				case 1:
+					vote.PeerID = string(raw)
+				case 2:
+					vote.Vote = v != 0
+				}
+				return nil
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	votes[peer] = vote
+	return nil
+}
+
+func unmarshalBatch(data []byte) (*BatchStruct, error) {
+	batch := &BatchStruct{}
+	err := consumeFields(data, func(number protowire.Number, v uint64, raw []byte) error {
+		switch number {
+		case batchFieldFrom:
+			batch.From = append(batch.From, string(raw))
+		case batchFieldTo:
+			batch.To = append(batch.To, string(raw))
+		case batchFieldAmounts:
+			batch.Amounts = append(batch.Amounts, string(raw))
+		case batchFieldTransactionHash:
+			batch.TransactionHash = append(batch.TransactionHash, string(raw))
+		case batchFieldSenderBalances:
+			batch.SenderBalances = append(batch.SenderBalances, string(raw))
+		case batchFieldReceiverBalances:
+			batch.ReceiverBalances = append(batch.ReceiverBalances, string(raw))
+		case batchFieldMessages:
+			batch.Messages = append(batch.Messages, string(raw))
+		case batchFieldTransactionNonces:
+			batch.TransactionNonces = append(batch.TransactionNonces, string(raw))
+		case batchFieldAccountNonces:
+			batch.AccountNonces = append(batch.AccountNonces, string(raw))
+		case batchFieldTxnEndIndex:
+			batch.TxnEndIndex = int(int64(v))
+		case batchFieldTxnCount:
+			batch.TxnCount = int(int64(v))
+		}
+		return nil
+	})
+	return batch, err
+}
+
+// consumeFields calls fn with the value of every varint and length-delimited field of a message
+// and skips fields of other wire types, which this encoding does not use.
+func consumeFields(data []byte, fn func(number protowire.Number, v uint64, raw []byte) error) error {
+	for len(data) > 0 {
+		number, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+
+		var v uint64
+		var raw []byte
+		switch typ {
+		case protowire.VarintType:
+			v, n = protowire.ConsumeVarint(data)
+		case protowire.BytesType:
+			raw, n = protowire.ConsumeBytes(data)
+		default:
+			n = protowire.ConsumeFieldValue(number, typ, data)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			data = data[n:]
+			continue
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+		if err := fn(number, v, raw); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func appendVarint(b []byte, number protowire.Number, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, number, protowire.VarintType)
+	return protowire.AppendVarint(b, v)
+}
+
+// appendBytes appends a bytes or string field, which proto3 omits when empty.
+func appendBytes(b []byte, number protowire.Number, value []byte) []byte {
+	if len(value) == 0 {
+		return b
+	}
+	return appendMessage(b, number, value)
+}
+
+// appendMessage appends a length-delimited field even when it is empty, as required for
+// messages and repeated strings.
+func appendMessage(b []byte, number protowire.Number, value []byte) []byte {
+	b = protowire.AppendTag(b, number, protowire.BytesType)
+	return protowire.AppendBytes(b, value)
+}
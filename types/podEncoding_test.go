@@ -0,0 +1,85 @@
+package types_test
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/airchains-network/tracks/types"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func testPod() *types.PodState {
+	timestamp := time.Unix(1700000000, 123456789)
+	return &types.PodState{
+		LatestPodHeight:     7,
+		LatestTxState:       "InitPod",
+		LatestPodHash:       []byte("root"),
+		PreviousPodHash:     []byte("previous root"),
+		LatestPodProof:      []byte("proof"),
+		LatestPublicWitness: []byte("witness"),
+		Votes: map[string]types.Votes{
+			"peer-b": {PeerID: "peer-b"},
+			"peer-a": {PeerID: "peer-a", Vote: true},
+		},
+		TracksAppHash: []byte("app hash"),
+		Batch: &types.BatchStruct{
+			From:            []string{"0x1", ""},
+			To:              []string{"0x2", "0x3"},
+			TransactionHash: []string{"0xa", "0xb"},
+			TxnEndIndex:     175,
+			TxnCount:        2,
+		},
+		Timestamp:           &timestamp,
+		VRFInitiationTxHash: "vrf init",
+		InitPodTxHash:       "init pod",
+	}
+}
+
+func TestPodStateRoundTrip(t *testing.T) {
+	pod := testPod()
+	data := types.MarshalPodState(pod)
+	decoded, err := types.UnmarshalPodState(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, pod) {
+		t.Errorf("decoded %+v, want %+v", decoded, pod)
+	}
+	if again := types.MarshalPodState(decoded); string(again) != string(data) {
+		t.Error("re-encoding a decoded record changed its bytes")
+	}
+}
+
+func TestPodStateReadsJSON(t *testing.T) {
+	pod := testPod()
+	data, err := json.Marshal(pod)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := types.UnmarshalPodState(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !decoded.Timestamp.Equal(*pod.Timestamp) {
+		t.Errorf("timestamp = %v, want %v", decoded.Timestamp, pod.Timestamp)
+	}
+	decoded.Timestamp = pod.Timestamp
+	if !reflect.DeepEqual(decoded, pod) {
+		t.Errorf("decoded %+v, want %+v", decoded, pod)
+	}
+}
+
+func TestPodStateRejectsNewerVersion(t *testing.T) {
+	var data []byte
+	data = protowire.AppendTag(data, 1, protowire.VarintType)
+	data = protowire.AppendVarint(data, types.PodStateVersion+1)
+	if _, err := types.UnmarshalPodState(data); !errors.Is(err, types.ErrPodStateVersion) {
+		t.Errorf("UnmarshalPodState of a newer record returned %v, want ErrPodStateVersion", err)
+	}
+	if _, err := types.UnmarshalPodState([]byte{0xff}); err == nil {
+		t.Error("UnmarshalPodState accepted a truncated record")
+	}
+}
@@ -1,5 +1,7 @@
 package types
 
+import "time"
+
 type BatchStruct struct {
 	From              []string
 	To                []string
@@ -10,12 +12,24 @@ type BatchStruct struct {
 	Messages          []string
 	TransactionNonces []string
 	AccountNonces     []string
+
+	// TxnEndIndex is the last txns-N record covered by the batch. It is zero for batches built
+	// before skipped transactions were recorded, which cover exactly podSize records.
+	TxnEndIndex int `json:",omitempty"`
+
+	// TxnCount is the number of transactions in the batch, committed as a public input of the
+	// pod proof. It is below the station's pod size for a pod sealed after its MaxPodInterval,
+	// and zero for batches built before partial pods, which hold a full pod of transactions.
+	TxnCount int `json:",omitempty"`
 }
 
 type Votes struct {
 	PeerID string // TODO change this type to proper Peer ID Type
 	Vote   bool
 }
+
+// PodState is the pod record kept as podState in the state database and as pod-N in the pods
+// database. It is stored with MarshalPodState; RPC responses render it as JSON.
 type PodState struct {
 	LatestPodHeight     uint64
 	LatestTxState       string // InitVRF / VerifyVRF / InitPod / VerifyPod
@@ -27,4 +41,10 @@ type PodState struct {
 	TracksAppHash       []byte
 	Batch               *BatchStruct
 	MasterTrackAppHash  []byte
+	Timestamp           *time.Time `json:"timestamp,omitempty"`
+
+	VRFInitiationTxHash string
+	VRFValidationTxHash string
+	InitPodTxHash       string
+	VerifyPodTxHash     string
 }
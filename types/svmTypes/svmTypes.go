@@ -31,6 +31,19 @@ type LatestSlotStruct struct {
 	ID      int    `json:"id"`
 }
 
+type BlocksResponseStruct struct {
+	Jsonrpc string `json:"jsonrpc"`
+	Result  []int  `json:"result"`
+	Error   *Error `json:"error,omitempty"`
+	ID      int    `json:"id"`
+}
+
+// SkippedSlotStruct is stored in place of the block of a slot the station skipped.
+type SkippedSlotStruct struct {
+	Slot    int  `json:"slot"`
+	Skipped bool `json:"skipped"`
+}
+
 type BlockResponseStruct struct {
 	JsonRpc string `json:"jsonrpc"`
 	Result  struct {
@@ -41,7 +54,8 @@ type BlockResponseStruct struct {
 		PreviousBlockhash string                 `json:"previousBlockhash"`
 		Transactions      []SVMTransactionStruct `json:"transactions"`
 	} `json:"result"`
-	ID int `json:"id"`
+	Error *Error `json:"error,omitempty"`
+	ID    int    `json:"id"`
 }
 
 type SVMTransactionStruct struct {
@@ -71,6 +85,11 @@ type SVMTransactionStruct struct {
 			Instructions []struct {
 				Parsed struct {
 					Info struct {
+						// Source, Destination and Lamports are set for system program transfers.
+						Source      string `json:"source"`
+						Destination string `json:"destination"`
+						Lamports    uint64 `json:"lamports"`
+
 						VoteAccount     string `json:"voteAccount"`
 						VoteAuthority   string `json:"voteAuthority"`
 						VoteStateUpdate struct {
@@ -2,6 +2,7 @@ package utils
 
 import (
 	"context"
+	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
@@ -13,6 +14,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/ignite/cli/v28/ignite/pkg/cosmosaccount"
+	"github.com/mr-tron/base58"
 	"github.com/rs/zerolog/log"
 	"io"
 	"math/big"
@@ -253,6 +255,20 @@ func Bech32Decoder(value string) string {
 	return decodedBigInt.String()
 }
 
+// Base58Decoder returns a base58 SVM public key or signature as the decimal value of a
+// BLS12-381 scalar. Keys and signatures are 32 and 64 bytes, wider than the scalar field, so the
+// value is the first 31 bytes of their sha256 hash instead of the bytes themselves, which the
+// prover would silently reduce modulo the field order.
+func Base58Decoder(value string) (string, error) {
+	bytes, err := base58.Decode(value)
+	if err != nil {
+		return "", fmt.Errorf("invalid base58 value %q: %w", value, err)
+	}
+
+	hash := sha256.Sum256(bytes)
+	return new(big.Int).SetBytes(hash[:31]).String(), nil
+}
+
 func TXHashCheck(value string) string {
 	byteSlice, err := hex.DecodeString(value)
 	if err != nil {
@@ -263,22 +279,13 @@ func TXHashCheck(value string) string {
 	return decodedBigInt.String()
 }
 
-func AccountBalanceCheck(walletAddress string, blockHeight string, JsonAPI string) string {
-
+// AccountBalanceCheck returns the balance of a Cosmos account before the block at blockHeight,
+// as reported by the station API at JsonAPI. An account without balances has a balance of 0.
+func AccountBalanceCheck(ctx context.Context, walletAddress string, blockHeight string, JsonAPI string) (string, error) {
 	height, err := strconv.Atoi(blockHeight)
 	if err != nil {
-		logs.Log.Error(fmt.Sprintf("Error converting block height to integer: %v", err))
-	}
-
-	res, resErr := http.Get(
-		fmt.Sprintf(
-			"%s/cosmos/bank/v1beta1/balances/%s?height=%d", JsonAPI, walletAddress, height-1,
-		),
-	)
-	if resErr != nil {
-		logs.Log.Error(fmt.Sprintf("Error making HTTP request: %v", resErr))
+		return "", fmt.Errorf("invalid block height %q: %w", blockHeight, err)
 	}
-	defer res.Body.Close()
 
 	var accountBalance struct {
 		Balances []struct {
@@ -290,26 +297,19 @@ func AccountBalanceCheck(walletAddress string, blockHeight string, JsonAPI strin
 			Total   string `json:"total"`
 		} `json:"pagination"`
 	}
-
-	decodeError := json.NewDecoder(res.Body).Decode(&accountBalance)
-	if decodeError != nil {
-		logs.Log.Error(fmt.Sprintf("Error decoding JSON response: %v", decodeError))
+	url := fmt.Sprintf("%s/cosmos/bank/v1beta1/balances/%s?height=%d", JsonAPI, walletAddress, height-1)
+	if err = getJSON(ctx, url, &accountBalance); err != nil {
+		return "", err
 	}
-
-	return accountBalance.Balances[0].Amount
-}
-
-func AccountNounceCheck(walletAddress string, JsonAPI string) string {
-	res, resErr := http.Get(
-		fmt.Sprintf(
-			"%s/cosmos/auth/v1beta1/accounts/%s", JsonAPI, walletAddress,
-		),
-	)
-	if resErr != nil {
-		logs.Log.Error(fmt.Sprintf("Error making HTTP request: %v", resErr))
+	if len(accountBalance.Balances) == 0 {
+		return "0", nil
 	}
-	defer res.Body.Close()
+	return accountBalance.Balances[0].Amount, nil
+}
 
+// AccountNounceCheck returns the sequence of a Cosmos account as reported by the station API at
+// JsonAPI.
+func AccountNounceCheck(ctx context.Context, walletAddress string, JsonAPI string) (string, error) {
 	var accountNounce struct {
 		Account struct {
 			Type    string `json:"@type"`
@@ -322,21 +322,50 @@ func AccountNounceCheck(walletAddress string, JsonAPI string) string {
 			Sequence      string `json:"sequence"`
 		} `json:"account"`
 	}
-
-	decodeError := json.NewDecoder(res.Body).Decode(&accountNounce)
-	if decodeError != nil {
-		logs.Log.Error(fmt.Sprintf("Error decoding JSON response: %v", decodeError))
+	url := fmt.Sprintf("%s/cosmos/auth/v1beta1/accounts/%s", JsonAPI, walletAddress)
+	if err := getJSON(ctx, url, &accountNounce); err != nil {
+		return "", err
 	}
+	return accountNounce.Account.Sequence, nil
+}
 
-	return accountNounce.Account.Sequence
+// getJSON decodes the JSON response of a GET request to url.
+func getJSON(ctx context.Context, url string, result any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error making HTTP request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("error making HTTP request: status %s", res.Status)
+	}
+	if err = json.NewDecoder(res.Body).Decode(result); err != nil {
+		return fmt.Errorf("error decoding JSON response: %w", err)
+	}
+	return nil
 }
 
-const retryDelay = 2 * time.Second
+const (
+	retryDelay    = 2 * time.Second
+	retryMaxDelay = 30 * time.Second
+)
 
-// Retry function to retry any function that returns an error indefinitely until it succeeds
+// Retry function to retry any function that returns an error indefinitely until it succeeds. The
+// delay between attempts doubles up to retryMaxDelay so a down station is not hammered.
 func Retry(operation func() error) error {
+	return RetryContext(context.Background(), operation)
+}
+
+// RetryContext retries operation like Retry until it succeeds or ctx is cancelled, in which case
+// it returns the last error of operation.
+func RetryContext(ctx context.Context, operation func() error) error {
 	var err error
 	attempt := 0
+	delay := retryDelay
 	for {
 		err = operation()
 		if err == nil {
@@ -344,6 +373,13 @@ func Retry(operation func() error) error {
 		}
 		attempt++
 		log.Error().Msgf("Error occurred (attempt %d): %s", attempt, err.Error())
-		time.Sleep(retryDelay)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		delay = min(2*delay, retryMaxDelay)
 	}
 }
@@ -0,0 +1,241 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// endpointMaxFailures is the number of consecutive failed calls after which an endpoint is
+	// only used when no healthy endpoint is left.
+	endpointMaxFailures = 3
+	// endpointMaxLag is how many blocks an endpoint may trail the highest endpoint before it is
+	// considered lagging.
+	endpointMaxLag = 10
+)
+
+// EndpointProbe checks a single station endpoint for the health and consistency checks of an
+// EndpointPool.
+type EndpointProbe interface {
+	// LatestHeight returns the station head as seen by the endpoint.
+	LatestHeight(ctx context.Context, url string) (int, error)
+	// BlockHash returns the hash of the block at height as seen by the endpoint, or "" if the
+	// endpoint has no block at that height.
+	BlockHash(ctx context.Context, url string, height int) (string, error)
+}
+
+// EndpointStatus is the health of one endpoint of an EndpointPool.
+type EndpointStatus struct {
+	URL      string
+	Latency  time.Duration
+	Failures int
+	Height   int
+	// Lagging is set when the endpoint trails the other endpoints by more than endpointMaxLag.
+	Lagging bool
+	// Inconsistent is set when the endpoint disagreed with the majority on a block hash.
+	Inconsistent bool
+}
+
+func (s *EndpointStatus) healthy() bool {
+	return s.Failures < endpointMaxFailures && !s.Lagging && !s.Inconsistent
+}
+
+// EndpointPool spreads calls to a station over several equivalent endpoints. Calls go to the
+// healthy endpoint with the lowest latency and fail over to the next one on error. An optional
+// probe runs periodic health checks that also take lagging endpoints and endpoints that disagree
+// with the majority on a block hash out of rotation.
+type EndpointPool struct {
+	name  string
+	probe EndpointProbe
+
+	mu        sync.Mutex
+	endpoints []*EndpointStatus
+}
+
+// NewEndpointPool returns a pool over the given endpoint URLs. Empty and duplicate URLs are
+// ignored. probe may be nil, in which case endpoints are only scored on the calls made through
+// the pool.
+func NewEndpointPool(name string, urls []string, probe EndpointProbe) *EndpointPool {
+	pool := &EndpointPool{name: name, probe: probe}
+	seen := make(map[string]bool)
+	for _, url := range urls {
+		if url == "" || seen[url] {
+			continue
+		}
+		seen[url] = true
+		pool.endpoints = append(pool.endpoints, &EndpointStatus{URL: url})
+	}
+	return pool
+}
+
+// Status returns a snapshot of the endpoints of the pool.
+func (p *EndpointPool) Status() []EndpointStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	status := make([]EndpointStatus, 0, len(p.endpoints))
+	for _, endpoint := range p.endpoints {
+		status = append(status, *endpoint)
+	}
+	return status
+}
+
+// Primary returns the URL calls are currently sent to first.
+func (p *EndpointPool) Primary() string {
+	order := p.ordered()
+	if len(order) == 0 {
+		return ""
+	}
+	return order[0].URL
+}
+
+// Do calls fn with endpoint URLs in order of preference until it succeeds, and returns the last
+// error if every endpoint failed.
+func (p *EndpointPool) Do(ctx context.Context, fn func(url string) error) error {
+	order := p.ordered()
+	if len(order) == 0 {
+		return fmt.Errorf("no %s endpoint configured", p.name)
+	}
+
+	var err error
+	for _, endpoint := range order {
+		start := time.Now()
+		err = fn(endpoint.URL)
+		p.record(endpoint, time.Since(start), err)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return err
+		}
+		if len(order) > 1 {
+			log.Warn().Str("module", "utils").Err(err).Msg(fmt.Sprintf("%s endpoint %s failed, failing over", p.name, endpoint.URL))
+		}
+	}
+	return err
+}
+
+// Run performs a health check every interval until ctx is cancelled. It returns immediately if
+// the pool has no probe.
+func (p *EndpointPool) Run(ctx context.Context, interval time.Duration) {
+	if p.probe == nil {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		p.Check(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check probes every endpoint for its head and latency, marks endpoints that lag behind, and
+// compares the block hash every endpoint reports at the highest height they all have.
+func (p *EndpointPool) Check(ctx context.Context) {
+	if p.probe == nil {
+		return
+	}
+
+	p.mu.Lock()
+	endpoints := append([]*EndpointStatus(nil), p.endpoints...)
+	p.mu.Unlock()
+
+	heights := make(map[*EndpointStatus]int)
+	maxHeight := 0
+	for _, endpoint := range endpoints {
+		start := time.Now()
+		height, err := p.probe.LatestHeight(ctx, endpoint.URL)
+		p.record(endpoint, time.Since(start), err)
+		if err != nil {
+			continue
+		}
+		heights[endpoint] = height
+		maxHeight = max(maxHeight, height)
+	}
+
+	commonHeight := -1
+	p.mu.Lock()
+	for endpoint, height := range heights {
+		endpoint.Height = height
+		endpoint.Lagging = maxHeight-height > endpointMaxLag
+		if !endpoint.Lagging && (commonHeight < 0 || height < commonHeight) {
+			commonHeight = height
+		}
+	}
+	p.mu.Unlock()
+	if len(heights) < 2 || commonHeight < 0 {
+		return
+	}
+
+	hashes := make(map[*EndpointStatus]string)
+	votes := make(map[string]int)
+	for endpoint := range heights {
+		if endpoint.Lagging {
+			continue
+		}
+		hash, err := p.probe.BlockHash(ctx, endpoint.URL, commonHeight)
+		if err != nil || hash == "" {
+			continue
+		}
+		hashes[endpoint] = hash
+		votes[hash]++
+	}
+	majority, best := "", 0
+	for hash, count := range votes {
+		if count > best {
+			majority, best = hash, count
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for endpoint, hash := range hashes {
+		inconsistent := len(votes) > 1 && hash != majority
+		if inconsistent && !endpoint.Inconsistent {
+			log.Warn().Str("module", "utils").Msg(fmt.Sprintf("%s endpoint %s reports block %d as %s, majority has %s", p.name, endpoint.URL, commonHeight, hash, majority))
+		}
+		endpoint.Inconsistent = inconsistent
+	}
+}
+
+// ordered returns the endpoints healthy first, each group by ascending latency.
+func (p *EndpointPool) ordered() []*EndpointStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	order := append([]*EndpointStatus(nil), p.endpoints...)
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := order[i], order[j]
+		if a.healthy() != b.healthy() {
+			return a.healthy()
+		}
+		if !a.healthy() && a.Failures != b.Failures {
+			return a.Failures < b.Failures
+		}
+		return a.Latency < b.Latency
+	})
+	return order
+}
+
+// record scores an endpoint on the outcome and latency of a call.
+func (p *EndpointPool) record(endpoint *EndpointStatus, latency time.Duration, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err != nil {
+		endpoint.Failures++
+		return
+	}
+	endpoint.Failures = 0
+	if endpoint.Latency == 0 {
+		endpoint.Latency = latency
+	} else {
+		endpoint.Latency = (endpoint.Latency*7 + latency) / 8
+	}
+}
@@ -0,0 +1,249 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// fakeProbe reports fixed heads and block hashes per endpoint URL. Endpoints without a head fail.
+type fakeProbe struct {
+	heights map[string]int
+	hashes  map[string]string
+}
+
+func (f *fakeProbe) LatestHeight(_ context.Context, url string) (int, error) {
+	height, ok := f.heights[url]
+	if !ok {
+		return 0, fmt.Errorf("%s is down", url)
+	}
+	return height, nil
+}
+
+func (f *fakeProbe) BlockHash(_ context.Context, url string, _ int) (string, error) {
+	return f.hashes[url], nil
+}
+
+func urls(status []*EndpointStatus) []string {
+	var out []string
+	for _, endpoint := range status {
+		out = append(out, endpoint.URL)
+	}
+	return out
+}
+
+func TestNewEndpointPool(t *testing.T) {
+	pool := NewEndpointPool("station rpc", []string{"a", "", "b", "a"}, nil)
+	if got := fmt.Sprint(urls(pool.ordered())); got != "[a b]" {
+		t.Errorf("pool endpoints are %s, want [a b]", got)
+	}
+	if err := NewEndpointPool("station rpc", nil, nil).Do(context.Background(), func(string) error { return nil }); err == nil {
+		t.Error("Do on an empty pool returned no error")
+	}
+}
+
+func TestEndpointPoolDo(t *testing.T) {
+	tests := []struct {
+		name         string
+		failing      map[string]bool
+		wantCalls    string
+		wantErr      bool
+		wantFailures []int
+	}{
+		{"first endpoint healthy", nil, "[a]", false, []int{0, 0, 0}},
+		{"fails over in order", map[string]bool{"a": true}, "[a b]", false, []int{1, 0, 0}},
+		{"fails over twice", map[string]bool{"a": true, "b": true}, "[a b c]", false, []int{1, 1, 0}},
+		{"every endpoint fails", map[string]bool{"a": true, "b": true, "c": true}, "[a b c]", true, []int{1, 1, 1}},
+	}
+	for _, tt := range tests {
+		pool := NewEndpointPool("station rpc", []string{"a", "b", "c"}, nil)
+		var calls []string
+		err := pool.Do(context.Background(), func(url string) error {
+			calls = append(calls, url)
+			if tt.failing[url] {
+				return errors.New("connection refused")
+			}
+			return nil
+		})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: Do returned error %v", tt.name, err)
+		}
+		if fmt.Sprint(calls) != tt.wantCalls {
+			t.Errorf("%s: called %v, want %s", tt.name, calls, tt.wantCalls)
+		}
+		for i, status := range pool.Status() {
+			if status.Failures != tt.wantFailures[i] {
+				t.Errorf("%s: endpoint %s has %d failures, want %d", tt.name, status.URL, status.Failures, tt.wantFailures[i])
+			}
+		}
+	}
+}
+
+func TestEndpointPoolDoStopsOnCancel(t *testing.T) {
+	pool := NewEndpointPool("station rpc", []string{"a", "b"}, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	err := pool.Do(ctx, func(string) error {
+		calls++
+		cancel()
+		return context.Canceled
+	})
+	if err == nil || calls != 1 {
+		t.Errorf("Do after cancellation made %d calls and returned %v, want 1 call and an error", calls, err)
+	}
+}
+
+func TestEndpointPoolOrdered(t *testing.T) {
+	tests := []struct {
+		name      string
+		endpoints []EndpointStatus
+		want      string
+	}{
+		{
+			"by latency",
+			[]EndpointStatus{{URL: "a", Latency: 30}, {URL: "b", Latency: 10}, {URL: "c", Latency: 20}},
+			"[b c a]",
+		},
+		{
+			"healthy first",
+			[]EndpointStatus{{URL: "a", Latency: 1, Failures: endpointMaxFailures}, {URL: "b", Latency: 20}, {URL: "c", Latency: 10, Lagging: true}},
+			"[b c a]",
+		},
+		{
+			"unhealthy by failures",
+			[]EndpointStatus{{URL: "a", Failures: endpointMaxFailures + 2}, {URL: "b", Failures: endpointMaxFailures}, {URL: "c", Inconsistent: true}},
+			"[c b a]",
+		},
+		{
+			"failures below the threshold keep an endpoint healthy",
+			[]EndpointStatus{{URL: "a", Latency: 20, Failures: endpointMaxFailures - 1}, {URL: "b", Latency: 10, Inconsistent: true}},
+			"[a b]",
+		},
+	}
+	for _, tt := range tests {
+		pool := &EndpointPool{name: "station rpc"}
+		for i := range tt.endpoints {
+			pool.endpoints = append(pool.endpoints, &tt.endpoints[i])
+		}
+		if got := fmt.Sprint(urls(pool.ordered())); got != tt.want {
+			t.Errorf("%s: ordered %s, want %s", tt.name, got, tt.want)
+		}
+		if got, want := pool.Primary(), tt.want[1:2]; got != want {
+			t.Errorf("%s: primary is %s, want %s", tt.name, got, want)
+		}
+	}
+}
+
+func TestEndpointPoolRecord(t *testing.T) {
+	pool := NewEndpointPool("station rpc", []string{"a"}, nil)
+	endpoint := pool.endpoints[0]
+	steps := []struct {
+		latency      time.Duration
+		err          error
+		wantLatency  time.Duration
+		wantFailures int
+	}{
+		{80, nil, 80, 0},
+		{160, nil, 90, 0},
+		{1000, errors.New("timeout"), 90, 1},
+		{1000, errors.New("timeout"), 90, 2},
+		{10, nil, 80, 0},
+	}
+	for i, step := range steps {
+		pool.record(endpoint, step.latency, step.err)
+		if endpoint.Latency != step.wantLatency || endpoint.Failures != step.wantFailures {
+			t.Errorf("after call %d latency is %d with %d failures, want %d with %d", i, endpoint.Latency, endpoint.Failures, step.wantLatency, step.wantFailures)
+		}
+	}
+}
+
+func TestEndpointPoolCheck(t *testing.T) {
+	tests := []struct {
+		name             string
+		heights          map[string]int
+		hashes           map[string]string
+		wantLagging      string
+		wantInconsistent string
+		wantFailures     string
+	}{
+		{
+			"all in sync",
+			map[string]int{"a": 100, "b": 100, "c": 98},
+			map[string]string{"a": "h", "b": "h", "c": "h"},
+			"[]", "[]", "[]",
+		},
+		{
+			"lagging endpoint",
+			map[string]int{"a": 100, "b": 100, "c": 100 - endpointMaxLag - 1},
+			map[string]string{"a": "h", "b": "h", "c": "other"},
+			"[c]", "[]", "[]",
+		},
+		{
+			"endpoint on another fork",
+			map[string]int{"a": 100, "b": 100, "c": 100},
+			map[string]string{"a": "h", "b": "fork", "c": "h"},
+			"[]", "[b]", "[]",
+		},
+		{
+			"endpoint down",
+			map[string]int{"a": 100, "b": 100},
+			map[string]string{"a": "h", "b": "h"},
+			"[]", "[]", "[c]",
+		},
+		{
+			"single endpoint answering",
+			map[string]int{"a": 100},
+			map[string]string{"a": "h"},
+			"[]", "[]", "[b c]",
+		},
+	}
+	for _, tt := range tests {
+		pool := NewEndpointPool("station rpc", []string{"a", "b", "c"}, &fakeProbe{heights: tt.heights, hashes: tt.hashes})
+		pool.Check(context.Background())
+
+		var lagging, inconsistent, failing []string
+		for _, status := range pool.Status() {
+			if status.Lagging {
+				lagging = append(lagging, status.URL)
+			}
+			if status.Inconsistent {
+				inconsistent = append(inconsistent, status.URL)
+			}
+			if status.Failures > 0 {
+				failing = append(failing, status.URL)
+			}
+			if height, ok := tt.heights[status.URL]; ok && status.Height != height {
+				t.Errorf("%s: endpoint %s is at height %d, want %d", tt.name, status.URL, status.Height, height)
+			}
+		}
+		if fmt.Sprint(lagging) != tt.wantLagging {
+			t.Errorf("%s: lagging endpoints are %v, want %s", tt.name, lagging, tt.wantLagging)
+		}
+		if fmt.Sprint(inconsistent) != tt.wantInconsistent {
+			t.Errorf("%s: inconsistent endpoints are %v, want %s", tt.name, inconsistent, tt.wantInconsistent)
+		}
+		if fmt.Sprint(failing) != tt.wantFailures {
+			t.Errorf("%s: failing endpoints are %v, want %s", tt.name, failing, tt.wantFailures)
+		}
+	}
+}
+
+func TestEndpointPoolCheckRecovers(t *testing.T) {
+	probe := &fakeProbe{
+		heights: map[string]int{"a": 100, "b": 100, "c": 100},
+		hashes:  map[string]string{"a": "h", "b": "fork", "c": "h"},
+	}
+	pool := NewEndpointPool("station rpc", []string{"b", "a", "c"}, probe)
+	pool.Check(context.Background())
+	if primary := pool.Primary(); primary == "b" {
+		t.Error("the endpoint on another fork is still the primary")
+	}
+
+	probe.hashes["b"] = "h"
+	pool.Check(context.Background())
+	if status := pool.Status()[0]; status.Inconsistent {
+		t.Error("endpoint b is still inconsistent after it agreed with the majority")
+	}
+}
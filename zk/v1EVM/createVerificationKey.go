@@ -2,24 +2,28 @@ package v1EVM
 
 import (
 	"encoding/json"
+	"github.com/airchains-network/tracks/config"
 	logs "github.com/airchains-network/tracks/log"
 	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/consensys/gnark/backend/groth16"
 	"os"
 )
 
-// CreateVkPkNew generates and saves a new Proving Key and Verification Key if either file doesn't exist
-func CreateVkPkNew() {
-	homeDir, _ := os.UserHomeDir()
-	provingKeyFile := homeDir + "/.tracks/config/provingKey.txt"
-	verificationKeyFile := homeDir + "/.tracks/config/verificationKey.json"
+// CreateVkPkNew generates and saves a new Proving Key and Verification Key for pods of podSize
+// transactions if either file doesn't exist
+func CreateVkPkNew(podSize int) {
+	provingKeyFile, verificationKeyFile, err := config.ProverKeyPaths(podSize)
+	if err != nil {
+		logs.Log.Error("Unable to locate the key files: " + err.Error())
+		return
+	}
 
 	_, err1 := os.Stat(provingKeyFile)
 	_, err2 := os.Stat(verificationKeyFile)
 
 	// If either file doesn't exist, generate and save new keys
 	if os.IsNotExist(err1) || os.IsNotExist(err2) {
-		provingKey, verificationKey, err := GenerateVerificationKey()
+		provingKey, verificationKey, err := GenerateVerificationKey(podSize)
 		if err != nil {
 			return
 		}
@@ -49,10 +53,12 @@ func CreateVkPkNew() {
 	}
 }
 
-func GetVkPk() (groth16.ProvingKey, groth16.VerifyingKey, error) {
-	homeDir, _ := os.UserHomeDir()
-	provingKeyFile := homeDir + "/.tracks/config/provingKey.txt"
-	verificationKeyFile := homeDir + "/.tracks/config/verificationKey.json"
+// GetVkPk reads the keys generated for pods of podSize transactions.
+func GetVkPk(podSize int) (groth16.ProvingKey, groth16.VerifyingKey, error) {
+	provingKeyFile, verificationKeyFile, err := config.ProverKeyPaths(podSize)
+	if err != nil {
+		return nil, nil, err
+	}
 
 	// Read Proving Key
 	pk, err := ReadProvingKeyFromFile2(provingKeyFile)
@@ -96,6 +102,12 @@ func ReadVerificationKeyFromFile(filename string) (groth16.VerifyingKey, error)
 	if err != nil {
 		return nil, err
 	}
+	// the JSON holds the curve points only, the pairing the verifier checks against is derived
+	if precomputed, ok := vk.(interface{ Precompute() error }); ok {
+		if err = precomputed.Precompute(); err != nil {
+			return nil, err
+		}
+	}
 
 	return vk, nil
 }
@@ -18,13 +18,31 @@ import (
 	"strconv"
 )
 
+// MyCircuit proves a pod of len(To) transactions. Create it with NewCircuit, as the slices must
+// be sized before the circuit is compiled.
 type MyCircuit struct {
-	To              [config.PODSize]frontend.Variable `gnark:",public"`
-	From            [config.PODSize]frontend.Variable `gnark:",public"`
-	Amount          [config.PODSize]frontend.Variable `gnark:",public"`
-	TransactionHash [config.PODSize]frontend.Variable `gnark:",public"`
-	FromBalances    [config.PODSize]frontend.Variable `gnark:",public"`
-	ToBalances      [config.PODSize]frontend.Variable `gnark:",public"`
+	To              []frontend.Variable `gnark:",public"`
+	From            []frontend.Variable `gnark:",public"`
+	Amount          []frontend.Variable `gnark:",public"`
+	TransactionHash []frontend.Variable `gnark:",public"`
+	FromBalances    []frontend.Variable `gnark:",public"`
+	ToBalances      []frontend.Variable `gnark:",public"`
+
+	// TxnCount is the number of transactions in the pod. The slots after them are padding and
+	// must be zero.
+	TxnCount frontend.Variable `gnark:",public"`
+}
+
+// NewCircuit returns a circuit for pods of podSize transactions.
+func NewCircuit(podSize int) *MyCircuit {
+	return &MyCircuit{
+		To:              make([]frontend.Variable, podSize),
+		From:            make([]frontend.Variable, podSize),
+		Amount:          make([]frontend.Variable, podSize),
+		TransactionHash: make([]frontend.Variable, podSize),
+		FromBalances:    make([]frontend.Variable, podSize),
+		ToBalances:      make([]frontend.Variable, podSize),
+	}
 }
 
 type TransactionSecond struct {
@@ -80,7 +98,13 @@ func GetMerkleRootSecond(transactions []TransactionSecond) string {
 }
 
 func (circuit *MyCircuit) Define(api frontend.API) error {
-	for i := 0; i < config.PODSize; i++ {
+	api.AssertIsLessOrEqual(circuit.TxnCount, len(circuit.To))
+	for i := range circuit.To {
+		// padding is 1 for the slots at or after TxnCount
+		padding := api.Sub(1, api.IsZero(api.Sub(api.Cmp(circuit.TxnCount, i), 1)))
+		api.AssertIsEqual(api.Mul(padding, circuit.TransactionHash[i]), 0)
+		api.AssertIsEqual(api.Mul(padding, circuit.Amount[i]), 0)
+
 		api.AssertIsLessOrEqual(circuit.Amount[i], circuit.FromBalances[i]) //TODO  Here is one error1
 
 		api.Sub(circuit.FromBalances[i], circuit.Amount[i])
@@ -96,48 +120,22 @@ func (circuit *MyCircuit) Define(api frontend.API) error {
 	return nil
 }
 
-func ComputeCCS() constraint.ConstraintSystem {
-	var circuit MyCircuit
-	ccs, _ := frontend.Compile(ecc.BLS12_381.ScalarField(), r1cs.NewBuilder, &circuit)
+func ComputeCCS(podSize int) constraint.ConstraintSystem {
+	ccs, _ := frontend.Compile(ecc.BLS12_381.ScalarField(), r1cs.NewBuilder, NewCircuit(podSize))
 
 	return ccs
 }
 
-func GenerateVerificationKey() (groth16.ProvingKey, groth16.VerifyingKey, error) {
-	ccs := ComputeCCS()
+func GenerateVerificationKey(podSize int) (groth16.ProvingKey, groth16.VerifyingKey, error) {
+	ccs := ComputeCCS(podSize)
 	pk, vk, error := groth16.Setup(ccs)
 	return pk, vk, error
 }
 
-func GenerateProof(inputData types.BatchStruct, batchNum int) (any, string, []byte, error) {
-	ccs := ComputeCCS()
+// GenerateProof proves a pod of at most podSize transactions with the keys generated for podSize.
+func GenerateProof(inputData types.BatchStruct, batchNum int, podSize int) (any, string, []byte, error) {
+	ccs := ComputeCCS(podSize)
 	log.Info().Str("batchNum", strconv.Itoa(batchNum)).Msg("Generating proof")
-	var transactions []TransactionSecond
-
-	for i := 0; i < config.PODSize; i++ {
-
-		transaction := TransactionSecond{
-			To:              inputData.To[i],
-			From:            inputData.From[i],
-			Amount:          inputData.Amounts[i],
-			FromBalances:    inputData.SenderBalances[i],
-			ToBalances:      inputData.ReceiverBalances[i],
-			TransactionHash: inputData.TransactionHash[i],
-		}
-		transactions = append(transactions, transaction)
-	}
-
-	currentStatusHash := GetMerkleRootSecond(transactions)
-
-	//pk, err := ReadProvingKeyFromFile("provingKey.txt")
-	homeDir, _ := os.UserHomeDir()
-	provingKeyFile := homeDir + "/.tracks/config/provingKey.txt"
-	pk, err := ReadProvingKeyFromFile(provingKeyFile)
-
-	if err != nil {
-		fmt.Println("Error reading proving key:", err)
-		return nil, "", nil, err
-	}
 
 	var inputValueLength int
 
@@ -164,9 +162,40 @@ func GenerateProof(inputData types.BatchStruct, batchNum int) (any, string, []by
 		fmt.Println("Error: Input data is not correct")
 		return nil, "", nil, fmt.Errorf("input data is not correct")
 	}
+	if inputValueLength == 0 || inputValueLength > podSize {
+		return nil, "", nil, fmt.Errorf("pod holds %d transactions, want 1 to %d", inputValueLength, podSize)
+	}
 
-	if inputValueLength < config.PODSize {
-		leftOver := config.PODSize - inputValueLength
+	// the state hash covers the transactions of the pod, not the padding
+	var transactions []TransactionSecond
+	for i := 0; i < inputValueLength; i++ {
+
+		transaction := TransactionSecond{
+			To:              inputData.To[i],
+			From:            inputData.From[i],
+			Amount:          inputData.Amounts[i],
+			FromBalances:    inputData.SenderBalances[i],
+			ToBalances:      inputData.ReceiverBalances[i],
+			TransactionHash: inputData.TransactionHash[i],
+		}
+		transactions = append(transactions, transaction)
+	}
+
+	currentStatusHash := GetMerkleRootSecond(transactions)
+
+	provingKeyFile, _, err := config.ProverKeyPaths(podSize)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	pk, err := ReadProvingKeyFromFile(provingKeyFile)
+
+	if err != nil {
+		fmt.Println("Error reading proving key:", err)
+		return nil, "", nil, err
+	}
+
+	if inputValueLength < podSize {
+		leftOver := podSize - inputValueLength
 		for i := 0; i < leftOver; i++ {
 			inputData.From = append(inputData.From, "0")
 			inputData.To = append(inputData.To, "0")
@@ -180,16 +209,10 @@ func GenerateProof(inputData types.BatchStruct, batchNum int) (any, string, []by
 		}
 	}
 
-	inputs := MyCircuit{
-		To:              [config.PODSize]frontend.Variable{},
-		From:            [config.PODSize]frontend.Variable{},
-		Amount:          [config.PODSize]frontend.Variable{},
-		TransactionHash: [config.PODSize]frontend.Variable{},
-		FromBalances:    [config.PODSize]frontend.Variable{},
-		ToBalances:      [config.PODSize]frontend.Variable{},
-	}
+	inputs := NewCircuit(podSize)
+	inputs.TxnCount = inputValueLength
 
-	for i := 0; i < config.PODSize; i++ {
+	for i := 0; i < podSize; i++ {
 		inputs.To[i] = frontend.Variable(inputData.To[i])
 		inputs.From[i] = frontend.Variable(inputData.From[i])
 		inputs.Amount[i] = frontend.Variable(inputData.Amounts[i])
@@ -198,7 +221,7 @@ func GenerateProof(inputData types.BatchStruct, batchNum int) (any, string, []by
 		inputs.ToBalances[i] = frontend.Variable(inputData.ReceiverBalances[i])
 	}
 
-	witness, err := frontend.NewWitness(&inputs, ecc.BLS12_381.ScalarField())
+	witness, err := frontend.NewWitness(inputs, ecc.BLS12_381.ScalarField())
 	if err != nil {
 		fmt.Printf("Error creating a witness: %v\n", err)
 		return nil, "", nil, err
@@ -215,7 +238,7 @@ func GenerateProof(inputData types.BatchStruct, batchNum int) (any, string, []by
 		fmt.Println("Error marshalling public witness:", err)
 		return nil, "", nil, err
 	}
-	err = publicWitnessDb.Put([]byte(publicWitnessDbKey), publicWitnessDbValue, nil)
+	err = publicWitnessDb.Put([]byte(publicWitnessDbKey), publicWitnessDbValue)
 	if err != nil {
 		fmt.Println("Error saving public witness:", err)
 		return nil, "", nil, err
@@ -233,7 +256,7 @@ func GenerateProof(inputData types.BatchStruct, batchNum int) (any, string, []by
 		fmt.Println("Error marshalling proof:", err)
 		return nil, "", nil, err
 	}
-	err = proofDb.Put([]byte(proofDbKey), proofDbValue, nil)
+	err = proofDb.Put([]byte(proofDbKey), proofDbValue)
 	if err != nil {
 		fmt.Println("Error saving proof:", err)
 		return nil, "", nil, err
@@ -0,0 +1,74 @@
+package v1EVM
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/airchains-network/tracks/config"
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/frontend"
+)
+
+// testWitness returns the assignment of a pod of podSize slots holding one transaction.
+func testWitness(t *testing.T, podSize int) *MyCircuit {
+	t.Helper()
+	assignment := NewCircuit(podSize)
+	assignment.TxnCount = 1
+	for i := 0; i < podSize; i++ {
+		assignment.To[i], assignment.From[i], assignment.TransactionHash[i] = 0, 0, 0
+		assignment.Amount[i], assignment.FromBalances[i], assignment.ToBalances[i] = 0, 0, 0
+	}
+	assignment.To[0], assignment.From[0], assignment.TransactionHash[0] = 2, 1, 7
+	assignment.Amount[0], assignment.FromBalances[0], assignment.ToBalances[0] = 5, 10, 0
+	return assignment
+}
+
+func TestCircuitPerPodSize(t *testing.T) {
+	for _, podSize := range []int{2, 3} {
+		// six public inputs per slot, the transaction count and the constant wire
+		if got, want := ComputeCCS(podSize).GetNbPublicVariables(), 6*podSize+2; got != want {
+			t.Errorf("circuit of pod size %d has %d public variables, want %d", podSize, got, want)
+		}
+	}
+}
+
+func TestStoredKeysProvePodSize(t *testing.T) {
+	if testing.Short() {
+		t.Skip("groth16 setup is slow")
+	}
+	home := t.TempDir()
+	config.SetHomeDir(home)
+	defer config.SetHomeDir("")
+	if err := os.MkdirAll(filepath.Join(home, config.DefaultConfigDir), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	const podSize = 2
+	CreateVkPkNew(podSize)
+	pk, vk, err := GetVkPk(podSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	witness, err := frontend.NewWitness(testWitness(t, podSize), ecc.BLS12_381.ScalarField())
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof, err := groth16.Prove(ComputeCCS(podSize), pk, witness)
+	if err != nil {
+		t.Fatal(err)
+	}
+	public, err := witness.Public()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = groth16.Verify(proof, vk, public); err != nil {
+		t.Errorf("proof does not verify with the stored verification key: %v", err)
+	}
+
+	// the keys of one size are not picked up for another
+	if _, _, err = GetVkPk(podSize + 1); err == nil {
+		t.Error("keys of pod size 3 were read although only size 2 was generated")
+	}
+}
@@ -0,0 +1,53 @@
+package v1SVM
+
+import (
+	"encoding/json"
+	"github.com/airchains-network/tracks/config"
+	logs "github.com/airchains-network/tracks/log"
+	"os"
+)
+
+// CreateVkPkSvm generates and saves a new Proving Key and Verification Key for pods of podSize
+// transactions if either file doesn't exist
+func CreateVkPkSvm(podSize int) {
+	provingKeyFile, verificationKeyFile, err := config.ProverKeyPaths(podSize)
+	if err != nil {
+		logs.Log.Error("Unable to locate the key files: " + err.Error())
+		return
+	}
+
+	_, err1 := os.Stat(provingKeyFile)
+	_, err2 := os.Stat(verificationKeyFile)
+
+	// If either file doesn't exist, generate and save new keys
+	if os.IsNotExist(err1) || os.IsNotExist(err2) {
+		provingKey, verificationKey, err := GenerateVerificationKey(podSize)
+		if err != nil {
+			logs.Log.Error("Unable to generate the keys: " + err.Error())
+			return
+		}
+
+		// Save Proving Key
+		pkFile, err := os.Create(provingKeyFile)
+		if err != nil {
+			logs.Log.Error("Unable to create Proving Key file" + err.Error())
+			return
+		}
+		_, err = provingKey.WriteTo(pkFile)
+		pkFile.Close()
+		if err != nil {
+			logs.Log.Error("Unable to write Proving Key" + err.Error())
+			return
+		}
+
+		// Save Verification Key
+		file, _ := json.MarshalIndent(verificationKey, "", " ")
+		err = os.WriteFile(verificationKeyFile, file, 0644)
+		if err != nil {
+			logs.Log.Error("Unable to write Verification Key to file" + err.Error())
+		}
+		logs.Log.Info("Proving key and Verification key generated and saved successfully\n")
+	} else {
+		logs.Log.Info("Both Proving key and Verification key already exist. No action needed.")
+	}
+}
@@ -0,0 +1,238 @@
+package v1SVM
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/airchains-network/tracks/blocksync"
+	"github.com/airchains-network/tracks/config"
+	"github.com/airchains-network/tracks/types"
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/rs/zerolog/log"
+	"os"
+	"strconv"
+)
+
+// MyCircuit proves a pod of len(To) SVM transfers. Create it with NewCircuit, as the slices must
+// be sized before the circuit is compiled.
+type MyCircuit struct {
+	To              []frontend.Variable `gnark:",public"`
+	From            []frontend.Variable `gnark:",public"`
+	Amount          []frontend.Variable `gnark:",public"`
+	TransactionHash []frontend.Variable `gnark:",public"`
+	FromBalances    []frontend.Variable `gnark:",public"`
+	ToBalances      []frontend.Variable `gnark:",public"`
+
+	// TxnCount is the number of transactions in the pod. The slots after them are padding and
+	// must be zero.
+	TxnCount frontend.Variable `gnark:",public"`
+}
+
+// NewCircuit returns a circuit for pods of podSize transactions.
+func NewCircuit(podSize int) *MyCircuit {
+	return &MyCircuit{
+		To:              make([]frontend.Variable, podSize),
+		From:            make([]frontend.Variable, podSize),
+		Amount:          make([]frontend.Variable, podSize),
+		TransactionHash: make([]frontend.Variable, podSize),
+		FromBalances:    make([]frontend.Variable, podSize),
+		ToBalances:      make([]frontend.Variable, podSize),
+	}
+}
+
+// Transaction is the part of an SVM transfer covered by the pod state hash.
+type Transaction struct {
+	To              string
+	From            string
+	Amount          string
+	FromBalances    string
+	ToBalances      string
+	TransactionHash string
+}
+
+func getTransactionHash(tx Transaction) string {
+	h1 := sha256.Sum256([]byte(tx.To))
+	h2 := sha256.Sum256([]byte(tx.From))
+	h3 := sha256.Sum256([]byte(tx.Amount))
+	h4 := sha256.Sum256([]byte(tx.FromBalances))
+	h5 := sha256.Sum256([]byte(tx.ToBalances))
+	h6 := sha256.Sum256([]byte(tx.TransactionHash))
+	h := sha256.New()
+	h.Write(h1[:])
+	h.Write(h2[:])
+	h.Write(h3[:])
+	h.Write(h4[:])
+	h.Write(h5[:])
+	h.Write(h6[:])
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// GetMerkleRoot returns the pod state hash, the sha256 merkle root of the transactions.
+func GetMerkleRoot(transactions []Transaction) string {
+	var merkleTree []string
+
+	for _, tx := range transactions {
+		merkleTree = append(merkleTree, getTransactionHash(tx))
+	}
+
+	for len(merkleTree) > 1 {
+		var tempTree []string
+		for i := 0; i < len(merkleTree); i += 2 {
+			if i+1 == len(merkleTree) {
+				tempTree = append(tempTree, merkleTree[i])
+			} else {
+				combinedHash := merkleTree[i] + merkleTree[i+1]
+				h := sha256.New()
+				h.Write([]byte(combinedHash))
+				tempTree = append(tempTree, hex.EncodeToString(h.Sum(nil)))
+			}
+		}
+		merkleTree = tempTree
+	}
+
+	return merkleTree[0]
+}
+
+// Define checks that every sender held the lamports it transferred before the transaction.
+func (circuit *MyCircuit) Define(api frontend.API) error {
+	api.AssertIsLessOrEqual(circuit.TxnCount, len(circuit.To))
+	for i := range circuit.To {
+		// padding is 1 for the slots at or after TxnCount
+		padding := api.Sub(1, api.IsZero(api.Sub(api.Cmp(circuit.TxnCount, i), 1)))
+		api.AssertIsEqual(api.Mul(padding, circuit.TransactionHash[i]), 0)
+		api.AssertIsEqual(api.Mul(padding, circuit.Amount[i]), 0)
+
+		api.AssertIsLessOrEqual(circuit.Amount[i], circuit.FromBalances[i])
+	}
+
+	return nil
+}
+
+func ComputeCCS(podSize int) constraint.ConstraintSystem {
+	ccs, _ := frontend.Compile(ecc.BLS12_381.ScalarField(), r1cs.NewBuilder, NewCircuit(podSize))
+
+	return ccs
+}
+
+func GenerateVerificationKey(podSize int) (groth16.ProvingKey, groth16.VerifyingKey, error) {
+	ccs := ComputeCCS(podSize)
+	return groth16.Setup(ccs)
+}
+
+// GenerateProof proves a pod of at most podSize transfers with the keys generated for podSize.
+// From, To and TransactionHash hold the decimal value of the base58 keys and signature.
+func GenerateProof(inputData types.BatchStruct, batchNum int, podSize int) (any, string, []byte, error) {
+	ccs := ComputeCCS(podSize)
+	log.Info().Str("batchNum", strconv.Itoa(batchNum)).Msg("Generating proof")
+
+	inputValueLength := len(inputData.From)
+	if len(inputData.To) != inputValueLength ||
+		len(inputData.Amounts) != inputValueLength ||
+		len(inputData.TransactionHash) != inputValueLength ||
+		len(inputData.SenderBalances) != inputValueLength ||
+		len(inputData.ReceiverBalances) != inputValueLength {
+		return nil, "", nil, fmt.Errorf("input data is not correct")
+	}
+	if inputValueLength == 0 || inputValueLength > podSize {
+		return nil, "", nil, fmt.Errorf("pod holds %d transactions, want 1 to %d", inputValueLength, podSize)
+	}
+
+	// the state hash covers the transactions of the pod, not the padding
+	transactions := make([]Transaction, 0, inputValueLength)
+	for i := 0; i < inputValueLength; i++ {
+		transactions = append(transactions, Transaction{
+			To:              inputData.To[i],
+			From:            inputData.From[i],
+			Amount:          inputData.Amounts[i],
+			FromBalances:    inputData.SenderBalances[i],
+			ToBalances:      inputData.ReceiverBalances[i],
+			TransactionHash: inputData.TransactionHash[i],
+		})
+	}
+	currentStatusHash := GetMerkleRoot(transactions)
+
+	provingKeyFile, _, err := config.ProverKeyPaths(podSize)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	pk, err := ReadProvingKeyFromFile(provingKeyFile)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("error reading proving key: %w", err)
+	}
+
+	inputs := NewCircuit(podSize)
+	inputs.TxnCount = inputValueLength
+	for i := 0; i < podSize; i++ {
+		if i >= inputValueLength {
+			inputs.To[i], inputs.From[i], inputs.Amount[i], inputs.TransactionHash[i] = 0, 0, 0, 0
+			inputs.FromBalances[i], inputs.ToBalances[i] = 0, 0
+			continue
+		}
+		inputs.To[i] = frontend.Variable(inputData.To[i])
+		inputs.From[i] = frontend.Variable(inputData.From[i])
+		inputs.Amount[i] = frontend.Variable(inputData.Amounts[i])
+		inputs.TransactionHash[i] = frontend.Variable(inputData.TransactionHash[i])
+		inputs.FromBalances[i] = frontend.Variable(inputData.SenderBalances[i])
+		inputs.ToBalances[i] = frontend.Variable(inputData.ReceiverBalances[i])
+	}
+
+	witness, err := frontend.NewWitness(inputs, ecc.BLS12_381.ScalarField())
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("error creating a witness: %w", err)
+	}
+
+	witnessVector := witness.Vector()
+
+	publicWitness, _ := witness.Public()
+
+	publicWitnessDb := blocksync.GetPublicWitnessDbInstance()
+	publicWitnessDbKey := fmt.Sprintf("public_witness_%d", batchNum)
+	publicWitnessDbValue, err := json.Marshal(publicWitness)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("error marshalling public witness: %w", err)
+	}
+	err = publicWitnessDb.Put([]byte(publicWitnessDbKey), publicWitnessDbValue)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("error saving public witness: %w", err)
+	}
+
+	proof, err := groth16.Prove(ccs, pk, witness)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("error generating proof: %w", err)
+	}
+
+	proofDb := blocksync.GetProofDbInstance()
+	proofDbKey := fmt.Sprintf("proof_%d", batchNum)
+	proofDbValue, err := json.Marshal(proof)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("error marshalling proof: %w", err)
+	}
+	err = proofDb.Put([]byte(proofDbKey), proofDbValue)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("error saving proof: %w", err)
+	}
+
+	return witnessVector, currentStatusHash, proofDbValue, nil
+}
+
+func ReadProvingKeyFromFile(filename string) (groth16.ProvingKey, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	pk := groth16.NewProvingKey(ecc.BLS12_381)
+	_, err = pk.ReadFrom(file)
+	if err != nil {
+		return nil, err
+	}
+
+	return pk, nil
+}
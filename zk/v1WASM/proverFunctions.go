@@ -25,16 +25,37 @@ import (
 	"github.com/consensys/gnark/std/signature/eddsa"
 )
 
+// MyCircuit proves a pod of len(To) transactions. Create it with NewCircuit, as the slices must
+// be sized before the circuit is compiled.
 type MyCircuit struct {
-	To              [config.PODSize]frontend.Variable `gnark:",public"`
-	From            [config.PODSize]frontend.Variable `gnark:",public"`
-	Amount          [config.PODSize]frontend.Variable `gnark:",public"`
-	TransactionHash [config.PODSize]frontend.Variable `gnark:",public"`
-	FromBalances    [config.PODSize]frontend.Variable `gnark:",public"`
-	ToBalances      [config.PODSize]frontend.Variable `gnark:",public"`
-	Messages        [config.PODSize]frontend.Variable `gnark:",public"`
-	PublicKeys      [config.PODSize]eddsa.PublicKey   `gnark:",public"`
-	Signatures      [config.PODSize]eddsa.Signature   `gnark:",public"`
+	To              []frontend.Variable `gnark:",public"`
+	From            []frontend.Variable `gnark:",public"`
+	Amount          []frontend.Variable `gnark:",public"`
+	TransactionHash []frontend.Variable `gnark:",public"`
+	FromBalances    []frontend.Variable `gnark:",public"`
+	ToBalances      []frontend.Variable `gnark:",public"`
+	Messages        []frontend.Variable `gnark:",public"`
+	PublicKeys      []eddsa.PublicKey   `gnark:",public"`
+	Signatures      []eddsa.Signature   `gnark:",public"`
+
+	// TxnCount is the number of transactions in the pod. The slots after them are padding and
+	// must be zero.
+	TxnCount frontend.Variable `gnark:",public"`
+}
+
+// NewCircuit returns a circuit for pods of podSize transactions.
+func NewCircuit(podSize int) *MyCircuit {
+	return &MyCircuit{
+		To:              make([]frontend.Variable, podSize),
+		From:            make([]frontend.Variable, podSize),
+		Amount:          make([]frontend.Variable, podSize),
+		TransactionHash: make([]frontend.Variable, podSize),
+		FromBalances:    make([]frontend.Variable, podSize),
+		ToBalances:      make([]frontend.Variable, podSize),
+		Messages:        make([]frontend.Variable, podSize),
+		PublicKeys:      make([]eddsa.PublicKey, podSize),
+		Signatures:      make([]eddsa.Signature, podSize),
+	}
 }
 
 func getTransactionHash(tx types.GetTransactionStruct) string {
@@ -69,7 +90,7 @@ func GetMerkleRootCheck(transactions []types.GetTransactionStruct) string {
 	return merkleTree[0]
 }
 
-func GetMerkleRoot(api frontend.API, leaves [config.PODSize]frontend.Variable) frontend.Variable {
+func GetMerkleRoot(api frontend.API, leaves []frontend.Variable) frontend.Variable {
 
 	if len(leaves) == 0 {
 		return nil
@@ -103,8 +124,13 @@ func GetMerkleRoot(api frontend.API, leaves [config.PODSize]frontend.Variable) f
 }
 
 func (circuit *MyCircuit) Define(api frontend.API) error {
-	var leaves [config.PODSize]frontend.Variable
-	for i := 0; i < config.PODSize; i++ {
+	leaves := make([]frontend.Variable, len(circuit.To))
+	api.AssertIsLessOrEqual(circuit.TxnCount, len(circuit.To))
+	for i := range circuit.To {
+		// padding is 1 for the slots at or after TxnCount
+		padding := api.Sub(1, api.IsZero(api.Sub(api.Cmp(circuit.TxnCount, i), 1)))
+		api.AssertIsEqual(api.Mul(padding, circuit.TransactionHash[i]), 0)
+		api.AssertIsEqual(api.Mul(padding, circuit.Amount[i]), 0)
 
 		//Signature Verification
 		curve, err := twistededwards.NewEdCurve(api, tedwards.ID(ecc.BLS12_381))
@@ -152,15 +178,14 @@ func (circuit *MyCircuit) Define(api frontend.API) error {
 	return nil
 }
 
-func ComputeCCS() constraint.ConstraintSystem {
-	var circuit MyCircuit
-	ccs, _ := frontend.Compile(ecc.BLS12_381.ScalarField(), r1cs.NewBuilder, &circuit)
+func ComputeCCS(podSize int) constraint.ConstraintSystem {
+	ccs, _ := frontend.Compile(ecc.BLS12_381.ScalarField(), r1cs.NewBuilder, NewCircuit(podSize))
 
 	return ccs
 }
 
-func GenerateVerificationKey() (groth16.ProvingKey, groth16.VerifyingKey, error) {
-	ccs := ComputeCCS()
+func GenerateVerificationKey(podSize int) (groth16.ProvingKey, groth16.VerifyingKey, error) {
+	ccs := ComputeCCS(podSize)
 	// groth16 zkSNARK: Setup
 	pk, vk, error := groth16.Setup(ccs)
 	return pk, vk, error
@@ -169,10 +194,39 @@ func GenerateVerificationKey() (groth16.ProvingKey, groth16.VerifyingKey, error)
 // GenerateProof generates a proof for the given input data
 // and returns the proof and the error
 // batchDbCount is the number of batches in the database and it will be passed as batchNum here
-func GenerateProof(inputData types.BatchStruct, batchNum int) (any, string, []byte, error) {
-	ccs := ComputeCCS()
+// podSize selects the circuit and keys, the batch may hold fewer transactions
+func GenerateProof(inputData types.BatchStruct, batchNum int, podSize int) (any, string, []byte, error) {
+	ccs := ComputeCCS(podSize)
+	var inputValueLength int
+	fromLength := len(inputData.From)
+	toLength := len(inputData.To)
+	amountsLength := len(inputData.Amounts)
+	txHashLength := len(inputData.TransactionHash)
+	senderBalancesLength := len(inputData.SenderBalances)
+	receiverBalancesLength := len(inputData.ReceiverBalances)
+	messagesLength := len(inputData.Messages)
+	txNoncesLength := len(inputData.TransactionNonces)
+	accountNoncesLength := len(inputData.AccountNonces)
+	if fromLength == toLength &&
+		fromLength == amountsLength &&
+		fromLength == txHashLength &&
+		fromLength == senderBalancesLength &&
+		fromLength == receiverBalancesLength &&
+		fromLength == messagesLength &&
+		fromLength == txNoncesLength &&
+		fromLength == accountNoncesLength {
+		inputValueLength = fromLength
+	} else {
+		fmt.Println("Error: Input data is not correct")
+		return nil, "", nil, fmt.Errorf("input data is not correct")
+	}
+	if inputValueLength == 0 || inputValueLength > podSize {
+		return nil, "", nil, fmt.Errorf("pod holds %d transactions, want 1 to %d", inputValueLength, podSize)
+	}
+
+	// the state hash covers the transactions of the pod, not the padding
 	var transactions []types.GetTransactionStruct
-	for i := 0; i < config.PODSize; i++ {
+	for i := 0; i < inputValueLength; i++ {
 		transaction := types.GetTransactionStruct{
 			To:              inputData.To[i],
 			From:            inputData.From[i],
@@ -184,8 +238,10 @@ func GenerateProof(inputData types.BatchStruct, batchNum int) (any, string, []by
 		transactions = append(transactions, transaction)
 	}
 	currentStatusHash := GetMerkleRootCheck(transactions)
-	homeDir, _ := os.UserHomeDir()
-	provingKeyFile := homeDir + "/.tracks/config/provingKey.txt"
+	provingKeyFile, _, err := config.ProverKeyPaths(podSize)
+	if err != nil {
+		return nil, "", nil, err
+	}
 	pk, err := ReadProvingKeyFromFile(provingKeyFile)
 	if err != nil {
 		fmt.Println("Error reading proving key:", err)
@@ -203,32 +259,8 @@ func GenerateProof(inputData types.BatchStruct, batchNum int) (any, string, []by
 		fmt.Println("Error getting snark field")
 		return nil, "", nil, err
 	}
-	var inputValueLength int
-	fromLength := len(inputData.From)
-	toLength := len(inputData.To)
-	amountsLength := len(inputData.Amounts)
-	txHashLength := len(inputData.TransactionHash)
-	senderBalancesLength := len(inputData.SenderBalances)
-	receiverBalancesLength := len(inputData.ReceiverBalances)
-	messagesLength := len(inputData.Messages)
-	txNoncesLength := len(inputData.TransactionNonces)
-	accountNoncesLength := len(inputData.AccountNonces)
-	if fromLength == toLength &&
-		fromLength == amountsLength &&
-		fromLength == txHashLength &&
-		fromLength == senderBalancesLength &&
-		fromLength == receiverBalancesLength &&
-		fromLength == messagesLength &&
-		fromLength == txNoncesLength &&
-		fromLength == accountNoncesLength {
-		inputValueLength = fromLength
-	} else {
-		fmt.Println("Error: Input data is not correct")
-		return nil, "", nil, fmt.Errorf("input data is not correct")
-	}
-
-	if inputValueLength < config.PODSize {
-		leftOver := config.PODSize - inputValueLength
+	if inputValueLength < podSize {
+		leftOver := podSize - inputValueLength
 		for i := 0; i < leftOver; i++ {
 			inputData.From = append(inputData.From, "0")
 			inputData.To = append(inputData.To, "0")
@@ -242,19 +274,10 @@ func GenerateProof(inputData types.BatchStruct, batchNum int) (any, string, []by
 		}
 	}
 
-	inputs := MyCircuit{
-		To:              [config.PODSize]frontend.Variable{},
-		From:            [config.PODSize]frontend.Variable{},
-		Amount:          [config.PODSize]frontend.Variable{},
-		TransactionHash: [config.PODSize]frontend.Variable{},
-		FromBalances:    [config.PODSize]frontend.Variable{},
-		ToBalances:      [config.PODSize]frontend.Variable{},
-		Signatures:      [config.PODSize]eddsa.Signature{},
-		PublicKeys:      [config.PODSize]eddsa.PublicKey{},
-		Messages:        [config.PODSize]frontend.Variable{},
-	}
+	inputs := NewCircuit(podSize)
+	inputs.TxnCount = inputValueLength
 
-	for i := 0; i < config.PODSize; i++ {
+	for i := 0; i < podSize; i++ {
 		inputs.To[i] = frontend.Variable(inputData.To[i])
 		inputs.From[i] = frontend.Variable(inputData.From[i])
 		inputs.Amount[i] = frontend.Variable(inputData.Amounts[i])
@@ -285,7 +308,7 @@ func GenerateProof(inputData types.BatchStruct, batchNum int) (any, string, []by
 	}
 
 	// witness definition
-	witness, err := frontend.NewWitness(&inputs, ecc.BLS12_381.ScalarField())
+	witness, err := frontend.NewWitness(inputs, ecc.BLS12_381.ScalarField())
 	if err != nil {
 		fmt.Printf("Error creating a witness: %v\n", err)
 		return nil, "", nil, err
@@ -301,7 +324,7 @@ func GenerateProof(inputData types.BatchStruct, batchNum int) (any, string, []by
 		return nil, "", nil, err
 
 	}
-	err = publicWitnessDb.Put([]byte(publicWitnessDbKey), publicWitnessDbValue, nil)
+	err = publicWitnessDb.Put([]byte(publicWitnessDbKey), publicWitnessDbValue)
 	if err != nil {
 		fmt.Println("Error saving public witness:", err)
 		return nil, "", nil, err
@@ -322,7 +345,7 @@ func GenerateProof(inputData types.BatchStruct, batchNum int) (any, string, []by
 		return nil, "", nil, err
 
 	}
-	err = proofDb.Put([]byte(proofDbKey), proofDbValue, nil)
+	err = proofDb.Put([]byte(proofDbKey), proofDbValue)
 	if err != nil {
 		fmt.Println("Error saving proof:", err)
 		return nil, "", nil, err